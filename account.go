@@ -1,15 +1,22 @@
 package wxpay
 
 import (
+	"crypto/tls"
 	"io/ioutil"
 )
 
 type Account struct {
-	appID     string
-	mchID     string
-	apiKey    string
-	certData  []byte
-	isSandbox bool
+	appID         string
+	mchID         string
+	apiKey        string
+	cert          *tls.Certificate // 解析后的商户证书
+	isSandbox     bool
+	sandboxMchID  string          // 仿真测试使用的商户号，为空时使用mchID
+	sandboxApiKey string          // 仿真测试使用的密钥，为空时使用apiKey
+	subAppID      string          // 服务商模式下子商户的公众账号ID
+	subMchID      string          // 服务商模式下子商户的商户号
+	miniAppID     string          // 小程序的appid，为空时使用appID
+	products      map[string]bool // 已开通的产品，为nil时不检查
 }
 
 // 创建微信支付账号
@@ -28,11 +35,57 @@ func (a *Account) SetCertFile(certPath string) error {
 	if err != nil {
 		return err
 	}
-	a.certData = certData
+	return a.SetCertData(certData)
+}
+
+// 设置PKCS12格式的商户证书，证书在设置时解析，密码为商户号
+func (a *Account) SetCertData(certData []byte) error {
+	cert, err := parsePkcs12(certData, a.mchID)
+	if err != nil {
+		return err
+	}
+	a.cert = &cert
 	return nil
 }
 
-// set cert data
-func (a *Account) SetCertData(certData []byte) {
-	a.certData = certData
+// 设置仿真测试环境使用的商户号和密钥，证书仍使用正式环境的证书
+func (a *Account) SetSandboxCredentials(mchID string, apiKey string) {
+	a.sandboxMchID = mchID
+	a.sandboxApiKey = apiKey
+}
+
+// 设置服务商模式下默认的子商户，请求时自动填充sub_appid、sub_mch_id，subAppID可以为空。
+// 请求中已有的sub_appid、sub_mch_id不会被覆盖，可以使用Params.SetSubMerchant按请求指定子商户
+func (a *Account) SetSubMerchant(subAppID string, subMchID string) {
+	a.subAppID = subAppID
+	a.subMchID = subMchID
+}
+
+// 设置小程序的appid，小程序与公众号使用不同appid时设置，小程序下单使用UnifiedOrderMiniProgram
+func (a *Account) SetMiniProgramAppID(appID string) {
+	a.miniAppID = appID
+}
+
+// 小程序支付使用的appid
+func (a *Account) miniProgramAppID() string {
+	if a.miniAppID != "" {
+		return a.miniAppID
+	}
+	return a.appID
+}
+
+// 当前环境使用的商户号
+func (a *Account) activeMchID() string {
+	if a.isSandbox && a.sandboxMchID != "" {
+		return a.sandboxMchID
+	}
+	return a.mchID
+}
+
+// 当前环境使用的签名密钥
+func (a *Account) activeApiKey() string {
+	if a.isSandbox && a.sandboxApiKey != "" {
+		return a.sandboxApiKey
+	}
+	return a.apiKey
 }
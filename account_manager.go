@@ -0,0 +1,279 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// 多个商户号之间分配订单，用于分散单个商户号的频率限制。
+// 下单时按权重轮询选择商户号，并记录订单所属商户号，之后的查询、关单、退款使用同一个商户号。
+// 路由只按out_trade_no记录，查询、关单、退款的参数必须包含out_trade_no；
+// 只有transaction_id、out_refund_no或refund_id时，应先用Lookup找到商户号再直接调用Client
+type AccountManager struct {
+	mu      sync.Mutex
+	routeMu sync.Mutex // sticky不是AtomicKVStore时，串行记录新订单的商户号
+	clients []*weightedClient
+	byMchID map[string]*managedAccount
+	sticky  KVStore
+}
+
+var errEmptyOutTradeNo = errors.New("AccountManager routes by out_trade_no, which is empty")
+
+// 商户号已被Disable停用
+var ErrAccountDisabled = errors.New("account is disabled")
+
+type weightedClient struct {
+	client  *Client
+	weight  int
+	current int
+}
+
+type managedAccount struct {
+	client   *Client
+	disabled bool
+	inflight sync.WaitGroup // 通过AccountManager发出、尚未完成的请求
+}
+
+// 创建AccountManager，sticky保存订单与商户号的对应关系，为nil时只保存在内存中。
+// 多个实例共享sticky时应实现AtomicKVStore，否则同一订单同时下单可能记录不同的商户号
+func NewAccountManager(sticky KVStore) *AccountManager {
+	if sticky == nil {
+		sticky = NewMemoryStore()
+	}
+	return &AccountManager{byMchID: make(map[string]*managedAccount), sticky: sticky}
+}
+
+// 添加商户号，weight为下单时的权重，所有商户号权重相同时为轮询
+func (m *AccountManager) Add(client *Client, weight int) {
+	if weight < 1 {
+		weight = 1
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clients = append(m.clients, &weightedClient{client: client, weight: weight})
+	m.byMchID[client.account.mchID] = &managedAccount{client: client}
+}
+
+// 停用商户号，例如怀疑密钥泄露时。停用后不再分配新订单，Route、Lookup以及通过AccountManager的调用
+// 返回ErrAccountDisabled；已经发出的请求继续完成，可以用Drain等待
+func (m *AccountManager) Disable(mchID string) error {
+	return m.setDisabled(mchID, true)
+}
+
+// 重新启用Disable停用的商户号
+func (m *AccountManager) Enable(mchID string) error {
+	return m.setDisabled(mchID, false)
+}
+
+func (m *AccountManager) setDisabled(mchID string, disabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.byMchID[mchID]
+	if !ok {
+		return errors.New("account " + mchID + " is not in AccountManager")
+	}
+	a.disabled = disabled
+	return nil
+}
+
+// 等待商户号上通过AccountManager发出的请求全部完成，ctx结束时返回ctx.Err()。通常在Disable之后调用
+func (m *AccountManager) Drain(ctx context.Context, mchID string) error {
+	m.mu.Lock()
+	a, ok := m.byMchID[mchID]
+	m.mu.Unlock()
+	if !ok {
+		return errors.New("account " + mchID + " is not in AccountManager")
+	}
+	done := make(chan struct{})
+	go func() {
+		a.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 平滑加权轮询，跳过已停用的商户号
+func (m *AccountManager) next() (*Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.clients) == 0 {
+		return nil, errors.New("no account in AccountManager")
+	}
+	total := 0
+	var best *weightedClient
+	for _, wc := range m.clients {
+		if m.byMchID[wc.client.account.mchID].disabled {
+			continue
+		}
+		wc.current += wc.weight
+		total += wc.weight
+		if best == nil || wc.current > best.current {
+			best = wc
+		}
+	}
+	if best == nil {
+		return nil, ErrAccountDisabled
+	}
+	best.current -= total
+	return best.client, nil
+}
+
+func routeKey(outTradeNo string) string {
+	return "wxpay/route/" + outTradeNo
+}
+
+// 为新订单选择商户号并记录
+func (m *AccountManager) Route(ctx context.Context, outTradeNo string) (*Client, error) {
+	if outTradeNo == "" {
+		return nil, errEmptyOutTradeNo
+	}
+	if c, err := m.Lookup(ctx, outTradeNo); err == nil {
+		return c, nil
+	} else if !errors.Is(err, ErrKeyNotFound) {
+		return nil, err
+	}
+	if store, ok := m.sticky.(AtomicKVStore); ok {
+		c, err := m.next()
+		if err != nil {
+			return nil, err
+		}
+		// 同时下单时以先写入的商户号为准
+		mchID, err := store.SetIfAbsent(ctx, routeKey(outTradeNo), []byte(c.account.mchID))
+		if err != nil {
+			return nil, err
+		}
+		return m.clientOf(string(mchID))
+	}
+
+	m.routeMu.Lock()
+	defer m.routeMu.Unlock()
+	if c, err := m.Lookup(ctx, outTradeNo); err == nil {
+		return c, nil
+	} else if !errors.Is(err, ErrKeyNotFound) {
+		return nil, err
+	}
+	c, err := m.next()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.sticky.Set(ctx, routeKey(outTradeNo), []byte(c.account.mchID)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// 查找订单所属的商户号，订单未记录时返回ErrKeyNotFound
+func (m *AccountManager) Lookup(ctx context.Context, outTradeNo string) (*Client, error) {
+	if outTradeNo == "" {
+		return nil, errEmptyOutTradeNo
+	}
+	mchID, err := m.sticky.Get(ctx, routeKey(outTradeNo))
+	if err != nil {
+		return nil, err
+	}
+	return m.clientOf(string(mchID))
+}
+
+// 商户号对应的Client，商户号已停用时返回ErrAccountDisabled
+func (m *AccountManager) clientOf(mchID string) (*Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.byMchID[mchID]
+	if !ok {
+		return nil, errors.New("account " + mchID + " is not in AccountManager")
+	}
+	if a.disabled {
+		return nil, fmt.Errorf("account %s: %w", mchID, ErrAccountDisabled)
+	}
+	return a.client, nil
+}
+
+// 记录一次进行中的请求，返回的函数在请求完成后调用。商户号已停用时返回ErrAccountDisabled
+func (m *AccountManager) acquire(c *Client) (func(), error) {
+	mchID := c.account.mchID
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a := m.byMchID[mchID]
+	if a.disabled {
+		return nil, fmt.Errorf("account %s: %w", mchID, ErrAccountDisabled)
+	}
+	a.inflight.Add(1)
+	return a.inflight.Done, nil
+}
+
+// 统一下单，按权重选择商户号
+func (m *AccountManager) UnifiedOrder(ctx context.Context, params Params) (Params, error) {
+	c, err := m.Route(ctx, params.GetString("out_trade_no"))
+	if err != nil {
+		return nil, err
+	}
+	done, err := m.acquire(c)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return c.UnifiedOrderContext(ctx, params)
+}
+
+// 订单查询，使用下单时的商户号
+func (m *AccountManager) OrderQuery(ctx context.Context, params Params) (Params, error) {
+	c, err := m.Lookup(ctx, params.GetString("out_trade_no"))
+	if err != nil {
+		return nil, err
+	}
+	done, err := m.acquire(c)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return c.OrderQueryContext(ctx, params)
+}
+
+// 关闭订单，使用下单时的商户号
+func (m *AccountManager) CloseOrder(ctx context.Context, params Params) (Params, error) {
+	c, err := m.Lookup(ctx, params.GetString("out_trade_no"))
+	if err != nil {
+		return nil, err
+	}
+	done, err := m.acquire(c)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return c.CloseOrderContext(ctx, params)
+}
+
+// 退款，使用下单时的商户号
+func (m *AccountManager) Refund(ctx context.Context, params Params, opts ...RefundOption) (Params, error) {
+	c, err := m.Lookup(ctx, params.GetString("out_trade_no"))
+	if err != nil {
+		return nil, err
+	}
+	done, err := m.acquire(c)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return c.RefundContext(ctx, params, opts...)
+}
+
+// 退款查询，使用下单时的商户号
+func (m *AccountManager) RefundQuery(ctx context.Context, params Params) (Params, error) {
+	c, err := m.Lookup(ctx, params.GetString("out_trade_no"))
+	if err != nil {
+		return nil, err
+	}
+	done, err := m.acquire(c)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return c.RefundQueryContext(ctx, params)
+}
@@ -0,0 +1,184 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAccountManager_Route(t *testing.T) {
+	m := NewAccountManager(nil)
+	a := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	b := NewClient(NewAccount("wx2421b1c4370ec43b", "10000200", "xxxxx", false))
+	m.Add(a, 3)
+	m.Add(b, 1)
+
+	ctx := context.Background()
+	counts := make(map[*Client]int)
+	for i := 0; i < 8; i++ {
+		c, err := m.Route(ctx, strconv.Itoa(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[c]++
+	}
+	if counts[a] != 6 || counts[b] != 2 {
+		t.Error(counts[a], counts[b])
+	}
+
+	// 同一个订单始终使用同一个商户号
+	first, _ := m.Lookup(ctx, "3")
+	again, _ := m.Route(ctx, "3")
+	if first != again {
+		t.Error("route is not sticky")
+	}
+	if _, err := m.Lookup(ctx, "unknown"); err != ErrKeyNotFound {
+		t.Error(err)
+	}
+}
+
+func TestAccountManager_EmptyOutTradeNo(t *testing.T) {
+	m := NewAccountManager(nil)
+	m.Add(NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false)), 1)
+
+	ctx := context.Background()
+	if _, err := m.Route(ctx, ""); err != errEmptyOutTradeNo {
+		t.Error(err)
+	}
+	// 只有transaction_id的退款无法路由
+	params := make(Params)
+	params.SetString("transaction_id", "1008450740201411110005820873").SetString("out_refund_no", "1415701182")
+	if _, err := m.Refund(ctx, params); err != errEmptyOutTradeNo {
+		t.Error(err)
+	}
+}
+
+// 只实现KVStore的存储
+type plainStore struct {
+	KVStore
+}
+
+func TestAccountManager_ConcurrentRoute(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wxpay-route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileStore, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, store := range map[string]KVStore{
+		"memory": NewMemoryStore(),
+		"file":   fileStore,
+		"plain":  plainStore{NewMemoryStore()},
+	} {
+		m := NewAccountManager(store)
+		m.Add(NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false)), 1)
+		m.Add(NewClient(NewAccount("wx2421b1c4370ec43b", "10000200", "xxxxx", false)), 1)
+
+		const n = 16
+		clients := make([]*Client, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				c, err := m.Route(context.Background(), "1409811653")
+				if err != nil {
+					t.Error(name, err)
+				}
+				clients[i] = c
+			}(i)
+		}
+		wg.Wait()
+		for _, c := range clients {
+			if c != clients[0] {
+				t.Fatal(name, "same order routed to different accounts")
+			}
+		}
+	}
+}
+
+func TestAccountManager_Disable(t *testing.T) {
+	ctx := context.Background()
+	m := NewAccountManager(nil)
+	a := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	b := NewClient(NewAccount("wx2421b1c4370ec43b", "10000200", "xxxxx", false))
+	entered, release := make(chan struct{}), make(chan struct{})
+	a.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			close(entered)
+			<-release
+			res := make(Params)
+			res.SetString("return_code", Success).SetString("result_code", Success)
+			res.SetString("sign", a.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+	m.Add(a, 1)
+	m.Add(b, 1)
+
+	// 进行中的请求在停用后继续完成
+	result := make(chan error, 1)
+	go func() {
+		_, err := m.UnifiedOrder(ctx, Params{"out_trade_no": "1409811653"})
+		result <- err
+	}()
+	<-entered
+	if err := m.Disable("10000100"); err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := m.Drain(timeout, "10000100"); err != context.DeadlineExceeded {
+		t.Error(err)
+	}
+	close(release)
+	if err := m.Drain(ctx, "10000100"); err != nil {
+		t.Error(err)
+	}
+	if err := <-result; err != nil {
+		t.Error(err)
+	}
+
+	// 停用后新订单只分配给其他商户号，已有订单的后续调用被拒绝
+	for i := 0; i < 4; i++ {
+		if c, err := m.Route(ctx, strconv.Itoa(i)); err != nil || c != b {
+			t.Error(i, err)
+		}
+	}
+	if _, err := m.OrderQuery(ctx, Params{"out_trade_no": "1409811653"}); !errors.Is(err, ErrAccountDisabled) {
+		t.Error(err)
+	}
+	if _, err := m.Lookup(ctx, "1409811653"); !errors.Is(err, ErrAccountDisabled) {
+		t.Error(err)
+	}
+	if err := m.Disable("10000200"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Route(ctx, "new"); !errors.Is(err, ErrAccountDisabled) {
+		t.Error(err)
+	}
+
+	if err := m.Enable("10000100"); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := m.Lookup(ctx, "1409811653"); err != nil || c != a {
+		t.Error(err)
+	}
+	if err := m.Disable("unknown"); err == nil {
+		t.Error("expected unknown account error")
+	}
+}
@@ -0,0 +1,131 @@
+package wxpay
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 人民币，fee_type为空时的默认币种
+const CNY = "CNY"
+
+// 不同币种的金额不能直接计算
+var ErrCurrencyMismatch = errors.New("amount currency mismatch")
+
+// 没有小数位的币种，其余币种按两位小数处理
+var zeroDecimalCurrencies = map[string]bool{"JPY": true, "KRW": true}
+
+// 金额，Value为币种的最小单位，人民币为分
+type Amount struct {
+	Value    int64
+	Currency string // 对应fee_type
+}
+
+// 创建金额，currency为空时为人民币
+func NewAmount(value int64, currency string) Amount {
+	if currency == "" {
+		currency = CNY
+	}
+	return Amount{Value: value, Currency: currency}
+}
+
+// 解析以最小单位表示的金额字符串，例如total_fee
+func ParseAmount(s string, currency string) (Amount, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	return NewAmount(i, currency), nil
+}
+
+// 解析带小数的金额字符串，例如对账单中的"0.01"
+func ParseDecimalAmount(s string, currency string) (Amount, error) {
+	a := NewAmount(0, currency)
+	v, err := parseDecimal(s, a.decimals())
+	if err != nil {
+		return Amount{}, err
+	}
+	a.Value = v
+	return a, nil
+}
+
+// 将小数字符串解析为保留digits位小数的整数，例如 ("0.01", 2) -> 1
+func parseDecimal(s string, digits int) (int64, error) {
+	str := strings.TrimSpace(s)
+	negative := strings.HasPrefix(str, "-")
+	str = strings.TrimPrefix(str, "-")
+
+	parts := strings.SplitN(str, ".", 2)
+	frac := ""
+	if len(parts) == 2 {
+		frac = parts[1]
+	}
+	if len(frac) > digits {
+		// 多余的小数位必须为0，否则会丢失精度
+		if strings.Trim(frac[digits:], "0") != "" {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+		frac = frac[:digits]
+	}
+	frac += strings.Repeat("0", digits-len(frac))
+
+	i, err := strconv.ParseInt(parts[0]+frac, 10, 64)
+	if err != nil || parts[0] == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		i = -i
+	}
+	return i, nil
+}
+
+// 将保留from位小数的整数四舍五入为保留to位小数，to不大于from
+func roundDecimal(v int64, from, to int) int64 {
+	div := int64(1)
+	for i := to; i < from; i++ {
+		div *= 10
+	}
+	if v < 0 {
+		return -((-v + div/2) / div)
+	}
+	return (v + div/2) / div
+}
+
+// 相加，币种不同时返回ErrCurrencyMismatch
+func (a Amount) Add(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, ErrCurrencyMismatch
+	}
+	return Amount{Value: a.Value + b.Value, Currency: a.Currency}, nil
+}
+
+// 相减，币种不同时返回ErrCurrencyMismatch
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, ErrCurrencyMismatch
+	}
+	return Amount{Value: a.Value - b.Value, Currency: a.Currency}, nil
+}
+
+// 以主单位输出，例如 {1 CNY} -> "0.01"
+func (a Amount) String() string {
+	digits := a.decimals()
+	sign := ""
+	v := a.Value
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	if digits == 0 {
+		return fmt.Sprintf("%s%d", sign, v)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+func (a Amount) decimals() int {
+	if zeroDecimalCurrencies[a.Currency] {
+		return 0
+	}
+	return 2
+}
@@ -0,0 +1,36 @@
+package wxpay
+
+import "testing"
+
+func TestParseDecimalAmount(t *testing.T) {
+	cases := []struct {
+		s        string
+		currency string
+		value    int64
+	}{
+		{"0.01", CNY, 1},
+		{"12.3", "", 1230},
+		{"-1.50", "USD", -150},
+		{"0.60000", CNY, 60},
+		{"100", "JPY", 100},
+	}
+	for _, c := range cases {
+		a, err := ParseDecimalAmount(c.s, c.currency)
+		if err != nil || a.Value != c.value {
+			t.Errorf("ParseDecimalAmount(%q) = %v, %v", c.s, a, err)
+		}
+	}
+	if _, err := ParseDecimalAmount("0.001", CNY); err == nil {
+		t.Error("expected precision error")
+	}
+}
+
+func TestAmount_Add(t *testing.T) {
+	if _, err := NewAmount(1, CNY).Add(NewAmount(1, "USD")); err != ErrCurrencyMismatch {
+		t.Error(err)
+	}
+	sum, err := NewAmount(1, "").Add(NewAmount(2, CNY))
+	if err != nil || sum.String() != "0.03" {
+		t.Error(sum, err)
+	}
+}
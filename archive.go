@@ -0,0 +1,35 @@
+package wxpay
+
+// 报文归档回调，请求报文在发送前以请求地址和实际发送的请求体调用，返回报文在读取后以原始返回数据调用
+type ArchiveHook func(url string, body []byte)
+
+// 设置请求报文归档回调，redactKeys中的字段在归档前脱敏；未指定时归档与发送完全一致的报文
+func (c *Client) SetArchiveHook(hook ArchiveHook, redactKeys ...string) {
+	c.archiveHook = hook
+	c.archiveRedactKeys = redactKeys
+}
+
+// 设置返回报文归档回调，对账单等较大的返回数据可以配合CompressArchive压缩、切分后归档
+func (c *Client) SetResponseArchiveHook(hook ArchiveHook) {
+	c.responseArchiveHook = hook
+}
+
+func (c *Client) archive(url string, codec Codec, params Params, body []byte) error {
+	if c.archiveHook == nil {
+		return nil
+	}
+	if len(c.archiveRedactKeys) > 0 {
+		var err error
+		if body, err = codec.Encode(redactParams(params, c.archiveRedactKeys)); err != nil {
+			return err
+		}
+	}
+	c.archiveHook(url, body)
+	return nil
+}
+
+func (c *Client) archiveResponse(url string, body []byte) {
+	if c.responseArchiveHook != nil {
+		c.responseArchiveHook(url, body)
+	}
+}
@@ -0,0 +1,126 @@
+package wxpay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"sort"
+)
+
+// 归档报文的压缩算法。标准库只提供gzip，zstd等算法可以通过第三方库实现该接口
+type ArchiveCompressor interface {
+	Encoding() string // 算法名，记录在ArchiveChunk.Encoding中
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// gzip压缩，Level为0时使用默认压缩级别
+type GzipCompressor struct {
+	Level int
+}
+
+func (GzipCompressor) Encoding() string {
+	return "gzip"
+}
+
+func (g GzipCompressor) Compress(data []byte) ([]byte, error) {
+	level := g.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// 压缩、切分后的一块归档数据，同一报文的各块ID相同
+type ArchiveChunk struct {
+	ID       string
+	Url      string
+	Encoding string // 压缩算法，为空表示未压缩
+	Index    int    // 块序号，从0开始
+	Total    int    // 报文的总块数
+	Data     []byte
+}
+
+// 归档数据块回调
+type ArchiveChunkHook func(chunk ArchiveChunk)
+
+// 返回先压缩报文、再按chunkSize字节切分后调用hook的ArchiveHook，可用于SetArchiveHook和SetResponseArchiveHook。
+// compressor为nil时不压缩，chunkSize不大于0时不切分；压缩失败时归档未压缩的报文
+func CompressArchive(compressor ArchiveCompressor, chunkSize int, hook ArchiveChunkHook) ArchiveHook {
+	return func(url string, body []byte) {
+		id, err := randomNonce()
+		if err != nil {
+			id = nonceStr()
+		}
+		encoding, data := "", body
+		if compressor != nil {
+			if compressed, err := compressor.Compress(body); err == nil {
+				encoding, data = compressor.Encoding(), compressed
+			}
+		}
+		if chunkSize <= 0 || len(data) <= chunkSize {
+			hook(ArchiveChunk{ID: id, Url: url, Encoding: encoding, Total: 1, Data: data})
+			return
+		}
+		total := (len(data) + chunkSize - 1) / chunkSize
+		for i := 0; i < total; i++ {
+			end := (i + 1) * chunkSize
+			if end > len(data) {
+				end = len(data)
+			}
+			hook(ArchiveChunk{ID: id, Url: url, Encoding: encoding, Index: i, Total: total, Data: data[i*chunkSize : end]})
+		}
+	}
+}
+
+// 将同一报文的全部数据块按序号拼接并解压，还原归档的原始报文。compressors中需包含归档时使用的算法
+func JoinArchiveChunks(chunks []ArchiveChunk, compressors ...ArchiveCompressor) ([]byte, error) {
+	if len(chunks) == 0 {
+		return nil, errors.New("no archive chunks")
+	}
+	sorted := make([]ArchiveChunk, len(chunks))
+	copy(sorted, chunks)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	first := sorted[0]
+	if len(sorted) != first.Total {
+		return nil, errors.New("archive chunks are incomplete")
+	}
+	var data []byte
+	for i, chunk := range sorted {
+		if chunk.ID != first.ID || chunk.Index != i || chunk.Encoding != first.Encoding {
+			return nil, errors.New("archive chunks do not belong to the same payload")
+		}
+		data = append(data, chunk.Data...)
+	}
+	if first.Encoding == "" {
+		return data, nil
+	}
+	for _, c := range compressors {
+		if c.Encoding() == first.Encoding {
+			return c.Decompress(data)
+		}
+	}
+	return nil, errors.New("no compressor for encoding " + first.Encoding)
+}
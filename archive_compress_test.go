@@ -0,0 +1,71 @@
+package wxpay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCompressArchive(t *testing.T) {
+	payload := []byte(strings.Repeat(testBillData, 50))
+	var chunks []ArchiveChunk
+	hook := CompressArchive(GzipCompressor{}, 256, func(chunk ArchiveChunk) {
+		chunks = append(chunks, chunk)
+	})
+	hook(DownloadBillUrl, payload)
+
+	if len(chunks) < 2 || chunks[0].Encoding != "gzip" || chunks[0].Total != len(chunks) {
+		t.Fatal(len(chunks), chunks[0].Encoding)
+	}
+	var size int
+	for _, chunk := range chunks {
+		if len(chunk.Data) > 256 || chunk.ID != chunks[0].ID {
+			t.Error(chunk.Index, len(chunk.Data))
+		}
+		size += len(chunk.Data)
+	}
+	if size >= len(payload) {
+		t.Error("payload was not compressed", size)
+	}
+
+	// 乱序的数据块也能还原
+	chunks[0], chunks[1] = chunks[1], chunks[0]
+	data, err := JoinArchiveChunks(chunks, GzipCompressor{})
+	if err != nil || !bytes.Equal(data, payload) {
+		t.Error(err)
+	}
+	if _, err := JoinArchiveChunks(chunks[1:], GzipCompressor{}); err == nil {
+		t.Error("expected incomplete error")
+	}
+	if _, err := JoinArchiveChunks(chunks); err == nil {
+		t.Error("expected missing compressor error")
+	}
+}
+
+func TestResponseArchiveHook(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(testBillData)),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+	var chunks []ArchiveChunk
+	client.SetResponseArchiveHook(CompressArchive(nil, 0, func(chunk ArchiveChunk) {
+		chunks = append(chunks, chunk)
+	}))
+
+	params := make(Params)
+	params.SetString("bill_date", "20200501").SetString("bill_type", BillTypeAll)
+	if _, err := client.DownloadBill(params); err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0].Url != DownloadBillUrl || string(chunks[0].Data) != testBillData {
+		t.Error(chunks)
+	}
+}
@@ -0,0 +1,78 @@
+package wxpay
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// attach字段的最大长度（字节）
+const MaxAttachLen = 127
+
+// 校验attach长度，超长时微信会截断或报错
+func ValidateAttach(attach string) error {
+	if len(attach) > MaxAttachLen {
+		return fmt.Errorf("attach is %d bytes, exceeds %d", len(attach), MaxAttachLen)
+	}
+	return nil
+}
+
+// 将v编码为JSON作为attach
+func EncodeAttachJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	attach := string(data)
+	return attach, ValidateAttach(attach)
+}
+
+// 从attach中解码JSON
+func DecodeAttachJSON(attach string, v interface{}) error {
+	return json.Unmarshal([]byte(attach), v)
+}
+
+// 将二进制数据编码为URL安全的base64作为attach
+func EncodeAttachBase64(data []byte) (string, error) {
+	attach := base64.RawURLEncoding.EncodeToString(data)
+	return attach, ValidateAttach(attach)
+}
+
+// 从attach中解码base64数据
+func DecodeAttachBase64(attach string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(attach)
+}
+
+// 将键值对编码为URL查询字符串作为attach
+func EncodeAttachValues(values url.Values) (string, error) {
+	attach := values.Encode()
+	return attach, ValidateAttach(attach)
+}
+
+// 从attach中解码键值对
+func DecodeAttachValues(attach string) (url.Values, error) {
+	return url.ParseQuery(attach)
+}
+
+// attach中路由字段的名称，用于在回调中选择处理该订单的业务
+const AttachRouteKey = "route"
+
+// 将路由key与其他键值对一起编码为attach，values可以为nil
+func EncodeAttachRoute(route string, values url.Values) (string, error) {
+	v := url.Values{}
+	for k, vs := range values {
+		v[k] = vs
+	}
+	v.Set(AttachRouteKey, route)
+	return EncodeAttachValues(v)
+}
+
+// 从attach中取出路由key，attach中没有路由key时返回空字符串
+func AttachRoute(attach string) string {
+	values, err := DecodeAttachValues(attach)
+	if err != nil {
+		return ""
+	}
+	return values.Get(AttachRouteKey)
+}
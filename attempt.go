@@ -0,0 +1,44 @@
+package wxpay
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// 一次http请求的信息。重试和容灾域名切换时每次请求都会重新生成nonce_str并签名，
+// 可以用Number和NonceStr关联同一次调用的多次请求
+type Attempt struct {
+	Number   int    // 同一次调用中的第几次请求，从1开始
+	Url      string // 实际请求的地址
+	NonceStr string // 本次请求的nonce_str，不自动签名的请求为调用方传入的值
+}
+
+// 请求回调，每次发送http请求前调用
+type AttemptHook func(a Attempt)
+
+// 设置请求回调，nil表示不回调
+func (c *Client) SetAttemptHook(hook AttemptHook) {
+	c.attemptHook = hook
+}
+
+type attemptCounterKey struct{}
+
+// 为一次调用设置请求计数，ctx中已有计数时原样返回
+func withAttemptCounter(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(attemptCounterKey{}).(*int32); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, attemptCounterKey{}, new(int32))
+}
+
+// 记录一次请求并调用回调
+func (c *Client) observeAttempt(ctx context.Context, url string, p Params) {
+	counter, ok := ctx.Value(attemptCounterKey{}).(*int32)
+	if !ok {
+		return
+	}
+	n := atomic.AddInt32(counter, 1)
+	if c.attemptHook != nil {
+		c.attemptHook(Attempt{Number: int(n), Url: url, NonceStr: p.GetString("nonce_str")})
+	}
+}
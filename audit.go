@@ -0,0 +1,84 @@
+package wxpay
+
+import "time"
+
+// 脱敏后的字段值
+const redactedValue = "***"
+
+// 默认脱敏的字段
+var defaultAuditRedactKeys = []string{"sign", "appsecret", "re_user_name", "enc_bank_no", "enc_true_name"}
+
+// 一次API调用的审计记录，Request和Response均为脱敏后的副本
+type AuditRecord struct {
+	AppID     string        // 调用方公众账号ID
+	MchID     string        // 调用方商户号
+	Url       string        // 请求地址
+	Request   Params        // 请求参数
+	Response  Params        // 返回参数，对账单等非XML返回时为空
+	Err       string        // 请求错误
+	StartTime time.Time     // 请求开始时间
+	Duration  time.Duration // 请求耗时
+}
+
+// 审计记录接收者，每次API调用结束后调用一次
+type AuditSink interface {
+	Audit(record AuditRecord)
+}
+
+// 设置审计记录接收者，nil表示关闭审计
+func (c *Client) SetAuditSink(sink AuditSink) {
+	c.auditSink = sink
+}
+
+// 设置审计记录中需要脱敏的字段，替换默认的脱敏字段
+func (c *Client) SetAuditRedactKeys(keys ...string) {
+	c.auditRedactKeys = keys
+}
+
+func (c *Client) audit(url string, request Params, response string, err error, start time.Time) {
+	if c.auditSink == nil {
+		return
+	}
+	c.auditSink.Audit(c.auditRecord(url, request, response, err, start))
+}
+
+func (c *Client) auditRecord(url string, request Params, response string, err error, start time.Time) AuditRecord {
+	record := AuditRecord{
+		AppID:     c.account.appID,
+		MchID:     c.account.activeMchID(),
+		Url:       url,
+		Request:   c.redact(request),
+		StartTime: start,
+		Duration:  c.getClock().Now().Sub(start),
+	}
+	if len(response) > 0 && response[0] == '<' {
+		record.Response = c.redact(MustXmlToMap(response))
+	}
+	if err != nil {
+		record.Err = err.Error()
+	}
+	return record
+}
+
+// 返回按审计配置脱敏后的参数副本
+func (c *Client) redact(params Params) Params {
+	keys := c.auditRedactKeys
+	if keys == nil {
+		keys = defaultAuditRedactKeys
+	}
+	return redactParams(params, keys)
+}
+
+// 返回将keys对应字段脱敏后的参数副本
+func redactParams(params Params, keys []string) Params {
+	p := make(Params, len(params))
+	for k, v := range params {
+		p[k] = v
+	}
+	for _, k := range keys {
+		if p.ContainsKey(k) {
+			p[k] = redactedValue
+		}
+	}
+	return p
+}
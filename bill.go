@@ -0,0 +1,213 @@
+package wxpay
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const billTimeLayout = "2006-01-02 15:04:05"
+
+// 对账单中的一条交易记录
+type BillRecord struct {
+	TradeTime          time.Time // 交易时间
+	AppID              string    // 公众账号ID
+	MchID              string    // 商户号
+	SubMchID           string    // 特约商户号
+	DeviceInfo         string    // 设备号
+	TransactionID      string    // 微信订单号
+	OutTradeNo         string    // 商户订单号
+	OpenID             string    // 用户标识
+	TradeType          string    // 交易类型
+	TradeState         string    // 交易状态
+	BankType           string    // 付款银行
+	FeeType            string    // 货币种类
+	SettlementTotalFee string    // 应结订单金额
+	CouponFee          string    // 代金券金额
+	RefundID           string    // 微信退款单号
+	OutRefundNo        string    // 商户退款单号
+	RefundFee          string    // 退款金额
+	RefundStatus       string    // 退款状态
+	Body               string    // 商品名称
+	Attach             string    // 商户数据包
+	ServiceFee         string    // 手续费
+	Rate               string    // 费率
+	TotalFee           string    // 订单金额
+	Fields             Params    // 原始字段，以表头为key
+}
+
+// 解析后的对账单
+type Bill struct {
+	Header    []string     // 交易记录表头
+	Records   []BillRecord // 交易记录
+	Summary   Params       // 汇总数据，以汇总表头为key
+	Synthetic bool         // 为true时由订单查询重建，并非微信下发的对账单
+}
+
+// 解析DownloadBill返回的data数据
+func ParseBill(data string) (*Bill, error) {
+	header, rows, summary, err := parseBillTable(data)
+	if err != nil {
+		return nil, err
+	}
+	bill := &Bill{Header: header, Summary: summary}
+	for _, fields := range rows {
+		record, err := newBillRecord(fields)
+		if err != nil {
+			return nil, err
+		}
+		bill.Records = append(bill.Records, record)
+	}
+	return bill, nil
+}
+
+// 解析对账单、资金账单通用的表格格式：表头、以`开头的数据行、汇总表头及汇总数据
+func parseBillTable(data string) (header []string, rows []Params, summary Params, err error) {
+	lines := splitBillLines(data)
+	if len(lines) == 0 {
+		return nil, nil, nil, errors.New("empty bill data")
+	}
+
+	header = splitBillHeader(lines[0])
+	summary = make(Params)
+	i := 1
+	for ; i < len(lines) && strings.HasPrefix(lines[i], "`"); i++ {
+		values := splitBillValues(lines[i])
+		if len(values) != len(header) {
+			return nil, nil, nil, errors.New("bill record column count mismatch")
+		}
+		fields := make(Params, len(values))
+		for j, v := range values {
+			fields.SetString(header[j], v)
+		}
+		rows = append(rows, fields)
+	}
+
+	// 数据行之后是汇总表头及汇总数据
+	if i+1 < len(lines) {
+		keys := splitBillHeader(lines[i])
+		values := splitBillValues(lines[i+1])
+		for j := 0; j < len(keys) && j < len(values); j++ {
+			summary.SetString(keys[j], values[j])
+		}
+	}
+	return header, rows, summary, nil
+}
+
+func newBillRecord(fields Params) (BillRecord, error) {
+	r := BillRecord{
+		AppID:              fields.GetString("公众账号ID"),
+		MchID:              fields.GetString("商户号"),
+		SubMchID:           fields.GetString("特约商户号"),
+		DeviceInfo:         fields.GetString("设备号"),
+		TransactionID:      fields.GetString("微信订单号"),
+		OutTradeNo:         fields.GetString("商户订单号"),
+		OpenID:             fields.GetString("用户标识"),
+		TradeType:          fields.GetString("交易类型"),
+		TradeState:         fields.GetString("交易状态"),
+		BankType:           fields.GetString("付款银行"),
+		FeeType:            fields.GetString("货币种类"),
+		SettlementTotalFee: fields.GetString("应结订单金额"),
+		CouponFee:          fields.GetString("代金券金额"),
+		RefundID:           fields.GetString("微信退款单号"),
+		OutRefundNo:        fields.GetString("商户退款单号"),
+		RefundFee:          fields.GetString("退款金额"),
+		RefundStatus:       fields.GetString("退款状态"),
+		Body:               fields.GetString("商品名称"),
+		Attach:             fields.GetString("商户数据包"),
+		ServiceFee:         fields.GetString("手续费"),
+		Rate:               fields.GetString("费率"),
+		TotalFee:           fields.GetString("订单金额"),
+		Fields:             fields,
+	}
+	// 旧版对账单中特约商户号叫做子商户号
+	if r.SubMchID == "" {
+		r.SubMchID = fields.GetString("子商户号")
+	}
+	if s := fields.GetString("交易时间"); s != "" {
+		t, err := time.ParseInLocation(billTimeLayout, s, beijingLocation)
+		if err != nil {
+			return r, err
+		}
+		r.TradeTime = t
+	}
+	return r, nil
+}
+
+func splitBillLines(data string) []string {
+	var lines []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func splitBillHeader(line string) []string {
+	header := strings.Split(line, ",")
+	for i := range header {
+		// 表头可能带有UTF-8 BOM
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+	return header
+}
+
+// 数据行中每个字段都以`开头，按",`"切分以免商品名称中的逗号影响解析
+func splitBillValues(line string) []string {
+	return strings.Split(strings.TrimPrefix(line, "`"), ",`")
+}
+
+// 对账单记录过滤条件
+type BillFilter func(r *BillRecord) bool
+
+// 按微信订单号集合过滤
+func ByTransactionIDs(ids ...string) BillFilter {
+	set := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return func(r *BillRecord) bool {
+		_, ok := set[r.TransactionID]
+		return ok
+	}
+}
+
+// 按交易时间过滤，包含start，不包含end；零值表示不限制
+func ByTradeTime(start, end time.Time) BillFilter {
+	return func(r *BillRecord) bool {
+		if !start.IsZero() && r.TradeTime.Before(start) {
+			return false
+		}
+		if !end.IsZero() && !r.TradeTime.Before(end) {
+			return false
+		}
+		return true
+	}
+}
+
+// 按特约商户号过滤
+func BySubMchID(subMchID string) BillFilter {
+	return func(r *BillRecord) bool {
+		return r.SubMchID == subMchID
+	}
+}
+
+// 返回同时满足所有过滤条件的记录
+func (b *Bill) Filter(filters ...BillFilter) []BillRecord {
+	var records []BillRecord
+	for i := range b.Records {
+		matched := true
+		for _, f := range filters {
+			if !f(&b.Records[i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			records = append(records, b.Records[i])
+		}
+	}
+	return records
+}
@@ -0,0 +1,112 @@
+package wxpay
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// 重建对账单包含的列，手续费、费率等订单查询无法得到的字段不包含在内
+var syntheticBillHeader = []string{
+	"交易时间", "公众账号ID", "商户号", "特约商户号", "设备号", "微信订单号", "商户订单号", "用户标识",
+	"交易类型", "交易状态", "付款银行", "货币种类", "应结订单金额", "代金券金额", "商品名称", "商户数据包", "订单金额",
+}
+
+// 下载指定日期的全部对账单，微信返回对账单不存在时按outTradeNos逐笔查询订单重建对账单，
+// 重建的对账单Synthetic为true
+func (c *Client) BackfillBill(ctx context.Context, billDate string, outTradeNos []string) (*Bill, error) {
+	params := make(Params)
+	params.SetString("bill_date", billDate).
+		SetString("bill_type", BillTypeAll)
+	p, err := c.DownloadBillContext(ctx, params)
+	if noBillExist(p) {
+		return c.SyntheticBill(ctx, billDate, outTradeNos)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.GetString("return_code") != Success {
+		return nil, BizErrorOf(p)
+	}
+	return ParseBill(p.GetString("data"))
+}
+
+// 按outTradeNos逐笔查询订单，重建billDate（yyyyMMdd）当天的对账单。
+// 只包含支付完成时间在当天的订单，订单不存在时跳过；手续费等字段为空，结账时应以之后补发的对账单为准
+func (c *Client) SyntheticBill(ctx context.Context, billDate string, outTradeNos []string) (*Bill, error) {
+	bill := &Bill{Header: syntheticBillHeader, Synthetic: true}
+	var total, settlement int64
+	for _, outTradeNo := range outTradeNos {
+		params := make(Params)
+		params.SetString("out_trade_no", outTradeNo)
+		p, err := c.OrderQueryContext(ctx, params)
+		if p.GetString("err_code") == "ORDERNOTEXIST" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := BizErrorOf(p); err != nil {
+			return nil, err
+		}
+		timeEnd := p.GetString("time_end")
+		if !strings.HasPrefix(timeEnd, billDate) {
+			continue
+		}
+		record, err := syntheticBillRecord(p)
+		if err != nil {
+			return nil, err
+		}
+		total += p.GetInt64("total_fee")
+		settlement += syntheticSettlementFee(p)
+		bill.Records = append(bill.Records, record)
+	}
+
+	bill.Summary = make(Params)
+	bill.Summary.SetInt64("总交易单数", int64(len(bill.Records))).
+		SetString("应结订单总金额", NewAmount(settlement, CNY).String()).
+		SetString("订单总金额", NewAmount(total, CNY).String())
+	return bill, nil
+}
+
+// 将订单查询的返回数据转换为对账单记录
+func syntheticBillRecord(p Params) (BillRecord, error) {
+	t, err := time.ParseInLocation(notifyTimeLayout, p.GetString("time_end"), beijingLocation)
+	if err != nil {
+		return BillRecord{}, err
+	}
+	feeType := p.GetString("fee_type")
+	fields := make(Params)
+	fields.SetString("交易时间", t.Format(billTimeLayout)).
+		SetString("公众账号ID", p.GetString("appid")).
+		SetString("商户号", p.GetString("mch_id")).
+		SetString("特约商户号", p.GetString("sub_mch_id")).
+		SetString("设备号", p.GetString("device_info")).
+		SetString("微信订单号", p.GetString("transaction_id")).
+		SetString("商户订单号", p.GetString("out_trade_no")).
+		SetString("用户标识", p.GetString("openid")).
+		SetString("交易类型", p.GetString("trade_type")).
+		SetString("交易状态", p.GetString("trade_state")).
+		SetString("付款银行", p.GetString("bank_type")).
+		SetString("货币种类", NewAmount(0, feeType).Currency).
+		SetString("应结订单金额", NewAmount(syntheticSettlementFee(p), feeType).String()).
+		SetString("代金券金额", NewAmount(p.GetInt64("coupon_fee"), feeType).String()).
+		SetString("商品名称", p.GetString("body")).
+		SetString("商户数据包", p.GetString("attach")).
+		SetString("订单金额", NewAmount(p.GetInt64("total_fee"), feeType).String())
+	return newBillRecord(fields)
+}
+
+// 应结订单金额，不返回settlement_total_fee时等于订单金额
+func syntheticSettlementFee(p Params) int64 {
+	if p.ContainsKey("settlement_total_fee") {
+		return p.GetInt64("settlement_total_fee")
+	}
+	return p.GetInt64("total_fee")
+}
+
+// DownloadBill的返回数据是否表示当天没有对账单
+func noBillExist(p Params) bool {
+	return p.GetString("return_code") == Fail &&
+		(p.GetString("err_code") == "NO_BILL_EXIST" || strings.Contains(p.GetString("return_msg"), "No Bill Exist"))
+}
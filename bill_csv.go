@@ -0,0 +1,102 @@
+package wxpay
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// 导出CSV的列，金额为最小单位（人民币为分）的整数，交易时间为RFC3339格式。
+// 手续费精确到分以下，保持对账单中的原值
+var BillCSVColumns = []string{
+	"trade_time", "appid", "mch_id", "sub_mch_id", "device_info", "transaction_id", "out_trade_no",
+	"openid", "trade_type", "trade_state", "bank_type", "fee_type", "total_fee", "settlement_total_fee",
+	"coupon_fee", "refund_id", "out_refund_no", "refund_fee", "refund_status", "body", "attach",
+	"service_fee", "rate",
+}
+
+// 将对账单记录逐条写为统一格式的CSV，首次写入时输出表头，列见BillCSVColumns
+type BillCSVWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+// 创建BillCSVWriter
+func NewBillCSVWriter(w io.Writer) *BillCSVWriter {
+	return &BillCSVWriter{w: csv.NewWriter(w)}
+}
+
+// 写入一条记录，金额格式错误时返回错误
+func (b *BillCSVWriter) Write(r *BillRecord) error {
+	if !b.wroteHeader {
+		if err := b.w.Write(BillCSVColumns); err != nil {
+			return err
+		}
+		b.wroteHeader = true
+	}
+	row, err := billCSVRow(r)
+	if err != nil {
+		return err
+	}
+	return b.w.Write(row)
+}
+
+// 写入所有记录并Flush
+func (b *BillCSVWriter) WriteAll(records []BillRecord) error {
+	for i := range records {
+		if err := b.Write(&records[i]); err != nil {
+			return err
+		}
+	}
+	return b.Flush()
+}
+
+// 从records读取记录并写入，直到records关闭，然后Flush
+func (b *BillCSVWriter) WriteFrom(records <-chan BillRecord) error {
+	for r := range records {
+		if err := b.Write(&r); err != nil {
+			return err
+		}
+	}
+	return b.Flush()
+}
+
+// 将缓冲的数据写入底层的io.Writer
+func (b *BillCSVWriter) Flush() error {
+	b.w.Flush()
+	return b.w.Error()
+}
+
+func billCSVRow(r *BillRecord) ([]string, error) {
+	amounts := make([]string, 0, 4)
+	for _, s := range []string{r.TotalFee, r.SettlementTotalFee, r.CouponFee, r.RefundFee} {
+		v, err := billCSVAmount(s, r.FeeType)
+		if err != nil {
+			return nil, err
+		}
+		amounts = append(amounts, v)
+	}
+	tradeTime := ""
+	if !r.TradeTime.IsZero() {
+		tradeTime = r.TradeTime.Format(time.RFC3339)
+	}
+	return []string{
+		tradeTime, r.AppID, r.MchID, r.SubMchID, r.DeviceInfo, r.TransactionID, r.OutTradeNo,
+		r.OpenID, r.TradeType, r.TradeState, r.BankType, r.FeeType, amounts[0], amounts[1],
+		amounts[2], r.RefundID, r.OutRefundNo, amounts[3], r.RefundStatus, r.Body, r.Attach,
+		r.ServiceFee, r.Rate,
+	}, nil
+}
+
+// 对账单中的金额为带小数的元，转换为最小单位的整数，空值保持为空
+func billCSVAmount(s string, currency string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	a, err := ParseDecimalAmount(s, currency)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(a.Value, 10), nil
+}
@@ -0,0 +1,101 @@
+package wxpay
+
+import (
+	"errors"
+	"strings"
+)
+
+// 对账单格式版本，微信多次调整过对账单的列
+type BillSchema struct {
+	Version string
+	Columns []string
+	Aliases map[string]string // 旧列名 -> 当前列名
+}
+
+var (
+	// 2017年以前的全部订单对账单
+	BillSchema2014 = &BillSchema{
+		Version: "2014",
+		Columns: strings.Split("交易时间,公众账号ID,商户号,子商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,总金额,代金券或立减优惠金额,微信退款单号,商户退款单号,退款金额,代金券或立减优惠退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率", ","),
+		Aliases: map[string]string{
+			"子商户号":         "特约商户号",
+			"总金额":          "应结订单金额",
+			"代金券或立减优惠金额":   "代金券金额",
+			"代金券或立减优惠退款金额": "充值券退款金额",
+		},
+	}
+	// 当前的全部订单对账单
+	BillSchema2018 = &BillSchema{
+		Version: "2018",
+		Columns: strings.Split("交易时间,公众账号ID,商户号,特约商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,应结订单金额,代金券金额,微信退款单号,商户退款单号,退款金额,充值券退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率,订单金额,申请退款金额,费率备注", ","),
+	}
+
+	billSchemas = []*BillSchema{BillSchema2018, BillSchema2014}
+)
+
+// 根据表头识别对账单格式，表头不完全一致时按列数识别
+func DetectBillSchema(header []string) (*BillSchema, error) {
+	for _, schema := range billSchemas {
+		if strings.Join(schema.Columns, ",") == strings.Join(header, ",") {
+			return schema, nil
+		}
+	}
+	return detectBillSchemaByCount(len(header))
+}
+
+func detectBillSchemaByCount(count int) (*BillSchema, error) {
+	for _, schema := range billSchemas {
+		if len(schema.Columns) == count {
+			return schema, nil
+		}
+	}
+	return nil, errors.New("unknown bill schema")
+}
+
+// 解析历史对账单，自动识别格式并将字段统一为当前格式的列名。
+// 历史存档中缺少表头时，按数据行的列数识别格式
+func ParseArchivedBill(data string) (*Bill, *BillSchema, error) {
+	lines := splitBillLines(data)
+	if len(lines) == 0 {
+		return nil, nil, errors.New("empty bill data")
+	}
+
+	var schema *BillSchema
+	var err error
+	if strings.HasPrefix(lines[0], "`") {
+		if schema, err = detectBillSchemaByCount(len(splitBillValues(lines[0]))); err != nil {
+			return nil, nil, err
+		}
+		data = strings.Join(schema.Columns, ",") + "\n" + strings.Join(lines, "\n")
+	} else if schema, err = DetectBillSchema(splitBillHeader(lines[0])); err != nil {
+		return nil, nil, err
+	}
+
+	header, rows, summary, err := parseBillTable(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	bill := &Bill{Summary: summary}
+	for _, h := range header {
+		bill.Header = append(bill.Header, schema.normalize(h))
+	}
+	for _, fields := range rows {
+		normalized := make(Params, len(fields))
+		for k, v := range fields {
+			normalized.SetString(schema.normalize(k), v)
+		}
+		record, err := newBillRecord(normalized)
+		if err != nil {
+			return nil, nil, err
+		}
+		bill.Records = append(bill.Records, record)
+	}
+	return bill, schema, nil
+}
+
+func (s *BillSchema) normalize(column string) string {
+	if name, ok := s.Aliases[column]; ok {
+		return name
+	}
+	return column
+}
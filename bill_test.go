@@ -0,0 +1,170 @@
+package wxpay
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBillData = "交易时间,公众账号ID,商户号,特约商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,应结订单金额,代金券金额,微信退款单号,商户退款单号,退款金额,充值券退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率,订单金额,申请退款金额,费率备注\r\n" +
+	"`2020-05-01 10:02:03,`wx2421b1c4370ec43b,`10000100,`20000001,`013467007045764,`1004400740201409030005092168,`1409811653,`ohcvrjphyaRIIqAppYv-BYuvKjNQ,`JSAPI,`SUCCESS,`OTHERS,`CNY,`0.01,`0.00,`0,`0,`0.00,`0.00,`,`,`测试,商品,`,`0.00000,`0.60%,`0.01,`0.00,`\r\n" +
+	"`2020-05-02 10:02:03,`wx2421b1c4370ec43b,`10000100,`20000002,`013467007045764,`1004400740201409030005092169,`1409811654,`ohcvrjphyaRIIqAppYv-BYuvKjNQ,`NATIVE,`SUCCESS,`CMB_DEBIT,`CNY,`0.02,`0.00,`0,`0,`0.00,`0.00,`,`,`测试,`,`0.00000,`0.60%,`0.02,`0.00,`\r\n" +
+	"总交易单数,应结订单总金额,退款总金额,充值券退款总金额,手续费总金额,订单总金额,申请退款总金额\r\n" +
+	"`2,`0.03,`0.00,`0.00,`0.00000,`0.03,`0.00\r\n"
+
+func TestParseBill(t *testing.T) {
+	bill, err := ParseBill(testBillData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bill.Records) != 2 {
+		t.Fatalf("got %d records", len(bill.Records))
+	}
+	r := bill.Records[0]
+	if r.SubMchID != "20000001" || r.Body != "测试,商品" || r.SettlementTotalFee != "0.01" {
+		t.Error(r)
+	}
+	if bill.Summary.GetString("总交易单数") != "2" {
+		t.Error(bill.Summary)
+	}
+}
+
+func TestBill_Filter(t *testing.T) {
+	bill, err := ParseBill(testBillData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(bill.Filter(BySubMchID("20000002"))); n != 1 {
+		t.Errorf("BySubMchID got %d", n)
+	}
+	if n := len(bill.Filter(ByTransactionIDs("1004400740201409030005092168", "x"))); n != 1 {
+		t.Errorf("ByTransactionIDs got %d", n)
+	}
+	start := time.Date(2020, 5, 2, 0, 0, 0, 0, beijingLocation)
+	if n := len(bill.Filter(ByTradeTime(start, time.Time{}), BySubMchID("20000001"))); n != 0 {
+		t.Errorf("ByTradeTime got %d", n)
+	}
+}
+
+func TestSummarizeBill(t *testing.T) {
+	records := []BillRecord{
+		{TradeTime: time.Date(2020, 5, 1, 10, 0, 0, 0, beijingLocation), TradeType: "JSAPI", FeeType: CNY, SettlementTotalFee: "0.01", ServiceFee: "0.00004"},
+		{TradeTime: time.Date(2020, 5, 1, 11, 0, 0, 0, beijingLocation), TradeType: "JSAPI", FeeType: CNY, SettlementTotalFee: "1.00", ServiceFee: "0.00600"},
+		{TradeTime: time.Date(2020, 5, 1, 12, 0, 0, 0, beijingLocation), TradeType: "JSAPI", FeeType: "USD", SettlementTotalFee: "2.00"},
+	}
+	totals, err := SummarizeBill(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cny := totals[SettlementKey{Date: "2020-05-01", TradeType: "JSAPI", FeeType: CNY}]
+	if cny == nil || cny.Count != 2 || cny.SettlementTotalFee.Value != 101 || cny.ServiceFee.Value != 1 {
+		t.Errorf("%+v", cny)
+	}
+	usd := totals[SettlementKey{Date: "2020-05-01", TradeType: "JSAPI", FeeType: "USD"}]
+	if usd == nil || usd.SettlementTotalFee.String() != "2.00" {
+		t.Errorf("%+v", usd)
+	}
+}
+
+func TestParseArchivedBill(t *testing.T) {
+	data := "`2016-05-01 10:02:03,`wx2421b1c4370ec43b,`10000100,`20000001,`013467007045764,`1004400740201409030005092168,`1409811653,`ohcvrjphyaRIIqAppYv-BYuvKjNQ,`JSAPI,`SUCCESS,`OTHERS,`CNY,`0.01,`0.00,`0,`0,`0.00,`0.00,`,`,`测试,`,`0.00000,`0.60%\r\n"
+	bill, schema, err := ParseArchivedBill(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema != BillSchema2014 || len(bill.Records) != 1 {
+		t.Fatal(schema, bill)
+	}
+	r := bill.Records[0]
+	if r.SubMchID != "20000001" || r.SettlementTotalFee != "0.01" || r.Fields.GetString("代金券金额") != "0.00" {
+		t.Error(r)
+	}
+
+	if _, schema, err = ParseArchivedBill(testBillData); err != nil || schema != BillSchema2018 {
+		t.Error(schema, err)
+	}
+}
+
+func TestBillCSVWriter(t *testing.T) {
+	bill, err := ParseBill(testBillData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := NewBillCSVWriter(&buf).WriteAll(bill.Records); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "trade_time" {
+		t.Fatal(rows)
+	}
+	row := rows[1]
+	if row[0] != "2020-05-01T10:02:03+08:00" || row[12] != "1" || row[19] != "测试,商品" || row[21] != "0.00000" {
+		t.Error(row)
+	}
+}
+
+func TestBackfillBill(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(r.Body)
+			req := MustXmlToMap(string(body))
+			res := make(Params)
+			res.SetString("return_code", Success)
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/downloadbill"):
+				res.SetString("return_code", Fail).SetString("return_msg", "No Bill Exist")
+			case req.GetString("out_trade_no") == "1409811653":
+				res.SetString("result_code", Success).
+					SetString("trade_state", "SUCCESS").
+					SetString("transaction_id", "1004400740201409030005092168").
+					SetString("out_trade_no", "1409811653").
+					SetString("trade_type", "JSAPI").
+					SetString("total_fee", "101").
+					SetString("time_end", "20200501100203")
+			case req.GetString("out_trade_no") == "1409811654":
+				res.SetString("result_code", Success).
+					SetString("trade_state", "SUCCESS").
+					SetString("total_fee", "1").
+					SetString("time_end", "20200502100203")
+			case req.GetString("out_trade_no") == "1409811655":
+				res.SetString("result_code", Success).SetString("trade_state", "NOTPAY")
+			default:
+				res.SetString("result_code", Fail).SetString("err_code", "ORDERNOTEXIST")
+			}
+			res.SetString("sign", client.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+
+	bill, err := client.BackfillBill(context.Background(), "20200501", []string{"1409811653", "1409811654", "1409811655", "1409811656"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bill.Synthetic || len(bill.Records) != 1 {
+		t.Fatal(bill)
+	}
+	r := bill.Records[0]
+	if r.TransactionID != "1004400740201409030005092168" || r.TotalFee != "1.01" || r.SettlementTotalFee != "1.01" || r.FeeType != CNY {
+		t.Error(r)
+	}
+	if !r.TradeTime.Equal(time.Date(2020, 5, 1, 10, 2, 3, 0, beijingLocation)) {
+		t.Error(r.TradeTime)
+	}
+	if bill.Summary.GetString("总交易单数") != "1" || bill.Summary.GetString("订单总金额") != "1.01" {
+		t.Error(bill.Summary)
+	}
+}
@@ -0,0 +1,44 @@
+package wxpay
+
+import "fmt"
+
+// 业务失败：return_code或result_code为FAIL。
+// 开启SetBizErrors后接口返回*BizError，可通过errors.As取出错误码
+type BizError struct {
+	ReturnCode string
+	ReturnMsg  string
+	ErrCode    string
+	ErrCodeDes string
+	Params     Params // 完整的返回数据
+}
+
+func (e *BizError) Error() string {
+	if e.ErrCode == "" {
+		return fmt.Sprintf("wxpay: return_code=%s: %s", e.ReturnCode, e.ReturnMsg)
+	}
+	if desc := e.Description(); desc != "" {
+		return fmt.Sprintf("wxpay: %s: %s (%s)", e.ErrCode, e.ErrCodeDes, desc)
+	}
+	return fmt.Sprintf("wxpay: %s: %s", e.ErrCode, e.ErrCodeDes)
+}
+
+// 返回数据表示业务失败时返回*BizError，否则返回nil
+func BizErrorOf(params Params) error {
+	returnCode := params.GetString("return_code")
+	if returnCode != Fail && params.GetString("result_code") != Fail {
+		return nil
+	}
+	return &BizError{
+		ReturnCode: returnCode,
+		ReturnMsg:  params.GetString("return_msg"),
+		ErrCode:    params.GetString("err_code"),
+		ErrCodeDes: params.GetString("err_code_des"),
+		Params:     params,
+	}
+}
+
+// 开启后通过Invoke调用的接口在业务失败时返回*BizError，同时仍返回Params。
+// 默认关闭，业务失败时err为nil，需要调用方自行检查result_code
+func (c *Client) SetBizErrors(enabled bool) {
+	c.bizErrors = enabled
+}
@@ -0,0 +1,59 @@
+package wxpay
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBizErrors(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			res := make(Params)
+			res.SetString("return_code", Success).
+				SetString("result_code", Fail).
+				SetString("err_code", "ORDERNOTEXIST").
+				SetString("err_code_des", "此交易订单号不存在")
+			res.SetString("sign", client.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+
+	params := make(Params)
+	params.SetString("out_trade_no", "1409811653")
+	if _, err := client.OrderQuery(params); err != nil {
+		t.Fatal("biz errors should be opt-in:", err)
+	}
+
+	client.SetBizErrors(true)
+	p, err := client.OrderQuery(params)
+	var bizErr *BizError
+	if !errors.As(err, &bizErr) {
+		t.Fatal(err)
+	}
+	if bizErr.ErrCode != "ORDERNOTEXIST" || bizErr.ErrCodeDes != "此交易订单号不存在" || p.GetString("err_code") != "ORDERNOTEXIST" {
+		t.Error(bizErr, p)
+	}
+}
+
+func TestBizErrorOf(t *testing.T) {
+	ok := make(Params)
+	ok.SetString("return_code", Success).SetString("result_code", Success)
+	if err := BizErrorOf(ok); err != nil {
+		t.Error(err)
+	}
+
+	fail := make(Params)
+	fail.SetString("return_code", Fail).SetString("return_msg", "No Bill Exist")
+	var bizErr *BizError
+	if err := BizErrorOf(fail); !errors.As(err, &bizErr) || bizErr.ReturnMsg != "No Bill Exist" {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,35 @@
+package wxpay
+
+// 当前配置下客户端具备的能力
+type Capabilities struct {
+	HasCert            bool            // 是否设置了证书
+	Sandbox            bool            // 是否为仿真测试环境
+	SandboxCredentials bool            // 是否设置了仿真测试专用的商户号和密钥
+	SignType           string          // 签名类型
+	Endpoints          map[string]bool // 接口名 -> 当前配置下是否可用
+}
+
+// 返回当前账号配置下可用的能力，便于在启动时检查配置而不是在首次支付时才发现问题
+func (c *Client) Capabilities() Capabilities {
+	caps := Capabilities{
+		HasCert:            c.account.cert != nil,
+		Sandbox:            c.account.isSandbox,
+		SandboxCredentials: c.account.sandboxMchID != "" || c.account.sandboxApiKey != "",
+		SignType:           c.currentSignType(),
+		Endpoints:          make(map[string]bool),
+	}
+	endpointsMu.RLock()
+	defer endpointsMu.RUnlock()
+	for name, e := range endpoints {
+		available := !e.NeedsCert || caps.HasCert
+		// 没有仿真测试环境的接口，例如企业付款
+		if caps.Sandbox && e.SandboxUrl == "" {
+			available = false
+		}
+		if !c.account.HasProduct(e.Product) {
+			available = false
+		}
+		caps.Endpoints[name] = available
+	}
+	return caps
+}
@@ -0,0 +1,103 @@
+package wxpay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 故障注入的Transport，用于测试调用方对各种异常情况的处理。
+// 各个Rate取值0~1，表示对应故障出现的概率
+type ChaosTransport struct {
+	Transport        http.RoundTripper // 被包装的Transport，为nil时使用http.DefaultTransport
+	LatencyRate      float64           // 注入延迟的概率
+	Latency          time.Duration     // 注入的延迟时间
+	TimeoutRate      float64           // 返回超时错误的概率
+	MalformedXmlRate float64           // 返回不完整XML的概率
+	BadSignRate      float64           // 篡改返回签名的概率
+	Clock            Clock             // 为nil时使用SystemClock
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// 注入的超时错误，实现了net.Error
+type chaosTimeoutError struct{}
+
+func (chaosTimeoutError) Error() string   { return "wxpay chaos: injected timeout" }
+func (chaosTimeoutError) Timeout() bool   { return true }
+func (chaosTimeoutError) Temporary() bool { return true }
+
+// 包装Transport，可作为Client.SetTransportWrapper的参数
+func (t *ChaosTransport) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return &ChaosTransport{
+		Transport:        rt,
+		LatencyRate:      t.LatencyRate,
+		Latency:          t.Latency,
+		TimeoutRate:      t.TimeoutRate,
+		MalformedXmlRate: t.MalformedXmlRate,
+		BadSignRate:      t.BadSignRate,
+		Clock:            t.Clock,
+	}
+}
+
+func (t *ChaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.hit(t.LatencyRate) {
+		select {
+		case <-clockOrSystem(t.Clock).After(t.Latency):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if t.hit(t.TimeoutRate) {
+		return nil, chaosTimeoutError{}
+	}
+
+	rt := t.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	response, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	malformed, badSign := t.hit(t.MalformedXmlRate), t.hit(t.BadSignRate)
+	if !malformed && !badSign {
+		return response, nil
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if malformed {
+		body = body[:len(body)/2]
+	} else if strings.Index(string(body), "<") == 0 {
+		params, err := XmlToMap(string(body))
+		if err == nil && params.ContainsKey(Sign) {
+			params.SetString(Sign, "CHAOS"+params.GetString(Sign))
+			body = []byte(MustMapToXml(params))
+		}
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	response.Header.Del("Content-Length")
+	return response, nil
+}
+
+func (t *ChaosTransport) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rand == nil {
+		t.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return t.rand.Float64() < rate
+}
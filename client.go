@@ -2,6 +2,7 @@ package wxpay
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha256"
@@ -11,18 +12,60 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 const bodyType = "application/xml; charset=utf-8"
 
+// 每个Transport保留的空闲连接数
+const maxIdleConnsPerHost = 16
+
 type Client struct {
 	account              *Account // 支付账号
 	signType             string   // 签名类型
 	httpConnectTimeoutMs int      // 连接超时时间
 	httpReadTimeoutMs    int      // 读取超时时间
+	auditSink            AuditSink
+	auditRedactKeys      []string
+	transportWrapper     func(http.RoundTripper) http.RoundTripper
+	terminals            *TerminalRegistry
+	apiClassTimeouts     map[ApiClass]time.Duration
+	extraParams          map[string][]ExtraParamsProvider
+	idempotencyHook      func(keys IdempotencyKeys) error
+	archiveHook          ArchiveHook
+	archiveRedactKeys    []string
+	responseArchiveHook  ArchiveHook
+	dnsCache             *DNSCache
+	clock                Clock
+	queryCache           ResponseCache
+	queryCacheTTL        time.Duration
+	cacheNonTerminal     bool
+	payoutGuard          *PayoutGuard
+	signFallback         *signFallback
+	latency              *LatencyTracker
+	slowCallThreshold    time.Duration
+	slowCallHook         SlowCallHook
+	publicKey            publicKeyCache
+	transports           transportCache
+	signatureCache       *SignatureCache
+	lintHook             LintHook
+	domainProber         *DomainProber
+	sandboxKeyStore      KVStore
+	sandboxKeyMu         sync.Mutex
+	bizErrors            bool
+	retryPolicy          *RetryPolicy
+	domainFailover       *DomainFailover
+	microPayPollInterval time.Duration
+	microPayPollTimeout  time.Duration
+	microPayProgress     func(MicroPayProgress)
+	keyUsageHook         KeyUsageHook
+	attemptHook          AttemptHook
+	subMerchantStore     SubMerchantStore
 }
 
 // 创建微信支付客户端
@@ -35,10 +78,13 @@ func NewClient(account *Account) *Client {
 	}
 }
 
+// 设置建立连接的超时时间
 func (c *Client) SetHttpConnectTimeoutMs(ms int) {
 	c.httpConnectTimeoutMs = ms
+	c.transports.reset()
 }
 
+// 设置读取超时时间，普通接口的总超时时间为连接超时与读取超时之和
 func (c *Client) SetHttpReadTimeoutMs(ms int) {
 	c.httpReadTimeoutMs = ms
 }
@@ -49,75 +95,211 @@ func (c *Client) SetSignType(signType string) {
 
 func (c *Client) SetAccount(account *Account) {
 	c.account = account
+	c.transports.reset()
+	c.InvalidatePublicKey()
+}
+
+// 设置Transport的包装函数，可用于注入日志、故障等
+func (c *Client) SetTransportWrapper(wrapper func(http.RoundTripper) http.RoundTripper) {
+	c.transportWrapper = wrapper
+	c.transports.reset()
+}
+
+func (c *Client) wrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if c.transportWrapper == nil {
+		return rt
+	}
+	return c.transportWrapper(rt)
 }
 
 // 向 params 中添加 appid、mch_id、nonce_str、sign_type、sign
-// 企业付款给零钱，appid->mch_appid,mch_id->mchid
-func (c *Client) fillRequestData(params Params, payTp ...string) Params {
-	if len(payTp) == 1 && payTp[0] == MchToCashTp {
-		params["mch_appid"] = c.account.appID
-		params["mchid"] = c.account.mchID
-	} else {
-		params["appid"] = c.account.appID
-		params["mch_id"] = c.account.mchID
-		params["sign_type"] = c.signType
+// 字段名按url对应的FieldMapping决定，例如企业付款给零钱为mch_appid、mchid。
+// 服务商模式下设置了SubMerchantStore时按子商户配置填充sub_appid等字段
+func (c *Client) fillRequestData(ctx context.Context, url string, params Params) (Params, error) {
+	c.mergeExtraParams(url, params)
+	m := fieldMappingFor(url)
+	// 小程序下单时保留请求指定的小程序appid，其余情况使用账号的appid
+	if m.AppID != "" && (c.account.miniAppID == "" || params.GetString(m.AppID) != c.account.miniAppID) {
+		params[m.AppID] = c.account.appID
+	}
+	params[m.MchID] = c.account.activeMchID()
+	if m.SubMerchant {
+		c.fillSubMerchant(params)
+		if err := c.fillSubMerchantSettings(ctx, url, params); err != nil {
+			return nil, err
+		}
+	}
+	signType := c.signTypeFor(m)
+	if m.SignType {
+		params["sign_type"] = signType
 	}
 	params["nonce_str"] = nonceStr()
-	params["sign"] = c.Sign(params)
-	return params
+	params["sign"] = c.signWithType(params, signType)
+	return params, nil
 }
 
-// https no cert post
-func (c *Client) postWithoutCert(url string, params Params) (string, error) {
-	h := &http.Client{}
-	p := c.fillRequestData(params)
-	response, err := h.Post(url, bodyType, strings.NewReader(MapToXml(p)))
-	if err != nil {
-		return "", err
+// 填充账号设置的子商户，请求中已指定子商户时不填充
+func (c *Client) fillSubMerchant(params Params) {
+	if c.account.subMchID == "" || params.ContainsKey("sub_mch_id") {
+		return
 	}
-	defer response.Body.Close()
-	res, err := ioutil.ReadAll(response.Body)
+	params["sub_mch_id"] = c.account.subMchID
+	if c.account.subAppID != "" {
+		params["sub_appid"] = c.account.subAppID
+	}
+}
+
+// 接口使用的签名类型，FieldMapping指定了FixedSignType时使用该类型
+func (c *Client) signTypeFor(m FieldMapping) string {
+	if m.FixedSignType != "" {
+		return m.FixedSignType
+	}
+	return c.currentSignType()
+}
+
+// 不使用证书向url发送请求，返回原始数据
+// fill为true时按FieldMapping自动填充appid、mch_id、nonce_str、sign_type并签名，否则按原样发送params
+func (c *Client) RawPostWithoutCert(ctx context.Context, url string, params Params, fill bool) (string, error) {
+	h := &http.Client{Transport: c.plainTransport(), Timeout: c.timeoutFor(url)}
+	if fill {
+		if err := c.ensureSandboxSignKey(ctx); err != nil {
+			return "", err
+		}
+		filled, err := c.fillRequestData(ctx, url, params)
+		if err != nil {
+			return "", err
+		}
+		params = filled
+	}
+	return c.post(ctx, h, url, params, fill)
+}
+
+// 使用证书向url发送请求，返回原始数据，fill的含义同RawPostWithoutCert
+func (c *Client) RawPostWithCert(ctx context.Context, url string, params Params, fill bool) (string, error) {
+	transport, err := c.certTransport()
 	if err != nil {
 		return "", err
 	}
-	return string(res), nil
+	h := &http.Client{Transport: transport, Timeout: c.timeoutFor(url)}
+	if cert := c.account.cert; len(cert.Certificate) > 0 {
+		c.observeKeyUsage(CredentialCert, cert.Certificate[0], url)
+	}
+	if fill {
+		if err := c.ensureSandboxSignKey(ctx); err != nil {
+			return "", err
+		}
+		filled, err := c.fillRequestData(ctx, url, params)
+		if err != nil {
+			return "", err
+		}
+		params = filled
+	}
+	return c.post(ctx, h, url, params, fill)
 }
 
-// https need cert post
-func (c *Client) postWithCert(url string, params Params, payTp ...string) (string, error) {
-	if c.account.certData == nil {
-		return "", errors.New("证书数据为空")
+// 创建Transport，config不为nil时使用证书。建立连接的超时时间为httpConnectTimeoutMs，
+// 读取超时由http.Client的Timeout控制，参见timeoutFor
+func (c *Client) newTransport(config *tls.Config) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// 所有请求都发往同一个域名，默认每个域名只保留2个空闲连接
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	if config != nil {
+		transport.TLSClientConfig = config
+		transport.DisableCompression = true
+	}
+	connectTimeout := time.Duration(c.httpConnectTimeoutMs) * time.Millisecond
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	if c.dnsCache != nil {
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if connectTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, connectTimeout)
+				defer cancel()
+			}
+			return c.dnsCache.DialContext(ctx, network, addr)
+		}
 	}
+	return transport
+}
 
-	// 将pkcs12证书转成pem
-	cert := pkcs12ToPem(c.account.certData, c.account.mchID)
-
-	config := &tls.Config{
-		Certificates: []tls.Certificate{cert},
+// 发送已签名的请求，并记录审计日志。refill为true时，切换到容灾域名前重新生成nonce_str并签名
+func (c *Client) post(ctx context.Context, h *http.Client, url string, p Params, refill bool) (res string, err error) {
+	ctx = withAttemptCounter(ctx)
+	start := c.getClock().Now()
+	defer func() {
+		c.audit(url, p, res, err, start)
+		c.observeLatency(url, p, res, err, start)
+	}()
+	c.lint(url, p)
+	if p.ContainsKey(Sign) {
+		c.observeKeyUsage(CredentialApiKey, []byte(c.account.activeApiKey()), url)
+	}
+	if c.idempotencyHook != nil {
+		if err := c.idempotencyHook(IdempotencyKeysOf(url, p)); err != nil {
+			return "", err
+		}
 	}
-	transport := &http.Transport{
-		TLSClientConfig:    config,
-		DisableCompression: true,
+	target := c.routeUrl(url)
+	body, err := c.sendParams(ctx, h, url, target, p)
+	if backupUrl, ok := c.failoverUrl(ctx, target, err); ok {
+		cause := err
+		// 主域名可能已收到请求，不重放同一个已签名的请求
+		if refill {
+			if p, err = c.fillRequestData(ctx, url, p); err != nil {
+				return "", err
+			}
+		}
+		if body, err = c.sendParams(ctx, h, url, backupUrl, p); err == nil {
+			c.domainFailover.stick(cause)
+		}
 	}
-	h := &http.Client{Transport: transport}
-	p := c.fillRequestData(params, payTp...)
-	response, err := h.Post(url, bodyType, strings.NewReader(MapToXml(p)))
 	if err != nil {
 		return "", err
 	}
-	defer response.Body.Close()
-	res, err := ioutil.ReadAll(response.Body)
+	c.archiveResponse(url, body)
+	return string(body), nil
+}
+
+// 编码并归档请求数据，发往target
+func (c *Client) sendParams(ctx context.Context, h *http.Client, url string, target string, p Params) ([]byte, error) {
+	codec := codecFor(url)
+	data, err := codec.Encode(p)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if err := c.archive(url, codec, p, data); err != nil {
+		return nil, err
+	}
+	c.observeAttempt(ctx, target, p)
+	return c.send(ctx, h, target, codec.ContentType(), data)
+}
+
+// 向url发送POST请求并读取返回数据。设置了DomainFailover.AttemptTimeout时，本次请求使用单独的超时时间
+func (c *Client) send(ctx context.Context, h *http.Client, url string, contentType string, data []byte) ([]byte, error) {
+	if f := c.domainFailover; f != nil && f.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.AttemptTimeout)
+		defer cancel()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
 	}
-	return string(res), nil
+	request.Header.Set("Content-Type", contentType)
+	response, err := h.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	return ioutil.ReadAll(response.Body)
 }
 
 // 生成带有签名的xml字符串
 func (c *Client) generateSignedXml(params Params) string {
 	sign := c.Sign(params)
 	params.SetString(Sign, sign)
-	return MapToXml(params)
+	return MustMapToXml(params)
 }
 
 // 验证签名
@@ -130,6 +312,20 @@ func (c *Client) ValidSign(params Params) bool {
 
 // 签名
 func (c *Client) Sign(params Params) string {
+	return c.signWithType(params, c.currentSignType())
+}
+
+// 使用指定的签名类型签名
+func (c *Client) signWithType(params Params, signType string) string {
+	return c.signWithKey(params, signType, c.account.activeApiKey())
+}
+
+// 使用指定的签名类型和密钥签名
+func (c *Client) signWithKey(params Params, signType string, apiKey string) string {
+	return signParams(params, signType, apiKey)
+}
+
+func signParams(params Params, signType string, apiKey string) string {
 	// 创建切片
 	var keys = make([]string, 0, len(params))
 	// 遍历签名参数
@@ -154,7 +350,7 @@ func (c *Client) Sign(params Params) string {
 	}
 	// 加入apiKey作加密密钥
 	buf.WriteString(`key=`)
-	buf.WriteString(c.account.apiKey)
+	buf.WriteString(apiKey)
 
 	var (
 		dataMd5    [16]byte
@@ -162,12 +358,12 @@ func (c *Client) Sign(params Params) string {
 		str        string
 	)
 
-	switch c.signType {
+	switch signType {
 	case MD5:
 		dataMd5 = md5.Sum(buf.Bytes())
 		str = hex.EncodeToString(dataMd5[:]) //需转换成切片
 	case HMACSHA256:
-		h := hmac.New(sha256.New, []byte(c.account.apiKey))
+		h := hmac.New(sha256.New, []byte(apiKey))
 		h.Write(buf.Bytes())
 		dataSha256 = h.Sum(nil)
 		str = hex.EncodeToString(dataSha256[:])
@@ -179,20 +375,30 @@ func (c *Client) Sign(params Params) string {
 // 处理 HTTPS API返回数据，转换成Map对象。return_code为SUCCESS时，验证签名。
 // flags传入标志，第一位标志是否需要验证签名
 func (c *Client) processResponseXml(xmlStr string, flags ...bool) (Params, error) {
+	verify := !(len(flags) == 1 && flags[0] == false)
+	return c.processResponseXmlWithType(xmlStr, verify, c.currentSignType())
+}
+
+// 同processResponseXml，使用指定的签名类型验证签名
+func (c *Client) processResponseXmlWithType(xmlStr string, verify bool, signType string) (Params, error) {
 	var returnCode string
-	params := XmlToMap(xmlStr)
+	params, err := XmlToMap(xmlStr)
+	if err != nil {
+		return nil, err
+	}
 	if params.ContainsKey("return_code") {
 		returnCode = params.GetString("return_code")
 	} else {
 		return nil, errors.New("no return_code in XML")
 	}
+	c.observeSignResult(params, signType)
 	if returnCode == Fail {
 		return params, nil
 	} else if returnCode == Success {
-		if len(flags) == 1 && flags[0] == false {
+		if !verify {
 			return params, nil
 		}
-		if c.ValidSign(params) {
+		if params.ContainsKey(Sign) && params.GetString(Sign) == c.signWithType(params, signType) {
 			return params, nil
 		} else {
 			return nil, errors.New("invalid sign value in XML")
@@ -204,211 +410,204 @@ func (c *Client) processResponseXml(xmlStr string, flags ...bool) (Params, error
 
 // 统一下单
 func (c *Client) UnifiedOrder(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxUnifiedOrderUrl
-	} else {
-		url = UnifiedOrderUrl
-	}
-	xmlStr, err := c.postWithoutCert(url, params)
-	if err != nil {
-		return nil, err
-	}
-	return c.processResponseXml(xmlStr)
+	return c.UnifiedOrderContext(context.Background(), params)
+}
+
+// 统一下单，ctx可用于取消请求
+func (c *Client) UnifiedOrderContext(ctx context.Context, params Params) (Params, error) {
+	return c.InvokeContext(ctx, "UnifiedOrder", params)
 }
 
 // 刷卡支付
 func (c *Client) MicroPay(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxMicroPayUrl
-	} else {
-		url = MicroPayUrl
-	}
-	xmlStr, err := c.postWithoutCert(url, params)
-	if err != nil {
-		return nil, err
-	}
-	return c.processResponseXml(xmlStr)
+	return c.MicroPayContext(context.Background(), params)
+}
+
+// 刷卡支付，ctx可用于取消请求
+func (c *Client) MicroPayContext(ctx context.Context, params Params) (Params, error) {
+	return c.InvokeContext(ctx, "MicroPay", params)
 }
 
 // 退款
-func (c *Client) Refund(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxRefundUrl
-	} else {
-		url = RefundUrl
-	}
-	xmlStr, err := c.postWithCert(url, params)
-	if err != nil {
+func (c *Client) Refund(params Params, opts ...RefundOption) (Params, error) {
+	return c.RefundContext(context.Background(), params, opts...)
+}
+
+// 退款，ctx可用于取消请求
+func (c *Client) RefundContext(ctx context.Context, params Params, opts ...RefundOption) (Params, error) {
+	if err := applyRefundOptions(params, opts); err != nil {
 		return nil, err
 	}
-	return c.processResponseXml(xmlStr)
+	return c.InvokeContext(ctx, "Refund", params)
 }
 
 // 订单查询
 func (c *Client) OrderQuery(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxOrderQueryUrl
-	} else {
-		url = OrderQueryUrl
+	return c.OrderQueryContext(context.Background(), params)
+}
+
+// 订单查询，ctx可用于取消请求
+func (c *Client) OrderQueryContext(ctx context.Context, params Params) (Params, error) {
+	key := c.queryCacheKey("orderquery", params, "transaction_id", "out_trade_no")
+	if p, ok := c.cachedQuery(key); ok {
+		return p, nil
 	}
-	xmlStr, err := c.postWithoutCert(url, params)
-	if err != nil {
-		return nil, err
+	p, err := c.InvokeContext(ctx, "OrderQuery", params)
+	if err == nil {
+		c.storeQuery(key, p, orderQueryTerminal(p))
 	}
-	return c.processResponseXml(xmlStr)
+	return p, err
 }
 
 // 退款查询
 func (c *Client) RefundQuery(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxRefundQueryUrl
-	} else {
-		url = RefundQueryUrl
+	return c.RefundQueryContext(context.Background(), params)
+}
+
+// 退款查询，ctx可用于取消请求
+func (c *Client) RefundQueryContext(ctx context.Context, params Params) (Params, error) {
+	key := c.queryCacheKey("refundquery", params, "refund_id", "out_refund_no", "transaction_id", "out_trade_no")
+	if p, ok := c.cachedQuery(key); ok {
+		return p, nil
 	}
-	xmlStr, err := c.postWithoutCert(url, params)
-	if err != nil {
-		return nil, err
+	p, err := c.InvokeContext(ctx, "RefundQuery", params)
+	if err == nil {
+		c.storeQuery(key, p, refundQueryTerminal(p))
 	}
-	return c.processResponseXml(xmlStr)
+	return p, err
 }
 
 // 撤销订单
 func (c *Client) Reverse(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxReverseUrl
-	} else {
-		url = ReverseUrl
-	}
-	xmlStr, err := c.postWithCert(url, params)
-	if err != nil {
-		return nil, err
-	}
-	return c.processResponseXml(xmlStr)
+	return c.ReverseContext(context.Background(), params)
+}
+
+// 撤销订单，ctx可用于取消请求
+func (c *Client) ReverseContext(ctx context.Context, params Params) (Params, error) {
+	return c.InvokeContext(ctx, "Reverse", params)
 }
 
 // 关闭订单
 func (c *Client) CloseOrder(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxCloseOrderUrl
-	} else {
-		url = CloseOrderUrl
-	}
-	xmlStr, err := c.postWithoutCert(url, params)
-	if err != nil {
-		return nil, err
-	}
-	return c.processResponseXml(xmlStr)
+	return c.CloseOrderContext(context.Background(), params)
+}
+
+// 关闭订单，ctx可用于取消请求
+func (c *Client) CloseOrderContext(ctx context.Context, params Params) (Params, error) {
+	return c.InvokeContext(ctx, "CloseOrder", params)
 }
 
 // 对账单下载
 func (c *Client) DownloadBill(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxDownloadBillUrl
-	} else {
-		url = DownloadBillUrl
-	}
-	xmlStr, err := c.postWithoutCert(url, params)
+	return c.DownloadBillContext(context.Background(), params)
+}
 
-	p := make(Params)
+// 对账单下载，ctx可用于取消请求
+func (c *Client) DownloadBillContext(ctx context.Context, params Params) (Params, error) {
+	return c.InvokeContext(ctx, "DownloadBill", params)
+}
 
-	// 如果出现错误，返回XML数据
-	if strings.Index(xmlStr, "<") == 0 {
-		p = XmlToMap(xmlStr)
-		return p, err
-	} else { // 正常返回csv数据
-		p.SetString("return_code", Success)
-		p.SetString("return_msg", "ok")
-		p.SetString("data", xmlStr)
-		return p, err
-	}
+// 下载成功支付的对账单
+func (c *Client) DownloadSuccessBill(params Params) (Params, error) {
+	params.SetString("bill_type", BillTypeSuccess)
+	return c.DownloadBill(params)
+}
+
+// 下载退款的对账单
+func (c *Client) DownloadRefundBill(params Params) (Params, error) {
+	params.SetString("bill_type", BillTypeRefund)
+	return c.DownloadBill(params)
 }
 
 func (c *Client) DownloadFundFlow(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxDownloadFundFlowUrl
-	} else {
-		url = DownloadFundFlowUrl
-	}
-	xmlStr, err := c.postWithCert(url, params)
+	return c.DownloadFundFlowContext(context.Background(), params)
+}
 
-	p := make(Params)
+// 下载资金账单，ctx可用于取消请求
+func (c *Client) DownloadFundFlowContext(ctx context.Context, params Params) (Params, error) {
+	return c.InvokeContext(ctx, "DownloadFundFlow", params)
+}
+
+// 按资金账户类型汇总的资金账单，value为DownloadFundFlow的返回数据
+type FundFlowReport map[string]Params
 
-	// 如果出现错误，返回XML数据
-	if strings.Index(xmlStr, "<") == 0 {
-		p = XmlToMap(xmlStr)
-		return p, err
-	} else { // 正常返回csv数据
-		p.SetString("return_code", Success)
-		p.SetString("return_msg", "ok")
-		p.SetString("data", xmlStr)
-		return p, err
+// 下载指定日期基本账户、运营账户、手续费账户的资金账单
+func (c *Client) DownloadAllFundFlows(billDate string) (FundFlowReport, error) {
+	return c.DownloadAllFundFlowsContext(context.Background(), billDate)
+}
+
+// 下载指定日期所有资金账户的资金账单，ctx可用于取消请求
+func (c *Client) DownloadAllFundFlowsContext(ctx context.Context, billDate string) (FundFlowReport, error) {
+	report := make(FundFlowReport)
+	for _, accountType := range []string{AccountTypeBasic, AccountTypeOperation, AccountTypeFees} {
+		params := make(Params)
+		params.SetString("bill_date", billDate).
+			SetString("account_type", accountType)
+		p, err := c.DownloadFundFlowContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		report[accountType] = p
 	}
+	return report, nil
 }
 
 // 交易保障
 func (c *Client) Report(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxReportUrl
-	} else {
-		url = ReportUrl
-	}
-	xmlStr, err := c.postWithoutCert(url, params)
-	if err != nil {
-		return nil, err
-	}
-	return c.processResponseXml(xmlStr)
+	return c.ReportContext(context.Background(), params)
+}
+
+// 交易保障，ctx可用于取消请求
+func (c *Client) ReportContext(ctx context.Context, params Params) (Params, error) {
+	return c.InvokeContext(ctx, "Report", params)
 }
 
 // 转换短链接
 func (c *Client) ShortUrl(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxShortUrl
-	} else {
-		url = ShortUrl
-	}
-	xmlStr, err := c.postWithoutCert(url, params)
-	if err != nil {
-		return nil, err
-	}
-	return c.processResponseXml(xmlStr)
+	return c.ShortUrlContext(context.Background(), params)
+}
+
+// 转换短链接，ctx可用于取消请求
+func (c *Client) ShortUrlContext(ctx context.Context, params Params) (Params, error) {
+	return c.InvokeContext(ctx, "ShortUrl", params)
 }
 
 // 授权码查询OPENID接口
 func (c *Client) AuthCodeToOpenid(params Params) (Params, error) {
-	var url string
-	if c.account.isSandbox {
-		url = SandboxAuthCodeToOpenidUrl
-	} else {
-		url = AuthCodeToOpenidUrl
-	}
-	xmlStr, err := c.postWithoutCert(url, params)
-	if err != nil {
-		return nil, err
-	}
-	return c.processResponseXml(xmlStr)
+	return c.AuthCodeToOpenidContext(context.Background(), params)
+}
+
+// 授权码查询OPENID接口，ctx可用于取消请求
+func (c *Client) AuthCodeToOpenidContext(ctx context.Context, params Params) (Params, error) {
+	return c.InvokeContext(ctx, "AuthCodeToOpenid", params)
 }
 
-//企业付款到零钱
+// 企业付款到零钱
 func (c *Client) MchToCash(params Params) (Params, error) {
-	var url string
-	url = MchToCashUrl
-	xmlStr, err := c.postWithCert(url, params, MchToCashTp)
+	return c.MchToCashContext(context.Background(), params)
+}
+
+// 企业付款到零钱，ctx可用于取消请求
+func (c *Client) MchToCashContext(ctx context.Context, params Params) (Params, error) {
+	release, err := c.reservePayout(ProductTransfer, params.GetInt64("amount"))
 	if err != nil {
-        fmt.Println("res", xmlStr, err)
-        return nil, err
+		return nil, err
+	}
+	p, err := c.InvokeContext(ctx, "MchToCash", params)
+	if payoutFailed(p) {
+		release()
 	}
-	return c.processResponseXml(xmlStr, false)
+	return p, err
+}
+
+// 查询企业付款到零钱的结果，params中需要partner_trade_no
+func (c *Client) GetTransferInfo(params Params) (Params, error) {
+	return c.GetTransferInfoContext(context.Background(), params)
+}
+
+// 查询企业付款到零钱的结果，ctx可用于取消请求
+func (c *Client) GetTransferInfoContext(ctx context.Context, params Params) (Params, error) {
+	return c.InvokeContext(ctx, "GetTransferInfo", params)
 }
 
 func (c *Client) AuthCodeToOpenidMch(params Params) (openID string, err error) {
@@ -417,8 +616,8 @@ func (c *Client) AuthCodeToOpenidMch(params Params) (openID string, err error) {
 
 	res, err := c.getFromWx(url)
 	if err != nil {
-        fmt.Println("request url", url, "res", res)
-        return
+		fmt.Println("request url", url, "res", res)
+		return
 	}
 	openIdInterFace, ok := res["openid"]
 	if !ok {
@@ -444,3 +643,20 @@ func (c *Client) getFromWx(url string) (result map[string]interface{}, err error
 	err = json.Unmarshal(res, &result)
 	return
 }
+
+// 扫码支付模式一回调的返回数据，errCodeDes不为空时result_code为FAIL
+func (c *Client) NativeNotifyReply(prepayID string, errCodeDes string) string {
+	params := make(Params)
+	params.SetString("return_code", Success).
+		SetString("appid", c.account.appID).
+		SetString("mch_id", c.account.activeMchID()).
+		SetString("nonce_str", nonceStr()).
+		SetString("prepay_id", prepayID)
+	if errCodeDes == "" {
+		params.SetString("result_code", Success)
+	} else {
+		params.SetString("result_code", Fail).
+			SetString("err_code_des", errCodeDes)
+	}
+	return c.generateSignedXml(params)
+}
@@ -1,6 +1,9 @@
 package wxpay
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestClient_UnifiedOrder(t *testing.T) {
 	client := NewClient(NewAccount("xxxxx", "xxx", "xxxxx", false))
@@ -13,3 +16,47 @@ func TestClient_UnifiedOrder(t *testing.T) {
 		SetString("trade_type", "APP")
 	t.Log(client.UnifiedOrder(params))
 }
+
+func TestFillSubMerchant(t *testing.T) {
+	account := NewAccount("wx8888888888888888", "1900000109", "xxxxx", false)
+	account.SetSubMerchant("wx1111111111111111", "1900000110")
+	client := NewClient(account)
+	fill := func(url string, params Params) Params {
+		p, err := client.fillRequestData(context.Background(), url, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	p := fill(UnifiedOrderUrl, make(Params))
+	if p.GetString("sub_mch_id") != "1900000110" || p.GetString("sub_appid") != "wx1111111111111111" {
+		t.Error(p)
+	}
+	p = fill(UnifiedOrderUrl, make(Params).SetSubMerchant("", "1900000111"))
+	if p.GetString("sub_mch_id") != "1900000111" || p.ContainsKey("sub_appid") {
+		t.Error(p)
+	}
+	// 企业付款不支持服务商模式
+	p = fill(MchToCashUrl, make(Params))
+	if p.ContainsKey("sub_mch_id") {
+		t.Error(p)
+	}
+}
+
+func TestFixedMD5FieldMappings(t *testing.T) {
+	client := NewClient(NewAccount("wx8888888888888888", "1900000109", "xxxxx", false))
+	client.SetSignType(HMACSHA256)
+	for _, url := range []string{MchToCashUrl, GetTransferInfoUrl, PayBankUrl, SendRedPackUrl, SendGroupRedPackUrl, GetHbInfoUrl, SendCouponUrl} {
+		p, _ := client.fillRequestData(context.Background(), url, make(Params))
+		if p.ContainsKey("sign_type") || p.GetString(Sign) != signParams(p, MD5, "xxxxx") {
+			t.Error(url, p)
+		}
+	}
+	// 返回数据同样按MD5验证签名
+	res := Params{"return_code": Success, "result_code": Success, "coupon_id": "1565"}
+	res.SetString(Sign, signParams(res, MD5, "xxxxx"))
+	if _, err := client.processResponseXmlWithType(MustMapToXml(res), true, client.signTypeFor(fieldMappingFor(SendCouponUrl))); err != nil {
+		t.Error(err)
+	}
+}
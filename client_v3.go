@@ -0,0 +1,231 @@
+package wxpay
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	v3AuthSchema = "WECHATPAY2-SHA256-RSA2048"
+	v3BodyType   = "application/json"
+)
+
+// APIv3 客户端，使用商户私钥签名，请求和返回均为JSON
+type ClientV3 struct {
+	mchID        string          // 商户号
+	serialNo     string          // 商户API证书序列号
+	privateKey   *rsa.PrivateKey // 商户API私钥
+	apiV3Key     string          // APIv3密钥，用于解密回调和平台证书
+	baseUrl      string
+	httpClient   *http.Client
+	clock        Clock
+	certs        *PlatformCertManager // 平台证书，为nil时不验证返回的签名
+	keyUsageHook KeyUsageHook
+	notifyGuard  notifyGuard
+	language     string // Accept-Language请求头，为空时不设置
+}
+
+// 创建APIv3客户端
+func NewClientV3(mchID string, serialNo string, privateKey *rsa.PrivateKey, apiV3Key string) *ClientV3 {
+	return &ClientV3{
+		mchID:      mchID,
+		serialNo:   serialNo,
+		privateKey: privateKey,
+		apiV3Key:   apiV3Key,
+		baseUrl:    V3BaseUrl,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// 设置http客户端，可用于自定义超时时间和Transport
+func (c *ClientV3) SetHttpClient(h *http.Client) {
+	c.httpClient = h
+}
+
+// 设置Accept-Language请求头，决定返回错误信息的语言，例如"en"、"zh-CN"，为空时使用微信支付的默认语言（中文）
+func (c *ClientV3) SetLanguage(lang string) {
+	c.language = lang
+}
+
+// 设置客户端使用的时钟，nil表示使用SystemClock
+func (c *ClientV3) SetClock(clock Clock) {
+	c.clock = clock
+}
+
+// 解析PEM格式的商户API私钥，支持PKCS#8和PKCS#1
+func LoadPrivateKey(pemData []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("invalid private key pem")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not RSA")
+	}
+	return rsaKey, nil
+}
+
+// 从文件读取商户API私钥，通常为apiclient_key.pem
+func LoadPrivateKeyFile(path string) (*rsa.PrivateKey, error) {
+	pemData, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPrivateKey(pemData)
+}
+
+// APIv3 接口返回的错误
+type V3Error struct {
+	StatusCode int             // http状态码
+	Code       string          `json:"code"`
+	Message    string          `json:"message"`
+	Detail     json.RawMessage `json:"detail,omitempty"`
+}
+
+func (e *V3Error) Error() string {
+	if desc := e.Description(); desc != "" {
+		return fmt.Sprintf("wxpay v3: %d %s: %s (%s)", e.StatusCode, e.Code, e.Message, desc)
+	}
+	return fmt.Sprintf("wxpay v3: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// 发送APIv3请求。body不为nil时编码为JSON作为请求体，result不为nil时将返回的JSON解码到result。
+// 返回非2xx状态码时返回*V3Error；设置了平台证书管理器时会验证返回的签名
+func (c *ClientV3) Do(ctx context.Context, method string, path string, body interface{}, result interface{}) error {
+	return c.doWithHeader(ctx, method, path, nil, body, result)
+}
+
+// 同Do，reqHeader为额外的请求头，例如请求体包含加密字段时的Wechatpay-Serial
+func (c *ClientV3) doWithHeader(ctx context.Context, method string, path string, reqHeader http.Header, body interface{}, result interface{}) error {
+	header, resBody, err := c.doUrl(ctx, method, c.baseUrl+path, path, reqHeader, body)
+	if err != nil {
+		return err
+	}
+	if c.certs != nil {
+		if err := c.VerifySignature(ctx, header, resBody); err != nil {
+			return err
+		}
+	}
+	if result == nil || len(resBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resBody, result)
+}
+
+// 发送APIv3请求并返回原始的返回头和返回体，不验证签名
+func (c *ClientV3) doRaw(ctx context.Context, method string, path string, body interface{}) (http.Header, []byte, error) {
+	return c.doUrl(ctx, method, c.baseUrl+path, path, nil, body)
+}
+
+// 向完整的url发送APIv3请求，使用path（不含域名的路径及查询参数）签名，header为额外的请求头
+func (c *ClientV3) doUrl(ctx context.Context, method string, url string, path string, header http.Header, body interface{}) (http.Header, []byte, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	authorization, err := c.authorization(method, path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.keyUsageHook != nil {
+		c.observeKeyUsage(CredentialV3PrivateKey, privateKeyFingerprint(c.privateKey), path)
+	}
+	for k, v := range header {
+		request.Header[k] = v
+	}
+	request.Header.Set("Authorization", authorization)
+	request.Header.Set("Accept", v3BodyType)
+	if c.language != "" {
+		request.Header.Set("Accept-Language", c.language)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", v3BodyType)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+	resBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		v3Err := &V3Error{StatusCode: response.StatusCode}
+		if len(resBody) > 0 {
+			json.Unmarshal(resBody, v3Err)
+		}
+		return nil, nil, v3Err
+	}
+	return response.Header, resBody, nil
+}
+
+// 生成Authorization头，签名串为 方法\nURL\n时间戳\n随机串\n请求体\n
+func (c *ClientV3) authorization(method string, path string, body []byte) (string, error) {
+	timestamp := strconv.FormatInt(clockOrSystem(c.clock).Now().Unix(), 10)
+	nonce, err := randomNonce()
+	if err != nil {
+		return "", err
+	}
+	message := method + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n" + string(body) + "\n"
+	signature, err := c.signV3(message)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`%s mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		v3AuthSchema, c.mchID, nonce, signature, timestamp, c.serialNo), nil
+}
+
+// 使用商户私钥进行SHA256-RSA签名，返回base64编码的签名
+func (c *ClientV3) signV3(message string) (string, error) {
+	hashed := sha256.Sum256([]byte(message))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// 生成32位随机字符串
+func randomNonce() (string, error) {
+	const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = letters[int(b[i])%len(letters)]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,82 @@
+package wxpay
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestClientV3_JSAPIPrepay(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authRe := regexp.MustCompile(`^WECHATPAY2-SHA256-RSA2048 mchid="1900000001",nonce_str="(\w+)",signature="([^"]+)",timestamp="(\d+)",serial_no="SERIAL"$`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		m := authRe.FindStringSubmatch(r.Header.Get("Authorization"))
+		if m == nil {
+			t.Errorf("bad Authorization %q", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		message := r.Method + "\n" + r.URL.RequestURI() + "\n" + m[3] + "\n" + m[1] + "\n" + string(body) + "\n"
+		hashed := sha256.Sum256([]byte(message))
+		signature, _ := base64.StdEncoding.DecodeString(m[2])
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], signature); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"prepay_id":"wx201410272009395522657a690389285100"}`))
+	}))
+	defer server.Close()
+
+	client := NewClientV3("1900000001", "SERIAL", key, "")
+	client.baseUrl = server.URL
+	res, err := client.JSAPIPrepay(context.Background(), &V3TransactionRequest{
+		AppID:       "wxd678efh567hg6787",
+		Description: "test",
+		OutTradeNo:  "1217752501201407033233368018",
+		NotifyUrl:   "https://www.weixin.qq.com/wxpay/pay.php",
+		Amount:      V3Amount{Total: 100, Currency: CNY},
+		Payer:       &V3Payer{OpenID: "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.PrepayID != "wx201410272009395522657a690389285100" {
+		t.Error(res)
+	}
+}
+
+func TestClientV3_Error(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Language") != "en" {
+			t.Error(r.Header)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"PARAM_ERROR","message":"参数错误"}`))
+	}))
+	defer server.Close()
+
+	client := NewClientV3("1900000001", "SERIAL", key, "")
+	client.baseUrl = server.URL
+	client.SetLanguage("en")
+	_, err := client.QueryRefund(context.Background(), "1217752501201407033233368018")
+	v3Err, ok := err.(*V3Error)
+	if !ok || v3Err.Code != "PARAM_ERROR" || v3Err.StatusCode != http.StatusBadRequest {
+		t.Error(err)
+	}
+	if v3Err.Message != "参数错误" || v3Err.Description() != "invalid parameter" {
+		t.Error(v3Err.Message, v3Err.Description())
+	}
+}
@@ -0,0 +1,38 @@
+package wxpay
+
+import "time"
+
+// 时钟，测试时可以替换为手动控制的时钟
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// 使用系统时间的时钟
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// 设置客户端使用的时钟，nil表示使用SystemClock
+func (c *Client) SetClock(clock Clock) {
+	c.clock = clock
+}
+
+func (c *Client) getClock() Clock {
+	return clockOrSystem(c.clock)
+}
+
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
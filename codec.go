@@ -0,0 +1,97 @@
+package wxpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// 请求体的编解码方式
+type Codec interface {
+	ContentType() string
+	Encode(params Params) ([]byte, error)
+	Decode(data []byte) (Params, error)
+}
+
+var (
+	// XML编码，v2接口默认使用
+	XmlCodec Codec = xmlCodec{}
+	// JSON编码，部分新接口使用
+	JsonCodec Codec = jsonCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	// 按接口地址配置的编码方式，未配置的接口使用XmlCodec
+	codecs = map[string]Codec{}
+)
+
+// 为接口地址注册编码方式
+func RegisterCodec(url string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[url] = codec
+}
+
+func codecFor(url string) Codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	if codec, ok := codecs[url]; ok {
+		return codec
+	}
+	return XmlCodec
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string {
+	return bodyType
+}
+
+func (xmlCodec) Encode(params Params) ([]byte, error) {
+	s, err := MapToXml(params)
+	return []byte(s), err
+}
+
+func (xmlCodec) Decode(data []byte) (Params, error) {
+	return XmlToMap(string(data))
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (jsonCodec) Encode(params Params) ([]byte, error) {
+	return json.Marshal(map[string]string(params))
+}
+
+// 字符串和数字原样保存，对象和数组保存为JSON字符串
+func (jsonCodec) Decode(data []byte) (Params, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var m map[string]interface{}
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	params := make(Params, len(m))
+	for k, v := range m {
+		switch value := v.(type) {
+		case string:
+			params.SetString(k, value)
+		case json.Number:
+			params.SetString(k, value.String())
+		case nil:
+			params.SetString(k, "")
+		default:
+			b, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			params.SetString(k, strings.TrimSpace(string(b)))
+		}
+	}
+	return params, nil
+}
@@ -6,7 +6,7 @@ const (
 	HMACSHA256                 = "HMAC-SHA256"
 	MD5                        = "MD5"
 	Sign                       = "sign"
-	MchToCashTp                = "mch"
+	MchToCashTp                = "mch" // Deprecated: 字段名改由FieldMapping按接口地址决定
 	MicroPayUrl                = "https://api.mch.weixin.qq.com/pay/micropay"
 	UnifiedOrderUrl            = "https://api.mch.weixin.qq.com/pay/unifiedorder"
 	OrderQueryUrl              = "https://api.mch.weixin.qq.com/pay/orderquery"
@@ -20,6 +20,25 @@ const (
 	ShortUrl                   = "https://api.mch.weixin.qq.com/tools/shorturl"
 	AuthCodeToOpenidUrl        = "https://api.mch.weixin.qq.com/tools/authcodetoopenid"
 	MchToCashUrl               = "https://api.mch.weixin.qq.com/mmpaymkttransfers/promotion/transfers"
+	GetTransferInfoUrl         = "https://api.mch.weixin.qq.com/mmpaymkttransfers/gettransferinfo"
+	SendRedPackUrl             = "https://api.mch.weixin.qq.com/mmpaymkttransfers/sendredpack"
+	SendGroupRedPackUrl        = "https://api.mch.weixin.qq.com/mmpaymkttransfers/sendgroupredpack"
+	GetHbInfoUrl               = "https://api.mch.weixin.qq.com/mmpaymkttransfers/gethbinfo"
+	SendCouponUrl              = "https://api.mch.weixin.qq.com/mmpaymkttransfers/send_coupon"
+	QueryCouponStockUrl        = "https://api.mch.weixin.qq.com/mmpaymkttransfers/query_coupon_stock"
+	QueryCouponsInfoUrl        = "https://api.mch.weixin.qq.com/mmpaymkttransfers/querycouponsinfo"
+	PayBankUrl                 = "https://api.mch.weixin.qq.com/mmpaysptrans/pay_bank"
+	GetPublicKeyUrl            = "https://fraud.mch.weixin.qq.com/risk/getpublickey"
+	ProfitSharingUrl           = "https://api.mch.weixin.qq.com/secapi/pay/profitsharing"
+	MultiProfitSharingUrl      = "https://api.mch.weixin.qq.com/secapi/pay/multiprofitsharing"
+	ProfitSharingQueryUrl      = "https://api.mch.weixin.qq.com/pay/profitsharingquery"
+	ProfitSharingFinishUrl     = "https://api.mch.weixin.qq.com/secapi/pay/profitsharingfinish"
+	EntrustWebUrl              = "https://api.mch.weixin.qq.com/papay/entrustweb"
+	H5EntrustWebUrl            = "https://api.mch.weixin.qq.com/papay/h5entrustweb"
+	PreEntrustWebUrl           = "https://api.mch.weixin.qq.com/papay/preentrustweb"
+	PapPayApplyUrl             = "https://api.mch.weixin.qq.com/pay/pappayapply"
+	QueryContractUrl           = "https://api.mch.weixin.qq.com/papay/querycontract"
+	DeleteContractUrl          = "https://api.mch.weixin.qq.com/papay/deletecontract"
 	AuthCodeToOpenidUrlMch     = "https://api.weixin.qq.com/sns/oauth2/access_token"
 	SandboxMicroPayUrl         = "https://api.mch.weixin.qq.com/sandboxnew/pay/micropay"
 	SandboxUnifiedOrderUrl     = "https://api.mch.weixin.qq.com/sandboxnew/pay/unifiedorder"
@@ -33,4 +52,23 @@ const (
 	SandboxReportUrl           = "https://api.mch.weixin.qq.com/sandboxnew/payitil/report"
 	SandboxShortUrl            = "https://api.mch.weixin.qq.com/sandboxnew/tools/shorturl"
 	SandboxAuthCodeToOpenidUrl = "https://api.mch.weixin.qq.com/sandboxnew/tools/authcodetoopenid"
+	SandboxGetSignKeyUrl       = "https://api.mch.weixin.qq.com/sandboxnew/pay/getsignkey"
 )
+
+// 对账单类型
+const (
+	BillTypeAll            = "ALL"
+	BillTypeSuccess        = "SUCCESS"
+	BillTypeRefund         = "REFUND"
+	BillTypeRechargeRefund = "RECHARGE_REFUND"
+)
+
+// 资金账单的资金账户类型
+const (
+	AccountTypeBasic     = "Basic"
+	AccountTypeOperation = "Operation"
+	AccountTypeFees      = "Fees"
+)
+
+// APIv3 接口域名
+const V3BaseUrl = "https://api.mch.weixin.qq.com"
@@ -0,0 +1,93 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+)
+
+// 代金券接口的公共参数，未填写时使用默认值
+func (c *Client) fillCouponDefaults(params Params) {
+	if !params.ContainsKey("op_user_id") {
+		params.SetString("op_user_id", c.account.activeMchID())
+	}
+	if !params.ContainsKey("version") {
+		params.SetString("version", "1.0")
+	}
+	if !params.ContainsKey("type") {
+		params.SetString("type", "XML")
+	}
+}
+
+// 发放代金券，params中需要coupon_stock_id、partner_trade_no、openid，openid_count默认为1
+func (c *Client) SendCoupon(params Params) (Params, error) {
+	return c.SendCouponContext(context.Background(), params)
+}
+
+// 发放代金券，ctx可用于取消请求
+func (c *Client) SendCouponContext(ctx context.Context, params Params) (Params, error) {
+	for _, k := range []string{"coupon_stock_id", "partner_trade_no", "openid"} {
+		if params.GetString(k) == "" {
+			return nil, errors.New("send_coupon: " + k + " is required")
+		}
+	}
+	if !params.ContainsKey("openid_count") {
+		params.SetInt64("openid_count", 1)
+	}
+	c.fillCouponDefaults(params)
+	return c.InvokeContext(ctx, "SendCoupon", params)
+}
+
+// 查询代金券批次，params中需要coupon_stock_id
+func (c *Client) QueryCouponStock(params Params) (Params, error) {
+	return c.QueryCouponStockContext(context.Background(), params)
+}
+
+// 查询代金券批次，ctx可用于取消请求
+func (c *Client) QueryCouponStockContext(ctx context.Context, params Params) (Params, error) {
+	if params.GetString("coupon_stock_id") == "" {
+		return nil, errors.New("query_coupon_stock: coupon_stock_id is required")
+	}
+	c.fillCouponDefaults(params)
+	return c.InvokeContext(ctx, "QueryCouponStock", params)
+}
+
+// 查询代金券信息，params中需要coupon_id、openid、stock_id
+func (c *Client) QueryCouponsInfo(params Params) (Params, error) {
+	return c.QueryCouponsInfoContext(context.Background(), params)
+}
+
+// 查询代金券信息，ctx可用于取消请求
+func (c *Client) QueryCouponsInfoContext(ctx context.Context, params Params) (Params, error) {
+	for _, k := range []string{"coupon_id", "openid", "stock_id"} {
+		if params.GetString(k) == "" {
+			return nil, errors.New("querycouponsinfo: " + k + " is required")
+		}
+	}
+	c.fillCouponDefaults(params)
+	return c.InvokeContext(ctx, "QueryCouponsInfo", params)
+}
+
+// 返回用于CouponBudgetMonitor的批次查询
+func (c *Client) CouponStockSource() CouponStockSource {
+	return func(ctx context.Context, stockID string) (*CouponStock, error) {
+		params := make(Params)
+		params.SetString("coupon_stock_id", stockID)
+		res, err := c.QueryCouponStockContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		if res.GetString("return_code") != Success || res.GetString("result_code") != Success {
+			return nil, errors.New("query_coupon_stock failed: " + res.GetString("return_msg") + res.GetString("err_code_des"))
+		}
+		sent := res.GetInt64("is_send_num")
+		stock := &CouponStock{
+			StockID:           stockID,
+			RemainingQuantity: res.GetInt64("coupon_total") - sent,
+			RemainingAmount:   -1,
+		}
+		if res.ContainsKey("coupon_budget") && res.ContainsKey("coupon_value") {
+			stock.RemainingAmount = res.GetInt64("coupon_budget") - sent*res.GetInt64("coupon_value")
+		}
+		return stock, nil
+	}
+}
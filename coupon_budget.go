@@ -0,0 +1,81 @@
+package wxpay
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 代金券批次的剩余预算
+type CouponStock struct {
+	StockID           string
+	RemainingAmount   int64 // 剩余预算，单位为分，-1表示未知
+	RemainingQuantity int64 // 剩余可发放数量，-1表示未知
+}
+
+// 查询代金券批次的剩余预算
+type CouponStockSource func(ctx context.Context, stockID string) (*CouponStock, error)
+
+// 代金券批次预算监控。定时查询批次，剩余预算或数量低于阈值时调用OnLow，
+// 同一批次只在从阈值之上跌破时调用一次，补充预算后重新开始监控
+type CouponBudgetMonitor struct {
+	Source      CouponStockSource
+	StockIDs    []string
+	MinAmount   int64         // 剩余预算阈值，0表示不检查
+	MinQuantity int64         // 剩余数量阈值，0表示不检查
+	Interval    time.Duration // 检查间隔，为0时为5分钟
+	Clock       Clock
+	OnLow       func(stock *CouponStock)
+	OnError     func(stockID string, err error) // 可以为nil
+
+	mu  sync.Mutex
+	low map[string]bool
+}
+
+// 查询一次所有批次
+func (m *CouponBudgetMonitor) Check(ctx context.Context) {
+	for _, id := range m.StockIDs {
+		stock, err := m.Source(ctx, id)
+		if err != nil {
+			if m.OnError != nil {
+				m.OnError(id, err)
+			}
+			continue
+		}
+		if m.crossed(id, stock) && m.OnLow != nil {
+			m.OnLow(stock)
+		}
+	}
+}
+
+// 按Interval定时检查，直到ctx取消。可以用NewComponent包装以统一启动和停止
+func (m *CouponBudgetMonitor) Run(ctx context.Context) {
+	clock := clockOrSystem(m.Clock)
+	interval := m.Interval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(interval):
+		}
+	}
+}
+
+// 记录批次是否低于阈值，返回是否刚刚跌破阈值
+func (m *CouponBudgetMonitor) crossed(stockID string, stock *CouponStock) bool {
+	low := (m.MinAmount > 0 && stock.RemainingAmount >= 0 && stock.RemainingAmount < m.MinAmount) ||
+		(m.MinQuantity > 0 && stock.RemainingQuantity >= 0 && stock.RemainingQuantity < m.MinQuantity)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.low == nil {
+		m.low = make(map[string]bool)
+	}
+	wasLow := m.low[stockID]
+	m.low[stockID] = low
+	return low && !wasLow
+}
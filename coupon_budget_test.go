@@ -0,0 +1,37 @@
+package wxpay
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCouponBudgetMonitorDefaultInterval(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	clock := &fakeClock{now: start}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls int
+	var lows []string
+	m := &CouponBudgetMonitor{
+		Source: func(ctx context.Context, stockID string) (*CouponStock, error) {
+			calls++
+			if calls == 3 {
+				cancel()
+			}
+			return &CouponStock{StockID: stockID, RemainingAmount: 100, RemainingQuantity: -1}, nil
+		},
+		StockIDs:  []string{"1234567"},
+		MinAmount: 1000,
+		Clock:     clock,
+		OnLow:     func(stock *CouponStock) { lows = append(lows, stock.StockID) },
+	}
+	m.Run(ctx)
+	// Interval为0时每5分钟检查一次，而不是不停地查询
+	if elapsed := clock.now.Sub(start); elapsed < 10*time.Minute || elapsed%(5*time.Minute) != 0 {
+		t.Error(elapsed, calls)
+	}
+	if len(lows) != 1 {
+		t.Error(lows)
+	}
+}
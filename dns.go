@@ -0,0 +1,117 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// DNS缓存，解析失败时继续使用过期的解析结果，避免DNS故障导致支付不可用
+type DNSCache struct {
+	TTL      time.Duration // 解析结果的有效期
+	Resolver *net.Resolver // 为nil时使用net.DefaultResolver
+	Clock    Clock         // 为nil时使用SystemClock
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+	static  map[string][]string
+	dialer  net.Dialer
+}
+
+type dnsEntry struct {
+	ips     []string
+	expires time.Time
+}
+
+// 创建DNS缓存
+func NewDNSCache(ttl time.Duration) *DNSCache {
+	return &DNSCache{
+		TTL:     ttl,
+		entries: make(map[string]dnsEntry),
+		static:  make(map[string][]string),
+	}
+}
+
+// 为host指定固定的IP，设置后不再解析该host
+func (d *DNSCache) SetStatic(host string, ips ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.static[host] = ips
+}
+
+// 预先解析hosts，通常在启动时调用
+func (d *DNSCache) Preresolve(ctx context.Context, hosts ...string) error {
+	for _, host := range hosts {
+		if _, err := d.lookup(ctx, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 使用缓存的解析结果建立连接，可用作http.Transport的DialContext
+func (d *DNSCache) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+	ips, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("no addresses for host " + host)
+	}
+	for _, ip := range ips {
+		var conn net.Conn
+		if conn, err = d.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+func (d *DNSCache) lookup(ctx context.Context, host string) ([]string, error) {
+	d.mu.Lock()
+	if ips, ok := d.static[host]; ok {
+		d.mu.Unlock()
+		return ips, nil
+	}
+	entry, ok := d.entries[host]
+	d.mu.Unlock()
+	if ok && clockOrSystem(d.Clock).Now().Before(entry.expires) {
+		return entry.ips, nil
+	}
+
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ips, err := resolver.LookupHost(ctx, host)
+	if err != nil || len(ips) == 0 {
+		// 解析失败时使用过期的结果
+		if ok {
+			return entry.ips, nil
+		}
+		if err == nil {
+			err = errors.New("no address found for " + host)
+		}
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.entries[host] = dnsEntry{ips: ips, expires: clockOrSystem(d.Clock).Now().Add(d.TTL)}
+	d.mu.Unlock()
+	return ips, nil
+}
+
+// 设置DNS缓存，nil表示使用系统解析
+func (c *Client) SetDNSCache(d *DNSCache) {
+	c.dnsCache = d
+	c.transports.reset()
+}
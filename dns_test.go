@@ -0,0 +1,16 @@
+package wxpay
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDNSCacheNoAddresses(t *testing.T) {
+	d := NewDNSCache(time.Minute)
+	d.SetStatic("api.mch.weixin.qq.com")
+	conn, err := d.DialContext(context.Background(), "tcp", "api.mch.weixin.qq.com:443")
+	if err == nil || conn != nil {
+		t.Error(conn, err)
+	}
+}
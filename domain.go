@@ -0,0 +1,179 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// 微信支付接口的主域名和容灾域名
+const (
+	PrimaryDomain = "api.mch.weixin.qq.com"
+	BackupDomain  = "api2.mch.weixin.qq.com"
+)
+
+// 探测域名的延迟，返回错误表示域名不可用
+type DomainProbe func(ctx context.Context, domain string) (time.Duration, error)
+
+// 域名延迟探测器，定时探测候选域名并选择较快的可用域名。
+// 为避免在延迟相近的域名间来回切换，只有新域名比当前域名快Margin以上，或当前域名不可用时才切换
+type DomainProber struct {
+	Domains  []string      // 候选域名，为空时使用主域名和容灾域名，第一个为初始域名
+	Margin   time.Duration // 切换域名需要的最小延迟差
+	Interval time.Duration // Run的探测间隔，为0时为1分钟
+	Probe    DomainProbe   // 为nil时使用TLS握手耗时
+	Clock    Clock
+	OnSwitch func(from string, to string) // 切换域名时调用，可以为nil
+
+	mu      sync.Mutex
+	current string
+	latency map[string]time.Duration
+	healthy map[string]bool
+}
+
+func (p *DomainProber) domains() []string {
+	if len(p.Domains) == 0 {
+		return []string{PrimaryDomain, BackupDomain}
+	}
+	return p.Domains
+}
+
+// 当前选择的域名
+func (p *DomainProber) Current() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current == "" {
+		return p.domains()[0]
+	}
+	return p.current
+}
+
+// 最近一次探测的延迟，ok为false表示域名不可用或尚未探测
+func (p *DomainProber) Latency(domain string) (d time.Duration, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.latency[domain], p.healthy[domain]
+}
+
+// 探测所有候选域名并按需切换，所有域名都不可用时返回错误并保持当前域名
+func (p *DomainProber) ProbeOnce(ctx context.Context) error {
+	probe := p.Probe
+	if probe == nil {
+		probe = probeTLSHandshake
+	}
+	domains := p.domains()
+	latency := make(map[string]time.Duration, len(domains))
+	healthy := make(map[string]bool, len(domains))
+	var lastErr error
+	for _, domain := range domains {
+		d, err := probe(ctx, domain)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		latency[domain], healthy[domain] = d, true
+	}
+
+	p.mu.Lock()
+	from := p.current
+	if from == "" {
+		from = domains[0]
+	}
+	p.latency, p.healthy = latency, healthy
+	to := p.choose(from, domains)
+	p.current = to
+	p.mu.Unlock()
+
+	if to != from && p.OnSwitch != nil {
+		p.OnSwitch(from, to)
+	}
+	if len(healthy) == 0 {
+		return errors.New("no healthy domain: " + lastErr.Error())
+	}
+	return nil
+}
+
+// 选择域名，调用时持有mu
+func (p *DomainProber) choose(current string, domains []string) string {
+	best := ""
+	for _, domain := range domains {
+		if p.healthy[domain] && (best == "" || p.latency[domain] < p.latency[best]) {
+			best = domain
+		}
+	}
+	if best == "" {
+		return current
+	}
+	if !p.healthy[current] || p.latency[best]+p.Margin < p.latency[current] {
+		return best
+	}
+	return current
+}
+
+// 按Interval定时探测，直到ctx取消。探测失败时调用onError（可以为nil），可以用NewComponent包装以统一启动和停止
+func (p *DomainProber) Run(ctx context.Context, onError func(error)) {
+	clock := clockOrSystem(p.Clock)
+	interval := p.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	for {
+		if err := p.ProbeOnce(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(interval):
+		}
+	}
+}
+
+// 以TCP连接和TLS握手的耗时作为域名延迟
+func probeTLSHandshake(ctx context.Context, domain string) (time.Duration, error) {
+	start := time.Now()
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(domain, "443"))
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(start.Add(5 * time.Second))
+	if err := tls.Client(conn, &tls.Config{ServerName: domain}).Handshake(); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// 设置域名延迟探测器，设置后发往主域名的请求改为发往探测器选择的域名。nil表示始终使用主域名
+func (c *Client) SetDomainProber(p *DomainProber) {
+	c.domainProber = p
+}
+
+// 请求实际发往的地址，容灾切换的保持时间内发往容灾域名
+func (c *Client) routeUrl(rawUrl string) string {
+	if f := c.domainFailover; f != nil && f.active() {
+		return replaceHost(rawUrl, PrimaryDomain, f.backup())
+	}
+	if c.domainProber == nil {
+		return rawUrl
+	}
+	return replaceHost(rawUrl, PrimaryDomain, c.domainProber.Current())
+}
+
+// 将地址中的域名from替换为to，域名不是from时原样返回
+func replaceHost(rawUrl string, from string, to string) string {
+	if from == to {
+		return rawUrl
+	}
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host != from {
+		return rawUrl
+	}
+	u.Host = to
+	return u.String()
+}
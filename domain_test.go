@@ -0,0 +1,49 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDomainProber(t *testing.T) {
+	latency := map[string]time.Duration{PrimaryDomain: 30 * time.Millisecond, BackupDomain: 25 * time.Millisecond}
+	var switches []string
+	p := &DomainProber{
+		Margin: 10 * time.Millisecond,
+		Probe: func(ctx context.Context, domain string) (time.Duration, error) {
+			if d, ok := latency[domain]; ok {
+				return d, nil
+			}
+			return 0, errors.New("unreachable")
+		},
+		OnSwitch: func(from, to string) { switches = append(switches, to) },
+	}
+	ctx := context.Background()
+
+	// 差距小于Margin时不切换
+	if err := p.ProbeOnce(ctx); err != nil || p.Current() != PrimaryDomain {
+		t.Fatal(p.Current(), err)
+	}
+	latency[BackupDomain] = 15 * time.Millisecond
+	p.ProbeOnce(ctx)
+	if p.Current() != BackupDomain {
+		t.Error(p.Current())
+	}
+	// 当前域名不可用时立即切换
+	delete(latency, BackupDomain)
+	p.ProbeOnce(ctx)
+	if p.Current() != PrimaryDomain || len(switches) != 2 {
+		t.Error(p.Current(), switches)
+	}
+
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.SetDomainProber(&DomainProber{current: BackupDomain})
+	if u := client.routeUrl(OrderQueryUrl); u != "https://api2.mch.weixin.qq.com/pay/orderquery" {
+		t.Error(u)
+	}
+	if u := client.routeUrl(GetPublicKeyUrl); u != GetPublicKeyUrl {
+		t.Error(u)
+	}
+}
@@ -0,0 +1,157 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// 接口定义，新增接口时只需注册Endpoint并编写一个调用Invoke的方法
+type Endpoint struct {
+	Name         string        // 接口名，与Client上的方法名一致
+	Url          string        // 接口地址
+	SandboxUrl   string        // 仿真测试环境的地址，为空表示没有仿真测试环境
+	NeedsCert    bool          // 是否需要商户证书
+	VerifySign   bool          // 是否验证返回的签名
+	RawResponse  bool          // 成功时返回的不是XML，例如下载对账单，数据放在data字段中
+	FieldMapping *FieldMapping // 身份字段名，为nil时使用DefaultFieldMapping
+	Product      string        // 需要开通的产品，为空表示基础支付接口
+	Idempotent   bool          // 是否可以安全地重复请求，设置了RetryPolicy时失败后自动重试
+}
+
+var (
+	endpointsMu sync.RWMutex
+	endpoints   = make(map[string]Endpoint)
+)
+
+func init() {
+	for _, e := range []Endpoint{
+		{Name: "UnifiedOrder", Url: UnifiedOrderUrl, SandboxUrl: SandboxUnifiedOrderUrl, VerifySign: true},
+		{Name: "MicroPay", Url: MicroPayUrl, SandboxUrl: SandboxMicroPayUrl, VerifySign: true},
+		{Name: "Refund", Url: RefundUrl, SandboxUrl: SandboxRefundUrl, NeedsCert: true, VerifySign: true, Product: ProductRefund},
+		{Name: "OrderQuery", Url: OrderQueryUrl, SandboxUrl: SandboxOrderQueryUrl, VerifySign: true, Idempotent: true},
+		{Name: "RefundQuery", Url: RefundQueryUrl, SandboxUrl: SandboxRefundQueryUrl, VerifySign: true, Product: ProductRefund, Idempotent: true},
+		{Name: "Reverse", Url: ReverseUrl, SandboxUrl: SandboxReverseUrl, NeedsCert: true, VerifySign: true},
+		{Name: "CloseOrder", Url: CloseOrderUrl, SandboxUrl: SandboxCloseOrderUrl, VerifySign: true, Idempotent: true},
+		{Name: "DownloadBill", Url: DownloadBillUrl, SandboxUrl: SandboxDownloadBillUrl, RawResponse: true, Idempotent: true},
+		{Name: "DownloadFundFlow", Url: DownloadFundFlowUrl, SandboxUrl: SandboxDownloadFundFlowUrl, NeedsCert: true, RawResponse: true, Idempotent: true},
+		{Name: "Report", Url: ReportUrl, SandboxUrl: SandboxReportUrl, VerifySign: true},
+		{Name: "ShortUrl", Url: ShortUrl, SandboxUrl: SandboxShortUrl, VerifySign: true},
+		{Name: "AuthCodeToOpenid", Url: AuthCodeToOpenidUrl, SandboxUrl: SandboxAuthCodeToOpenidUrl, VerifySign: true},
+		// 企业付款的返回没有签名
+		{Name: "MchToCash", Url: MchToCashUrl, NeedsCert: true, FieldMapping: &MchPayFieldMapping, Product: ProductTransfer},
+		{Name: "GetTransferInfo", Url: GetTransferInfoUrl, NeedsCert: true, FieldMapping: &TransferInfoFieldMapping, Product: ProductTransfer},
+		{Name: "SendRedPack", Url: SendRedPackUrl, NeedsCert: true, FieldMapping: &RedPackFieldMapping, Product: ProductRedPack},
+		{Name: "SendGroupRedPack", Url: SendGroupRedPackUrl, NeedsCert: true, FieldMapping: &RedPackFieldMapping, Product: ProductRedPack},
+		{Name: "GetHbInfo", Url: GetHbInfoUrl, NeedsCert: true, FieldMapping: &TransferInfoFieldMapping, Product: ProductRedPack},
+		{Name: "SendCoupon", Url: SendCouponUrl, NeedsCert: true, VerifySign: true, FieldMapping: &TransferInfoFieldMapping, Product: ProductCoupon},
+		{Name: "QueryCouponStock", Url: QueryCouponStockUrl, VerifySign: true, FieldMapping: &TransferInfoFieldMapping, Product: ProductCoupon},
+		{Name: "QueryCouponsInfo", Url: QueryCouponsInfoUrl, VerifySign: true, FieldMapping: &TransferInfoFieldMapping, Product: ProductCoupon},
+		{Name: "PayBank", Url: PayBankUrl, NeedsCert: true, FieldMapping: &PayBankFieldMapping, Product: ProductTransfer},
+		{Name: "PreEntrustWeb", Url: PreEntrustWebUrl, VerifySign: true, FieldMapping: &PapayFieldMapping, Product: ProductPapay},
+		{Name: "PapPayApply", Url: PapPayApplyUrl, VerifySign: true, Product: ProductPapay},
+		{Name: "QueryContract", Url: QueryContractUrl, VerifySign: true, FieldMapping: &PapayFieldMapping, Product: ProductPapay},
+		{Name: "DeleteContract", Url: DeleteContractUrl, VerifySign: true, FieldMapping: &PapayFieldMapping, Product: ProductPapay},
+		{Name: "ProfitSharing", Url: ProfitSharingUrl, NeedsCert: true, VerifySign: true, FieldMapping: &ProfitSharingFieldMapping, Product: ProductProfitSharing},
+		{Name: "MultiProfitSharing", Url: MultiProfitSharingUrl, NeedsCert: true, VerifySign: true, FieldMapping: &ProfitSharingFieldMapping, Product: ProductProfitSharing},
+		{Name: "ProfitSharingQuery", Url: ProfitSharingQueryUrl, VerifySign: true, FieldMapping: &ProfitSharingQueryFieldMapping, Product: ProductProfitSharing},
+		{Name: "ProfitSharingFinish", Url: ProfitSharingFinishUrl, NeedsCert: true, VerifySign: true, FieldMapping: &ProfitSharingFieldMapping, Product: ProductProfitSharing},
+	} {
+		RegisterEndpoint(e)
+	}
+}
+
+// 注册接口定义，指定了FieldMapping时同时为接口地址注册字段名映射
+func RegisterEndpoint(e Endpoint) {
+	endpointsMu.Lock()
+	endpoints[e.Name] = e
+	endpointsMu.Unlock()
+	if e.FieldMapping != nil {
+		RegisterFieldMapping(e.Url, *e.FieldMapping)
+		if e.SandboxUrl != "" {
+			RegisterFieldMapping(e.SandboxUrl, *e.FieldMapping)
+		}
+	}
+}
+
+// 按接口名查找接口定义
+func LookupEndpoint(name string) (Endpoint, bool) {
+	endpointsMu.RLock()
+	defer endpointsMu.RUnlock()
+	e, ok := endpoints[name]
+	return e, ok
+}
+
+// 按接口名调用已注册的接口
+func (c *Client) Invoke(name string, params Params) (Params, error) {
+	return c.InvokeContext(context.Background(), name, params)
+}
+
+// 按接口名调用已注册的接口，ctx可用于取消请求
+func (c *Client) InvokeContext(ctx context.Context, name string, params Params) (Params, error) {
+	e, ok := LookupEndpoint(name)
+	if !ok {
+		return nil, errors.New("unknown endpoint " + name)
+	}
+	p, err := c.invoke(ctx, e, params)
+	if err == nil && c.bizErrors {
+		err = BizErrorOf(p)
+	}
+	return p, err
+}
+
+// 当前环境下接口的地址，没有仿真测试环境的接口始终使用正式地址
+func (c *Client) endpointUrl(e Endpoint) string {
+	if c.account.isSandbox && e.SandboxUrl != "" {
+		return e.SandboxUrl
+	}
+	return e.Url
+}
+
+func (c *Client) invoke(ctx context.Context, e Endpoint, params Params) (Params, error) {
+	if err := c.account.checkProduct(e); err != nil {
+		return nil, err
+	}
+	ctx = withAttemptCounter(ctx)
+	if policy := c.retryPolicy; policy != nil && e.Idempotent {
+		return c.withRetry(ctx, policy, func(ctx context.Context) (Params, error) {
+			return c.invokeOnce(ctx, e, params)
+		})
+	}
+	return c.invokeOnce(ctx, e, params)
+}
+
+// 发送一次请求并处理返回数据
+func (c *Client) invokeOnce(ctx context.Context, e Endpoint, params Params) (Params, error) {
+	url := c.endpointUrl(e)
+	var (
+		xmlStr string
+		err    error
+	)
+	if e.NeedsCert {
+		xmlStr, err = c.RawPostWithCert(ctx, url, params, true)
+	} else {
+		xmlStr, err = c.RawPostWithoutCert(ctx, url, params, true)
+	}
+	if e.RawResponse {
+		return rawResponseParams(xmlStr), err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.processResponseXmlWithType(xmlStr, e.VerifySign, c.signTypeFor(fieldMappingFor(url)))
+}
+
+func rawResponseParams(data string) Params {
+	// 如果出现错误，返回XML数据
+	if strings.Index(data, "<") == 0 {
+		return MustXmlToMap(data)
+	}
+	// 正常返回csv数据
+	p := make(Params)
+	p.SetString("return_code", Success)
+	p.SetString("return_msg", "ok")
+	p.SetString("data", data)
+	return p
+}
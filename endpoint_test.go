@@ -0,0 +1,37 @@
+package wxpay
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEndpoints(t *testing.T) {
+	client := NewClient(NewAccount("xxxxx", "xxx", "xxxxx", true))
+	e, ok := LookupEndpoint("OrderQuery")
+	if !ok || client.endpointUrl(e) != SandboxOrderQueryUrl {
+		t.Error(e)
+	}
+	if fieldMappingFor(MchToCashUrl) != MchPayFieldMapping {
+		t.Error(fieldMappingFor(MchToCashUrl))
+	}
+	caps := client.Capabilities()
+	if !caps.Endpoints["OrderQuery"] || caps.Endpoints["Refund"] || caps.Endpoints["MchToCash"] {
+		t.Error(caps.Endpoints)
+	}
+	if _, err := client.Invoke("NoSuchApi", make(Params)); err == nil {
+		t.Error("expected unknown endpoint error")
+	}
+}
+
+func TestAccountProducts(t *testing.T) {
+	account := NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false)
+	account.SetProducts(ProductRefund)
+	client := NewClient(account)
+	if _, err := client.MchToCash(make(Params)); !errors.Is(err, ErrProductNotEnabled) {
+		t.Error(err)
+	}
+	caps := client.Capabilities()
+	if caps.Endpoints["SendRedPack"] || !caps.Endpoints["UnifiedOrder"] {
+		t.Error(caps.Endpoints)
+	}
+}
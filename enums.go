@@ -0,0 +1,108 @@
+package wxpay
+
+import "strings"
+
+// 交易类型 trade_type
+const (
+	TradeTypeJSAPI    = "JSAPI"    // 公众号支付、小程序支付
+	TradeTypeNative   = "NATIVE"   // 扫码支付
+	TradeTypeApp      = "APP"      // APP支付
+	TradeTypeMicroPay = "MICROPAY" // 付款码支付
+	TradeTypeMWeb     = "MWEB"     // H5支付
+	TradeTypeFacePay  = "FACEPAY"  // 刷脸支付
+)
+
+// 中英文显示名称
+type DisplayName struct {
+	ZH string
+	EN string
+}
+
+var tradeTypeNames = map[string]DisplayName{
+	TradeTypeJSAPI:    {"公众号支付", "JSAPI Payment"},
+	TradeTypeNative:   {"扫码支付", "Native Payment"},
+	TradeTypeApp:      {"APP支付", "In-App Payment"},
+	TradeTypeMicroPay: {"付款码支付", "Quick Pay"},
+	TradeTypeMWeb:     {"H5支付", "H5 Payment"},
+	TradeTypeFacePay:  {"刷脸支付", "Face Payment"},
+}
+
+// 交易类型的显示名称
+func TradeTypeName(tradeType string) (DisplayName, bool) {
+	name, ok := tradeTypeNames[tradeType]
+	return name, ok
+}
+
+// 付款银行 bank_type 中不区分卡类型的取值
+const (
+	BankTypeBalance     = "CFT"    // 零钱
+	BankTypeBalanceFund = "LQT"    // 零钱通
+	BankTypeOthers      = "OTHERS" // 其他银行
+)
+
+// 银行卡的bank_type为 银行代码_DEBIT 或 银行代码_CREDIT
+var bankNames = map[string]DisplayName{
+	"ICBC":  {"工商银行", "ICBC"},
+	"ABC":   {"农业银行", "ABC"},
+	"BOC":   {"中国银行", "Bank of China"},
+	"CCB":   {"建设银行", "CCB"},
+	"COMM":  {"交通银行", "Bank of Communications"},
+	"PSBC":  {"邮政储蓄银行", "Postal Savings Bank of China"},
+	"CMB":   {"招商银行", "China Merchants Bank"},
+	"CMBC":  {"民生银行", "China Minsheng Bank"},
+	"CITIC": {"中信银行", "China CITIC Bank"},
+	"CEB":   {"光大银行", "China Everbright Bank"},
+	"CIB":   {"兴业银行", "Industrial Bank"},
+	"SPDB":  {"浦发银行", "SPD Bank"},
+	"GDB":   {"广发银行", "China Guangfa Bank"},
+	"PAB":   {"平安银行", "Ping An Bank"},
+	"HXB":   {"华夏银行", "Hua Xia Bank"},
+	"BCCB":  {"北京银行", "Bank of Beijing"},
+	"BOSH":  {"上海银行", "Bank of Shanghai"},
+	"NBCB":  {"宁波银行", "Bank of Ningbo"},
+	"HZB":   {"杭州银行", "Bank of Hangzhou"},
+	"NJCB":  {"南京银行", "Bank of Nanjing"},
+	"JSB":   {"江苏银行", "Bank of Jiangsu"},
+	"CZB":   {"浙商银行", "China Zheshang Bank"},
+	"CBHB":  {"渤海银行", "China Bohai Bank"},
+	"HKBEA": {"东亚银行", "Bank of East Asia"},
+	"SRCB":  {"上海农商银行", "Shanghai Rural Commercial Bank"},
+	"BJRCB": {"北京农商银行", "Beijing Rural Commercial Bank"},
+}
+
+var (
+	otherBankTypeNames = map[string]DisplayName{
+		BankTypeBalance:     {"零钱", "WeChat Pay Balance"},
+		BankTypeBalanceFund: {"零钱通", "WeChat Pay Balance Fund"},
+		BankTypeOthers:      {"其他银行", "Other Bank"},
+	}
+	cardTypeNames = map[string]DisplayName{
+		"DEBIT":  {"借记卡", "Debit Card"},
+		"CREDIT": {"信用卡", "Credit Card"},
+	}
+)
+
+// 付款银行的显示名称，例如CMB_CREDIT为 招商银行信用卡
+func BankTypeName(bankType string) (DisplayName, bool) {
+	if name, ok := otherBankTypeNames[bankType]; ok {
+		return name, true
+	}
+	i := strings.LastIndex(bankType, "_")
+	if i < 0 {
+		return DisplayName{}, false
+	}
+	bank, ok := bankNames[bankType[:i]]
+	if !ok {
+		return DisplayName{}, false
+	}
+	card, ok := cardTypeNames[bankType[i+1:]]
+	if !ok {
+		return DisplayName{}, false
+	}
+	return DisplayName{ZH: bank.ZH + card.ZH, EN: bank.EN + " " + card.EN}, true
+}
+
+// 付款银行是否为信用卡
+func IsCreditBankType(bankType string) bool {
+	return strings.HasSuffix(bankType, "_CREDIT")
+}
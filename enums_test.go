@@ -0,0 +1,26 @@
+package wxpay
+
+import "testing"
+
+func TestBankTypeName(t *testing.T) {
+	tests := []struct {
+		bankType string
+		zh       string
+		ok       bool
+	}{
+		{"CMB_CREDIT", "招商银行信用卡", true},
+		{"ICBC_DEBIT", "工商银行借记卡", true},
+		{"CFT", "零钱", true},
+		{"XXX_DEBIT", "", false},
+		{"ICBC", "", false},
+	}
+	for _, test := range tests {
+		name, ok := BankTypeName(test.bankType)
+		if ok != test.ok || name.ZH != test.zh {
+			t.Error(test.bankType, name, ok)
+		}
+	}
+	if name, _ := TradeTypeName(TradeTypeNative); name.EN != "Native Payment" {
+		t.Error(name)
+	}
+}
@@ -0,0 +1,125 @@
+package wxpay
+
+import (
+	"errors"
+	"strings"
+)
+
+// 跨v2、v3接口的统一错误分类。*BizError和*V3Error按错误码归类，可以使用errors.Is判断，
+// 例如 errors.Is(err, wxpay.ErrNotEnough) 同时适用于v2的NOTENOUGH和v3的NOT_ENOUGH
+var (
+	ErrParam          = errors.New("wxpay: invalid parameter")
+	ErrSign           = errors.New("wxpay: signature error")
+	ErrNotEnough      = errors.New("wxpay: insufficient balance")
+	ErrNoAuth         = errors.New("wxpay: no permission")
+	ErrFrequencyLimit = errors.New("wxpay: frequency limited")
+	ErrSystem         = errors.New("wxpay: system error, result unknown")
+	ErrOrderNotExist  = errors.New("wxpay: order does not exist")
+	ErrOrderPaid      = errors.New("wxpay: order is already paid")
+	ErrOrderClosed    = errors.New("wxpay: order is closed")
+	ErrOutTradeNoUsed = errors.New("wxpay: out_trade_no is already used")
+	ErrUserPaying     = errors.New("wxpay: user is paying")
+	ErrAuthCode       = errors.New("wxpay: auth code is invalid or expired")
+)
+
+// 错误码去掉下划线后对应的分类，v2的错误码大多不带下划线，v3的错误码带下划线
+var errorCodeKinds = map[string]error{
+	"PARAMERROR":           ErrParam,
+	"INVALIDREQUEST":       ErrParam,
+	"LACKPARAMS":           ErrParam,
+	"XMLFORMATERROR":       ErrParam,
+	"POSTDATAEMPTY":        ErrParam,
+	"NOTUTF8":              ErrParam,
+	"REQUIREPOSTMETHOD":    ErrParam,
+	"APPIDNOTEXIST":        ErrParam,
+	"MCHIDNOTEXIST":        ErrParam,
+	"APPIDMCHIDNOTMATCH":   ErrParam,
+	"SIGNERROR":            ErrSign,
+	"NOTENOUGH":            ErrNotEnough,
+	"NOAUTH":               ErrNoAuth,
+	"FREQUENCYLIMITED":     ErrFrequencyLimit,
+	"FREQUENCYLIMITEXCEED": ErrFrequencyLimit,
+	"FREQLIMIT":            ErrFrequencyLimit,
+	"SYSTEMERROR":          ErrSystem,
+	"BANKERROR":            ErrSystem,
+	"ORDERNOTEXIST":        ErrOrderNotExist,
+	"RESOURCENOTEXISTS":    ErrOrderNotExist,
+	"ORDERPAID":            ErrOrderPaid,
+	"ORDERCLOSED":          ErrOrderClosed,
+	"OUTTRADENOUSED":       ErrOutTradeNoUsed,
+	"USERPAYING":           ErrUserPaying,
+	"AUTHCODEEXPIRE":       ErrAuthCode,
+	"AUTHCODEINVALID":      ErrAuthCode,
+	"AUTHCODEERROR":        ErrAuthCode,
+}
+
+// 错误码去掉下划线后对应的英文说明
+var errorCodeDescriptions = map[string]string{
+	"PARAMERROR":           "invalid parameter",
+	"INVALIDREQUEST":       "invalid request",
+	"LACKPARAMS":           "missing required parameters",
+	"XMLFORMATERROR":       "malformed XML",
+	"POSTDATAEMPTY":        "request body is empty",
+	"NOTUTF8":              "request is not encoded in UTF-8",
+	"REQUIREPOSTMETHOD":    "request must use POST",
+	"APPIDNOTEXIST":        "appid does not exist",
+	"MCHIDNOTEXIST":        "mch_id does not exist",
+	"APPIDMCHIDNOTMATCH":   "appid and mch_id do not match",
+	"SIGNERROR":            "signature error",
+	"NOTENOUGH":            "insufficient balance",
+	"NOAUTH":               "no permission",
+	"FREQUENCYLIMITED":     "frequency limited",
+	"FREQUENCYLIMITEXCEED": "frequency limit exceeded",
+	"FREQLIMIT":            "frequency limited",
+	"SYSTEMERROR":          "system error, result unknown",
+	"BANKERROR":            "bank system error, result unknown",
+	"ORDERNOTEXIST":        "order does not exist",
+	"RESOURCENOTEXISTS":    "resource does not exist",
+	"ORDERPAID":            "order is already paid",
+	"ORDERCLOSED":          "order is closed",
+	"OUTTRADENOUSED":       "out_trade_no is already used",
+	"USERPAYING":           "user is paying, waiting for password input",
+	"AUTHCODEEXPIRE":       "auth code has expired",
+	"AUTHCODEINVALID":      "auth code is invalid",
+	"AUTHCODEERROR":        "auth code is invalid",
+}
+
+func normalizeErrorCode(code string) string {
+	return strings.ReplaceAll(strings.ToUpper(code), "_", "")
+}
+
+// 返回v2的err_code或v3的code对应的错误分类，未归类的错误码返回nil
+func ErrorKindOf(code string) error {
+	return errorCodeKinds[normalizeErrorCode(code)]
+}
+
+// 返回v2的err_code或v3的code对应的英文说明，未收录的错误码返回空字符串
+func ErrorDescriptionOf(code string) string {
+	return errorCodeDescriptions[normalizeErrorCode(code)]
+}
+
+// 返回错误码的英文说明，原始的中文说明见ReturnMsg和ErrCodeDes
+func (e *BizError) Description() string {
+	return ErrorDescriptionOf(e.ErrCode)
+}
+
+// 返回错误码的英文说明，原始说明见Message
+func (e *V3Error) Description() string {
+	return ErrorDescriptionOf(e.Code)
+}
+
+// 返回业务失败的错误分类，用于errors.Is
+func (e *BizError) Unwrap() error {
+	if kind := ErrorKindOf(e.ErrCode); kind != nil {
+		return kind
+	}
+	if isSignError(e.Params) {
+		return ErrSign
+	}
+	return nil
+}
+
+// 返回错误码的分类，用于errors.Is
+func (e *V3Error) Unwrap() error {
+	return ErrorKindOf(e.Code)
+}
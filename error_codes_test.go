@@ -0,0 +1,52 @@
+package wxpay
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorTaxonomy(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want error
+	}{
+		{BizErrorOf(Params{"return_code": Success, "result_code": Fail, "err_code": "NOTENOUGH"}), ErrNotEnough},
+		{&V3Error{StatusCode: 403, Code: "NOT_ENOUGH"}, ErrNotEnough},
+		{BizErrorOf(Params{"return_code": Success, "result_code": Fail, "err_code": "SIGNERROR"}), ErrSign},
+		{BizErrorOf(Params{"return_code": Fail, "return_msg": "签名错误"}), ErrSign},
+		{&V3Error{StatusCode: 401, Code: "SIGN_ERROR"}, ErrSign},
+		{&V3Error{StatusCode: 400, Code: "PARAM_ERROR"}, ErrParam},
+		{BizErrorOf(Params{"return_code": Success, "result_code": Fail, "err_code": "ORDERNOTEXIST"}), ErrOrderNotExist},
+		{&V3Error{StatusCode: 404, Code: "ORDER_NOT_EXIST"}, ErrOrderNotExist},
+		{fmt.Errorf("refund: %w", &V3Error{StatusCode: 500, Code: "SYSTEM_ERROR"}), ErrSystem},
+	} {
+		if !errors.Is(tc.err, tc.want) {
+			t.Errorf("%v is not %v", tc.err, tc.want)
+		}
+	}
+
+	err := BizErrorOf(Params{"return_code": Success, "result_code": Fail, "err_code": "SOME_NEW_CODE"})
+	if errors.Is(err, ErrSystem) || errors.Is(err, ErrParam) || ErrorKindOf("SOME_NEW_CODE") != nil {
+		t.Error("unknown codes must not be classified")
+	}
+}
+
+func TestErrorDescription(t *testing.T) {
+	for code := range errorCodeKinds {
+		if errorCodeDescriptions[code] == "" {
+			t.Errorf("%s has no description", code)
+		}
+	}
+
+	err := BizErrorOf(Params{"return_code": Success, "result_code": Fail, "err_code": "ORDERCLOSED", "err_code_des": "订单已关闭"}).(*BizError)
+	if err.ErrCodeDes != "订单已关闭" || err.Description() != "order is closed" {
+		t.Error(err.ErrCodeDes, err.Description())
+	}
+	if err.Error() != "wxpay: ORDERCLOSED: 订单已关闭 (order is closed)" {
+		t.Error(err)
+	}
+	if ErrorDescriptionOf("order_closed") != "order is closed" || ErrorDescriptionOf("SOME_NEW_CODE") != "" {
+		t.Error("unexpected description")
+	}
+}
@@ -0,0 +1,81 @@
+package wxpay_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/TurtleFromBupt/wxpay"
+)
+
+// 模拟微信支付接口，返回使用client签名的params
+type fakeWxpay struct {
+	client *wxpay.Client
+	reply  func(req wxpay.Params) wxpay.Params
+}
+
+func (f *fakeWxpay) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, _ := ioutil.ReadAll(r.Body)
+	res := f.reply(wxpay.MustXmlToMap(string(body)))
+	res.SetString("return_code", wxpay.Success)
+	res.SetString("sign", f.client.Sign(res))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(wxpay.MustMapToXml(res))),
+	}, nil
+}
+
+// JSAPI下单，然后处理支付结果通知
+func Example_jsapiCheckout() {
+	client := wxpay.NewClient(wxpay.NewAccount("wx2421b1c4370ec43b", "10000100", "192006250b4c09247ec02edce69f6a2d", false))
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return &fakeWxpay{client: client, reply: func(req wxpay.Params) wxpay.Params {
+			res := make(wxpay.Params)
+			res.SetString("result_code", wxpay.Success).
+				SetString("trade_type", req.GetString("trade_type")).
+				SetString("prepay_id", "wx201410272009395522657a690389285100")
+			return res
+		}}
+	})
+
+	params := make(wxpay.Params)
+	params.SetString("body", "test").
+		SetString("out_trade_no", "1409811653").
+		SetInt64("total_fee", 1).
+		SetString("spbill_create_ip", "127.0.0.1").
+		SetString("notify_url", "https://example.com/notify").
+		SetString("trade_type", wxpay.TradeTypeJSAPI).
+		SetString("openid", "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o")
+	res, err := client.UnifiedOrder(params)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("prepay_id:", res.GetString("prepay_id"))
+
+	// 微信支付回调通知
+	handler := &wxpay.NotifyHandler{
+		Client: client,
+		Primary: wxpay.NotifyConsumerFunc(func(n *wxpay.Notification) error {
+			fmt.Println("paid:", n.OutTradeNo, n.TotalFee)
+			return nil
+		}),
+	}
+	notify := make(wxpay.Params)
+	notify.SetString("return_code", wxpay.Success).
+		SetString("result_code", wxpay.Success).
+		SetString("out_trade_no", "1409811653").
+		SetString("total_fee", "1")
+	notify.SetString("sign", client.Sign(notify))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(wxpay.MustMapToXml(notify))))
+	fmt.Println("reply:", wxpay.MustXmlToMap(w.Body.String()).GetString("return_code"))
+
+	// Output:
+	// prepay_id: wx201410272009395522657a690389285100
+	// paid: 1409811653 0.01
+	// reply: SUCCESS
+}
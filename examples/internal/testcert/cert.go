@@ -0,0 +1,59 @@
+// Package testcert 为examples提供模拟接口使用的商户证书
+package testcert
+
+import "encoding/base64"
+
+// 示例使用的自签名商户证书，商户号和证书密码均为10000100，只能用于模拟接口
+const certBase64 = "" +
+	"MIIJcQIBAzCCCTcGCSqGSIb3DQEHAaCCCSgEggkkMIIJIDCCA9cGCSqGSIb3DQEHBqCCA8gwggPE" +
+	"AgEAMIIDvQYJKoZIhvcNAQcBMBwGCiqGSIb3DQEMAQMwDgQIFG3ynycFhnwCAggAgIIDkONrqRrY" +
+	"CiUrw1SRIaWYEcXW9i0Pca9dgg2NJULbYHvgWrUmcdhHQMVaV6ZDMEhYxScKlqeYMjOYLjVXlK01" +
+	"acj2Jn8dSEhXonNnxO9rZvypXoX9Bq8WlOZ77glOrSnEgYx7zrWEmDzQbsp1KwVVONgtMcx2tb0K" +
+	"yEQgCk8p0TOtBb8+P0WXiXVAjXsdXLEGMMcV9l/iUY56fKp5QzFgVIblZGqnzkl3/TELKdCQwtH3" +
+	"bHvOm1HzVAjLT+3oZlv1t4Y5SU+uXidm2nZDcyoAiZS06/ZYfaZRp/UF7ooMpeiS869DJASa0EWm" +
+	"G4EJUYqrm0WXr5xA+WH74xRTgDuDS9CBQB5srCeEjA0DaSb6HMc3KS+Ph9IhIV4tO0sHWlXYb0AG" +
+	"pdgJ38Nl1EFtuUU6LY4yW/fuqYwtZeWKe9EiZR9Hx9T6ru+mTJUMMqQNq6wLz3gspADSAe+JwjbU" +
+	"4/uXkfcnHOmzRMwTzEF4kvEoIAPytv3dOXiqAgVXT6JFPlqFGizfuz8jqxr+sLiH3qoE3hYpfJhy" +
+	"u+F2VariwOPFwg+VwcvHdgy0NwN7jV3i3gekRxO+OBVP61lvNUupPLCu2bsoTYW84r0YVBL6iu8X" +
+	"Ay+F3iEzlRUmY/Yv+3B8DEqZeTw5RmSouXl5BSXLVdmG+VUAr2exwPtxkmUoA+KboByhegwqMs3L" +
+	"yo4FS3I94vha80X4UP/LzK6+Sn/ByjzLBKGjGaW78jqjBF6RtK5lfEpRsbY69PpMdeEQ2r98cC38" +
+	"CUWB/uAI8O61OkyBVoyTC+UzHv3N1lqw3wKzLEVw/u4loFm38Vm8iQm1If9SWYJoinqkQA40E9FR" +
+	"o7A4gIx0ewZvHG7S0ugAJ4vLhwyVfRq6ZEWSoSDIh5Kcvl6GMPgrSiodTEc3jBgI6jrcMdPxtYjw" +
+	"kZyygvUMChmKD3z3/fYpB4C4PNeCzFGjeFd0kpa8gIhmj0rxHw2f/3c+HTBJudpeHHK9vUgWORSH" +
+	"LAVBE+odP2uaCpO4u1/9EFub3dzdlKaj+taXKvgMQY2wOtpG8knMMtAwQT/ZmFaqxeZq3/EBDVRu" +
+	"U4GfeylK0+w5mis75DNDxo06vGuJt97DEiWSGAEb3YpDctfJgLT2N50SGQOcqkyMovl+NIXcETw5" +
+	"NBRliynYFw5RPNQCirKIlGdhI9Ft5yYMlesRBGeG07qkr6e2qYl4S/rP1EEXLD7qbiXHVDCCBUEG" +
+	"CSqGSIb3DQEHAaCCBTIEggUuMIIFKjCCBSYGCyqGSIb3DQEMCgECoIIE7jCCBOowHAYKKoZIhvcN" +
+	"AQwBAzAOBAjEzj21eLJjvQICCAAEggTIQF58XZPwrvBlLn70B99V/6YBg/ayg0tmZZKrLbzvgVOO" +
+	"3gkW61HTnZqCDWzv7vghD6WvEMWdJN0JwF20OGPIG/XtygfKi/0tdBvVRjgXyuu7QoiQpLqOTvW2" +
+	"SQkbgWC7AnyBzBMqCC0eVd/dQ0wBRRQUQgeG0r+QV3n1QD+WcO11eF1vK5aJtGmRja3dMt25Sz4S" +
+	"tpSsBMRW69Hy+VEko4BmGVk3lCeVW8UblRhuNEFENdpU/DfaRsiTFX8R5HkTbxweD5k+dscGOgLN" +
+	"6nNy7vM58dw53+DHftX8mCC+fbudVczQ6yInHH4x8M/M4HfOhTkLO1qCErLjGKuO6cd43YzGFuc+" +
+	"zwQzeylXHRHSkx48A/rMS7Re7KnwVE/onvuDfqugFJC1oAZfBoRRxSSBkek9LIA9fp+BMFq6WTGN" +
+	"JQNhbBHPfOpvBwsKEWiKXeMxJhBcxQaoaSWtceDM/RNAGPCrb7298EJWzNoLr5mOMCSjJW4LZ4bm" +
+	"fliLSNTV6dPPf1f5ReskH6GtGWeHuzlb2Dw+PcqDR3/dXJoe7c9W09Xc6zDNQY9EJ0vFJ0cst9i8" +
+	"fKOKiVlC/0KHfn7/VvVq4pEoczS8uvvSb4n3uztSYnIb/mBQ7mV2zv983aLKGXT9EMfgIvI5BM7T" +
+	"qhJCurU1SnjzI1c1pGK0qrfE6xSsqKrBWEURSHr3l4xERnhIGM7qIgixwrR3PShubKQSfWhebPsK" +
+	"xt3tb7PD//9ffrQjypiTqOuZwNGdyRBzkFZj4i5J09vZl4GXQYWMngMrahzoI7aPbf2a0NuVIbMW" +
+	"FufkD7dH6tEnRk9/3b7pauL1rKGAc3rT/ZoMqOAXFwUpBchwU+Z8+2REfEUoppXp31H2Z9Y3sMyx" +
+	"u9SM/UK/A6NcEaKrSEW6vQGdLpSQuEbcXwyuICWYyM7w+HSTecBxX2TJ8B/MAZ1C+mxqxI8LFiXP" +
+	"0KMsaqJrjTV3WqUiyl0M1UyFhdQ9WpnnIjZGe9VbJMRc0M+TSuPgj2f2ndqr2+xwfgCBpNRE5Llf" +
+	"GBAIL8YudDWYmQwWzUnWU37jjrLpbZKlA57INg8nAz1s+/p+fvXpwnLG3y/o5fWmpbs0yZ9cMxhB" +
+	"zjUJzxukXMli67YBxdhi15VAwV9tyn06XQWMVBfSheJ75QV1dSkGvSJpQDOG2UWXO8KnZx3xSgK9" +
+	"LiKGx7tWw66LLI8MSvZ/qDUBzN/ph3i6tYXTMZnxJNI5Y6FjFJLKCqCUTosutj/8ga0qVee0BMjE" +
+	"A92UIrYvDH5pLoWNWjkOlaUJLU2vH3L7Y5N8iKOgULMyGig5l2jFV/dKqOQ1Lju6AgzOTi7jsuTg" +
+	"RTtmApHe7xJua3B/BozYJYtDniQ2bT9pXDgJCtRb2dXGwJu2OU+90TRVKshhzgt4xgOTYrX3fgaF" +
+	"EvlPs2Ba1Y1QKp6eFCW85YfpbWIU2WBVLQNC8BaCCv+ItqFR+coOyT6hpbdFPuIwXMQIR8P+ABxE" +
+	"yk09cxTWa0+vPJquZYcmby1KkKeLwvd8NXZa6YvhnumKauvj5XMK+OdQVrxAZmy9Zt/7GmTDbowr" +
+	"j9ZpL4ei5q2Ezhb/aO1ek0k7nYZ2jb8SZrrX4l4HY2lVIl+P4nPF6IHskN5YzTcbwA3lMSUwIwYJ" +
+	"KoZIhvcNAQkVMRYEFA+RJ4MGhWCogRWJi83vylRvhReeMDEwITAJBgUrDgMCGgUABBTV/76vQ/x6" +
+	"Tr/eORGp89Adxk620AQIqx1eXdMFYsECAggA"
+
+// 返回示例使用的PKCS#12商户证书，可传给Account.SetCertData
+func Cert() []byte {
+	data, err := base64.StdEncoding.DecodeString(certBase64)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,67 @@
+// JSAPI支付：统一下单，生成前端调起支付的参数，再处理支付结果通知
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/TurtleFromBupt/wxpay"
+)
+
+const apiKey = "192006250b4c09247ec02edce69f6a2d"
+
+func main() {
+	client := wxpay.NewClient(wxpay.NewAccount("wx2421b1c4370ec43b", "10000100", apiKey, false))
+	// 使用模拟接口运行示例，接入真实环境时去掉SetTransportWrapper即可
+	mock := &wxpay.MockServer{ApiKey: apiKey}
+	client.SetTransportWrapper(mock.Wrap)
+	mock.Handle("/pay/unifiedorder", func(req wxpay.Params) wxpay.Params {
+		return wxpay.Params{
+			"result_code": wxpay.Success,
+			"trade_type":  req.GetString("trade_type"),
+			"prepay_id":   "wx201410272009395522657a690389285100",
+		}
+	})
+
+	// 统一下单
+	params := make(wxpay.Params)
+	params.SetString("body", "test").
+		SetString("out_trade_no", "1409811653").
+		SetInt64("total_fee", 1).
+		SetString("spbill_create_ip", "127.0.0.1").
+		SetString("notify_url", "https://example.com/notify").
+		SetString("trade_type", wxpay.TradeTypeJSAPI).
+		SetString("openid", "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o")
+	res, err := client.UnifiedOrder(params)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if res.GetString("result_code") != wxpay.Success {
+		log.Fatal(res.GetString("err_code_des"))
+	}
+
+	// 前端使用这些参数调用WeixinJSBridge的getBrandWCPayRequest
+	payParams := client.GetJSAPIPayParams(res.GetString("prepay_id"))
+	fmt.Println("package:", payParams.GetString("package"))
+
+	// 支付结果通知，实际部署时注册为notify_url的处理器
+	handler := &wxpay.NotifyHandler{
+		Client: client,
+		Primary: wxpay.NotifyConsumerFunc(func(n *wxpay.Notification) error {
+			fmt.Println("paid:", n.OutTradeNo, n.TotalFee)
+			return nil
+		}),
+	}
+	notify := mock.Notify(wxpay.Params{
+		"result_code":    wxpay.Success,
+		"out_trade_no":   "1409811653",
+		"transaction_id": "1004400740201409030005092168",
+		"total_fee":      "1",
+	})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(notify)))
+	fmt.Println("reply:", wxpay.MustXmlToMap(w.Body.String()).GetString("return_code"))
+}
@@ -0,0 +1,10 @@
+package main
+
+// JSAPI下单并处理支付结果通知，使用wxpay.MockServer模拟微信支付接口
+func Example() {
+	main()
+	// Output:
+	// package: prepay_id=wx201410272009395522657a690389285100
+	// paid: 1409811653 0.01
+	// reply: SUCCESS
+}
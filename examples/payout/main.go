@@ -0,0 +1,95 @@
+// 企业付款到零钱，并用次日的资金账单对账
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/TurtleFromBupt/wxpay"
+	"github.com/TurtleFromBupt/wxpay/examples/internal/testcert"
+)
+
+const apiKey = "192006250b4c09247ec02edce69f6a2d"
+
+const fundFlowData = "记账时间,微信支付业务单号,资金流水单号,业务名称,业务类型,收支类型,收支金额（元）,账户结余（元）,资金变更提交申请人,备注,业务凭证号\r\n" +
+	"`2020-05-01 10:00:00,`10100000000000000001,`4200000001,`企业付款,`企业付款,`支出,`1.00,`99.00,`10000100,`,`1000000001\r\n" +
+	"`2020-05-01 10:05:00,`10100000000000000002,`4200000002,`企业付款,`企业付款,`支出,`2.50,`96.50,`10000100,`,`1000000002\r\n" +
+	"资金流水总笔数,收入笔数,收入金额,支出笔数,支出金额\r\n" +
+	"`2,`0,`0.00,`2,`3.50\r\n"
+
+func main() {
+	account := wxpay.NewAccount("wx2421b1c4370ec43b", "10000100", apiKey, false)
+	// 企业付款需要商户证书，实际使用时为apiclient_cert.p12
+	if err := account.SetCertData(testcert.Cert()); err != nil {
+		log.Fatal(err)
+	}
+	client := wxpay.NewClient(account)
+	// 使用模拟接口运行示例，接入真实环境时去掉SetTransportWrapper即可
+	mock := &wxpay.MockServer{ApiKey: apiKey}
+	client.SetTransportWrapper(mock.Wrap)
+	var seq int
+	mock.Handle("/mmpaymkttransfers/promotion/transfers", func(req wxpay.Params) wxpay.Params {
+		seq++
+		return wxpay.Params{
+			"result_code":      wxpay.Success,
+			"partner_trade_no": req.GetString("partner_trade_no"),
+			"payment_no":       fmt.Sprintf("101000000000000000%02d", seq),
+		}
+	})
+	mock.HandleRaw("/pay/downloadfundflow", func(wxpay.Params) string {
+		return fundFlowData
+	})
+
+	// 付款，记录商户订单号与微信付款单号的对应关系
+	payments := make(map[string]int64) // payment_no -> 金额（分）
+	for i, amount := range []int64{100, 250} {
+		params := make(wxpay.Params)
+		params.SetString("partner_trade_no", fmt.Sprintf("P2020050100%d", i+1)).
+			SetString("openid", "oxTWIuGaIt6gTKsQRLau2M0yL16E").
+			SetString("check_name", "NO_CHECK").
+			SetInt64("amount", amount).
+			SetString("desc", "提现").
+			SetString("spbill_create_ip", "127.0.0.1")
+		res, err := client.MchToCash(params)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if res.GetString("result_code") != wxpay.Success {
+			log.Fatal(res.GetString("err_code_des"))
+		}
+		payments[res.GetString("payment_no")] = amount
+	}
+
+	// 下载基本账户的资金账单，逐笔核对付款单号和金额
+	params := make(wxpay.Params)
+	params.SetString("bill_date", "20200501").
+		SetString("account_type", wxpay.AccountTypeBasic)
+	res, err := client.DownloadFundFlow(params)
+	if err != nil {
+		log.Fatal(err)
+	}
+	flow, err := wxpay.ParseFundFlow(res.GetString("data"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var matched int
+	for _, r := range flow.Records {
+		amount, err := wxpay.ParseDecimalAmount(r.Amount, wxpay.CNY)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if want, ok := payments[r.TransactionID]; ok && want == amount.Value && r.Direction == wxpay.FundFlowExpense {
+			matched++
+			delete(payments, r.TransactionID)
+		}
+	}
+	fmt.Printf("matched %d payouts, %d missing from fund flow\n", matched, len(payments))
+
+	totals, err := wxpay.SummarizeFundFlow(flow.Records)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for key, t := range totals {
+		fmt.Println(key.Date, key.BizType, "expense:", t.Expense)
+	}
+}
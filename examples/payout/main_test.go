@@ -0,0 +1,9 @@
+package main
+
+// 企业付款后下载资金账单对账，使用wxpay.MockServer模拟微信支付接口
+func Example() {
+	main()
+	// Output:
+	// matched 2 payouts, 0 missing from fund flow
+	// 2020-05-01 企业付款 expense: 3.50
+}
@@ -0,0 +1,94 @@
+// 申请退款，并处理退款结果通知
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/TurtleFromBupt/wxpay"
+	"github.com/TurtleFromBupt/wxpay/examples/internal/testcert"
+)
+
+const apiKey = "192006250b4c09247ec02edce69f6a2d"
+
+// 商户自己的退款记录
+type refundRecord struct {
+	OutRefundNo string
+	RefundFee   int64
+	Status      string
+}
+
+type refundStore map[string]*refundRecord
+
+func (s refundStore) FindRefund(outRefundNo string) (interface{}, error) {
+	r, ok := s[outRefundNo]
+	if !ok {
+		return nil, errors.New("unknown refund " + outRefundNo)
+	}
+	return r, nil
+}
+
+func main() {
+	account := wxpay.NewAccount("wx2421b1c4370ec43b", "10000100", apiKey, false)
+	// 退款需要商户证书，实际使用时为apiclient_cert.p12
+	if err := account.SetCertData(testcert.Cert()); err != nil {
+		log.Fatal(err)
+	}
+	client := wxpay.NewClient(account)
+	// 使用模拟接口运行示例，接入真实环境时去掉SetTransportWrapper即可
+	mock := &wxpay.MockServer{ApiKey: apiKey}
+	client.SetTransportWrapper(mock.Wrap)
+	mock.Handle("/secapi/pay/refund", func(req wxpay.Params) wxpay.Params {
+		return wxpay.Params{
+			"result_code":   wxpay.Success,
+			"out_trade_no":  req.GetString("out_trade_no"),
+			"out_refund_no": req.GetString("out_refund_no"),
+			"refund_id":     "50000408942018111907145868882",
+			"refund_fee":    req.GetString("refund_fee"),
+		}
+	})
+
+	// 申请退款，退款结果以异步通知为准
+	store := refundStore{}
+	params := make(wxpay.Params)
+	params.SetString("out_trade_no", "1409811653").
+		SetString("out_refund_no", "R1409811653").
+		SetInt64("total_fee", 100).
+		SetInt64("refund_fee", 100).
+		SetString("notify_url", "https://example.com/refund_notify")
+	res, err := client.Refund(params, wxpay.WithRefundDesc("商品已售完"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if res.GetString("result_code") != wxpay.Success {
+		log.Fatal(res.GetString("err_code_des"))
+	}
+	store["R1409811653"] = &refundRecord{OutRefundNo: "R1409811653", RefundFee: 100, Status: "PROCESSING"}
+	fmt.Println("refund accepted:", res.GetString("refund_id"))
+
+	// 退款结果通知，实际部署时注册为notify_url的处理器
+	handler := &wxpay.RefundNotifyHandler{
+		Client: client,
+		Store:  store,
+		Callback: func(record interface{}, info wxpay.Params) error {
+			r := record.(*refundRecord)
+			r.Status = info.GetString("refund_status")
+			fmt.Println("refund", r.OutRefundNo, r.Status)
+			return nil
+		},
+	}
+	notify := mock.RefundNotify(wxpay.Params{
+		"out_trade_no":  "1409811653",
+		"out_refund_no": "R1409811653",
+		"refund_id":     "50000408942018111907145868882",
+		"refund_fee":    "100",
+		"refund_status": "SUCCESS",
+	})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/refund_notify", strings.NewReader(notify)))
+	fmt.Println("reply:", wxpay.MustXmlToMap(w.Body.String()).GetString("return_code"))
+}
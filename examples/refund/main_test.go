@@ -0,0 +1,10 @@
+package main
+
+// 申请退款并处理退款结果通知，使用wxpay.MockServer模拟微信支付接口
+func Example() {
+	main()
+	// Output:
+	// refund accepted: 50000408942018111907145868882
+	// refund R1409811653 SUCCESS
+	// reply: SUCCESS
+}
@@ -0,0 +1,24 @@
+package wxpay
+
+// 额外参数提供者，返回的参数在签名前合并到请求中，请求中已有的字段不会被覆盖
+type ExtraParamsProvider func(url string, params Params) Params
+
+// 为接口地址注册额外参数提供者，url为空时对所有接口生效
+func (c *Client) AddExtraParamsProvider(url string, provider ExtraParamsProvider) {
+	if c.extraParams == nil {
+		c.extraParams = make(map[string][]ExtraParamsProvider)
+	}
+	c.extraParams[url] = append(c.extraParams[url], provider)
+}
+
+func (c *Client) mergeExtraParams(url string, params Params) {
+	for _, key := range []string{"", url} {
+		for _, provider := range c.extraParams[key] {
+			for k, v := range provider(url, params) {
+				if !params.ContainsKey(k) {
+					params.SetString(k, v)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// 容灾域名切换：发往主域名的请求在建立连接阶段失败（DNS解析失败、连接失败）时，
+// 重新生成nonce_str并签名后向容灾域名重发一次。此时请求尚未到达微信，重发不会造成重复交易；
+// 读取超时等请求可能已被处理的错误不切换。切换成功后Stickiness时间内的请求直接发往容灾域名。
+// 设置AttemptTimeout后，主域名的请求超过该时间未完成时也会切换，此时微信可能已处理了请求，
+// 重复下单、退款依靠商户订单号、商户退款单号去重
+type DomainFailover struct {
+	Backup         string        // 容灾域名，为空时为BackupDomain
+	Stickiness     time.Duration // 切换后继续使用容灾域名的时间，为0时为5分钟
+	AttemptTimeout time.Duration // 每次请求的超时时间，与ctx的截止时间相互独立，0表示不单独限制
+	Clock          Clock
+	OnFailover     func(from string, to string, err error) // 切换到容灾域名时调用，可以为nil
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (f *DomainFailover) backup() string {
+	if f.Backup == "" {
+		return BackupDomain
+	}
+	return f.Backup
+}
+
+// 是否处于切换后的保持时间内
+func (f *DomainFailover) active() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return clockOrSystem(f.Clock).Now().Before(f.until)
+}
+
+// 容灾域名请求成功，在Stickiness时间内继续使用容灾域名
+func (f *DomainFailover) stick(cause error) {
+	stickiness := f.Stickiness
+	if stickiness <= 0 {
+		stickiness = 5 * time.Minute
+	}
+	f.mu.Lock()
+	f.until = clockOrSystem(f.Clock).Now().Add(stickiness)
+	f.mu.Unlock()
+	if f.OnFailover != nil {
+		f.OnFailover(PrimaryDomain, f.backup(), cause)
+	}
+}
+
+// 设置容灾域名切换，nil表示不切换
+func (c *Client) SetDomainFailover(f *DomainFailover) {
+	c.domainFailover = f
+}
+
+// 请求target失败后应重发的容灾地址，ok为false表示不重发。ctx已结束时不重发
+func (c *Client) failoverUrl(ctx context.Context, target string, err error) (backupUrl string, ok bool) {
+	f := c.domainFailover
+	if f == nil || err == nil || ctx.Err() != nil {
+		return "", false
+	}
+	if !isConnectError(err) && !(f.AttemptTimeout > 0 && errors.Is(err, context.DeadlineExceeded)) {
+		return "", false
+	}
+	backupUrl = replaceHost(target, PrimaryDomain, f.backup())
+	return backupUrl, backupUrl != target
+}
+
+// 是否为建立连接阶段的错误，此时请求数据尚未发出
+func isConnectError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
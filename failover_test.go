@@ -0,0 +1,156 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDomainFailover(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	var hosts []string
+	primaryErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			hosts = append(hosts, r.URL.Host)
+			if r.URL.Host == PrimaryDomain {
+				return nil, primaryErr
+			}
+			res := make(Params)
+			res.SetString("return_code", Success).SetString("result_code", Success)
+			res.SetString("sign", client.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	var failovers int
+	client.SetDomainFailover(&DomainFailover{
+		Stickiness: time.Minute,
+		Clock:      clock,
+		OnFailover: func(from, to string, err error) { failovers++ },
+	})
+
+	query := func() error {
+		params := make(Params)
+		params.SetString("out_trade_no", "1409811653")
+		_, err := client.OrderQuery(params)
+		return err
+	}
+	if err := query(); err != nil {
+		t.Fatal(err)
+	}
+	if err := query(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(hosts, ",") != PrimaryDomain+","+BackupDomain+","+BackupDomain || failovers != 1 {
+		t.Error(hosts, failovers)
+	}
+
+	// 保持时间过后恢复使用主域名
+	clock.now = clock.now.Add(2 * time.Minute)
+	hosts = nil
+	if err := query(); err != nil || len(hosts) != 2 || hosts[0] != PrimaryDomain {
+		t.Error(hosts, err)
+	}
+
+	// 请求可能已经发出的错误不切换
+	primaryErr = &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+	clock.now = clock.now.Add(2 * time.Minute)
+	hosts = nil
+	if err := query(); err == nil || len(hosts) != 1 {
+		t.Error(hosts, err)
+	}
+}
+
+func TestDomainFailoverAttemptTimeout(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.URL.Host == PrimaryDomain {
+				// 主域名无响应，直到请求被取消
+				<-r.Context().Done()
+				return nil, r.Context().Err()
+			}
+			res := make(Params)
+			res.SetString("return_code", Success).SetString("result_code", Success)
+			res.SetString("sign", client.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+	client.SetDomainFailover(&DomainFailover{AttemptTimeout: 50 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	params := make(Params)
+	params.SetString("out_trade_no", "1409811653")
+	start := time.Now()
+	if _, err := client.OrderQueryContext(ctx, params); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Error(elapsed)
+	}
+}
+
+func TestDomainFailoverResigns(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	var signed []bool
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(r.Body)
+			signed = append(signed, client.ValidSign(MustXmlToMap(string(body))))
+			if r.URL.Host == PrimaryDomain {
+				return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+			}
+			res := make(Params)
+			res.SetString("return_code", Success).SetString("result_code", Fail).SetString("err_code", "SYSTEMERROR")
+			res.SetString("sign", client.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+	client.SetDomainFailover(&DomainFailover{Stickiness: time.Nanosecond})
+	client.SetRetryPolicy(&RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+	var attempts []Attempt
+	client.SetAttemptHook(func(a Attempt) { attempts = append(attempts, a) })
+
+	params := make(Params)
+	params.SetString("out_trade_no", "1409811653")
+	if _, err := client.OrderQuery(params); err != nil {
+		t.Fatal(err)
+	}
+	// 两次调用各自包含一次主域名请求和一次容灾域名请求
+	if len(attempts) != 4 {
+		t.Fatal(attempts)
+	}
+	nonces := make(map[string]bool)
+	for i, a := range attempts {
+		nonces[a.NonceStr] = true
+		host := PrimaryDomain
+		if i%2 == 1 {
+			host = BackupDomain
+		}
+		if a.Number != i+1 || !strings.Contains(a.Url, host) || !signed[i] {
+			t.Error(i, a, signed[i])
+		}
+	}
+	if len(nonces) != 4 {
+		t.Error(nonces)
+	}
+}
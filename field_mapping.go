@@ -0,0 +1,55 @@
+package wxpay
+
+import "sync"
+
+// 请求中身份字段的名称，部分接口使用与统一下单不同的字段名
+type FieldMapping struct {
+	AppID    string // 公众账号ID字段名，例如appid、mch_appid、wxappid，为空表示不发送
+	MchID    string // 商户号字段名，例如mch_id、mchid
+	SignType bool   // 是否发送sign_type
+	// 是否支持服务商模式，支持时按账号设置填充sub_appid、sub_mch_id
+	SubMerchant bool
+	// 接口只支持的签名类型，例如分账接口只支持HMAC-SHA256，为空时使用客户端的签名类型
+	FixedSignType string
+}
+
+var (
+	// 大部分接口使用的字段名
+	DefaultFieldMapping = FieldMapping{AppID: "appid", MchID: "mch_id", SignType: true, SubMerchant: true}
+	// 企业付款接口，appid->mch_appid，mch_id->mchid，只支持MD5签名
+	MchPayFieldMapping = FieldMapping{AppID: "mch_appid", MchID: "mchid", FixedSignType: MD5}
+	// 企业付款查询、红包查询、代金券接口，字段名与统一下单相同，但只支持MD5签名，不发送sign_type
+	TransferInfoFieldMapping = FieldMapping{AppID: "appid", MchID: "mch_id", FixedSignType: MD5}
+	// 企业付款到银行卡接口，只发送mch_id，只支持MD5签名
+	PayBankFieldMapping = FieldMapping{MchID: "mch_id", FixedSignType: MD5}
+	// 现金红包接口，appid->wxappid，只支持MD5签名
+	RedPackFieldMapping = FieldMapping{AppID: "wxappid", MchID: "mch_id", FixedSignType: MD5}
+	// 委托代扣的签约、查询和解约接口，只支持MD5签名，不发送sign_type
+	PapayFieldMapping = FieldMapping{AppID: "appid", MchID: "mch_id", FixedSignType: MD5}
+	// 分账接口，只支持HMAC-SHA256签名
+	ProfitSharingFieldMapping = FieldMapping{AppID: "appid", MchID: "mch_id", SignType: true, FixedSignType: HMACSHA256, SubMerchant: true}
+	// 查询分账结果接口，不发送appid
+	ProfitSharingQueryFieldMapping = FieldMapping{MchID: "mch_id", SignType: true, FixedSignType: HMACSHA256, SubMerchant: true}
+)
+
+var (
+	fieldMappingsMu sync.RWMutex
+	// 按接口地址配置的字段名，未配置的接口使用DefaultFieldMapping
+	fieldMappings = make(map[string]FieldMapping)
+)
+
+// 为接口地址注册字段名映射
+func RegisterFieldMapping(url string, m FieldMapping) {
+	fieldMappingsMu.Lock()
+	defer fieldMappingsMu.Unlock()
+	fieldMappings[url] = m
+}
+
+func fieldMappingFor(url string) FieldMapping {
+	fieldMappingsMu.RLock()
+	defer fieldMappingsMu.RUnlock()
+	if m, ok := fieldMappings[url]; ok {
+		return m
+	}
+	return DefaultFieldMapping
+}
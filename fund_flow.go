@@ -0,0 +1,63 @@
+package wxpay
+
+import "time"
+
+// 资金账单收支类型
+const (
+	FundFlowIncome  = "收入"
+	FundFlowExpense = "支出"
+)
+
+// 资金账单中的一条资金流水
+type FundFlowRecord struct {
+	Time          time.Time // 记账时间
+	TransactionID string    // 微信支付业务单号
+	FlowID        string    // 资金流水单号
+	BizName       string    // 业务名称
+	BizType       string    // 业务类型
+	Direction     string    // 收支类型，FundFlowIncome或FundFlowExpense
+	Amount        string    // 收支金额（元）
+	Balance       string    // 账户结余（元）
+	Applicant     string    // 资金变更提交申请人
+	Remark        string    // 备注
+	BizVoucherID  string    // 业务凭证号
+	Fields        Params    // 原始字段，以表头为key
+}
+
+// 解析后的资金账单
+type FundFlow struct {
+	Header  []string
+	Records []FundFlowRecord
+	Summary Params
+}
+
+// 解析DownloadFundFlow返回的data数据
+func ParseFundFlow(data string) (*FundFlow, error) {
+	header, rows, summary, err := parseBillTable(data)
+	if err != nil {
+		return nil, err
+	}
+	flow := &FundFlow{Header: header, Summary: summary}
+	for _, fields := range rows {
+		r := FundFlowRecord{
+			TransactionID: fields.GetString("微信支付业务单号"),
+			FlowID:        fields.GetString("资金流水单号"),
+			BizName:       fields.GetString("业务名称"),
+			BizType:       fields.GetString("业务类型"),
+			Direction:     fields.GetString("收支类型"),
+			Amount:        fields.GetString("收支金额（元）"),
+			Balance:       fields.GetString("账户结余（元）"),
+			Applicant:     fields.GetString("资金变更提交申请人"),
+			Remark:        fields.GetString("备注"),
+			BizVoucherID:  fields.GetString("业务凭证号"),
+			Fields:        fields,
+		}
+		if s := fields.GetString("记账时间"); s != "" {
+			if r.Time, err = time.ParseInLocation(billTimeLayout, s, beijingLocation); err != nil {
+				return nil, err
+			}
+		}
+		flow.Records = append(flow.Records, r)
+	}
+	return flow, nil
+}
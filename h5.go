@@ -0,0 +1,90 @@
+package wxpay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// H5支付的场景类型
+const (
+	H5SceneWap     = "Wap"
+	H5SceneIOS     = "IOS"
+	H5SceneAndroid = "Android"
+)
+
+// H5支付的场景信息，编码为scene_info中的h5_info
+type H5SceneInfo struct {
+	Type        string `json:"type"`                   // Wap、IOS或Android
+	WapUrl      string `json:"wap_url,omitempty"`      // Wap网站地址
+	WapName     string `json:"wap_name,omitempty"`     // Wap网站名称
+	AppName     string `json:"app_name,omitempty"`     // IOS、Android应用名称
+	BundleID    string `json:"bundle_id,omitempty"`    // IOS应用的bundle_id
+	PackageName string `json:"package_name,omitempty"` // Android应用的包名
+}
+
+// 检查场景类型对应的必填字段
+func (s *H5SceneInfo) Validate() error {
+	if s == nil {
+		return errors.New("h5 scene: scene_info is required")
+	}
+	switch s.Type {
+	case H5SceneWap:
+		if s.WapUrl == "" || s.WapName == "" {
+			return errors.New("h5 scene: wap_url and wap_name are required for Wap")
+		}
+	case H5SceneIOS:
+		if s.AppName == "" || s.BundleID == "" {
+			return errors.New("h5 scene: app_name and bundle_id are required for IOS")
+		}
+	case H5SceneAndroid:
+		if s.AppName == "" || s.PackageName == "" {
+			return errors.New("h5 scene: app_name and package_name are required for Android")
+		}
+	default:
+		return errors.New("h5 scene: invalid type " + s.Type)
+	}
+	return nil
+}
+
+// 编码为scene_info字段的JSON
+func (s *H5SceneInfo) Encode() (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(map[string]*H5SceneInfo{"h5_info": s})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// H5支付统一下单，trade_type固定为MWEB，返回用于跳转的mweb_url。
+// spbill_create_ip必须是用户浏览器的IP，不能是服务器的IP
+func (c *Client) UnifiedOrderH5(params Params, scene *H5SceneInfo) (string, Params, error) {
+	return c.UnifiedOrderH5Context(context.Background(), params, scene)
+}
+
+// H5支付统一下单，ctx可用于取消请求
+func (c *Client) UnifiedOrderH5Context(ctx context.Context, params Params, scene *H5SceneInfo) (string, Params, error) {
+	if err := requireParams("unifiedorder_h5", params, "body", "out_trade_no", "total_fee", "spbill_create_ip", "notify_url"); err != nil {
+		return "", nil, err
+	}
+	if params.ContainsKey("openid") {
+		return "", nil, errors.New("unifiedorder_h5: openid is not allowed for MWEB")
+	}
+	sceneInfo, err := scene.Encode()
+	if err != nil {
+		return "", nil, err
+	}
+	params.SetString("trade_type", TradeTypeMWeb).
+		SetString("scene_info", sceneInfo)
+	res, err := c.UnifiedOrderContext(ctx, params)
+	if err != nil {
+		return "", nil, err
+	}
+	if res.GetString("return_code") != Success || res.GetString("result_code") != Success {
+		return "", res, errors.New("unified order failed: " + res.GetString("return_msg") + res.GetString("err_code_des"))
+	}
+	return res.GetString("mweb_url"), res, nil
+}
@@ -0,0 +1,51 @@
+package wxpay
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedOrderH5(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(r.Body)
+			req := MustXmlToMap(string(body))
+			if req.GetString("trade_type") != TradeTypeMWeb || req.GetString("scene_info") != `{"h5_info":{"type":"Wap","wap_url":"https://pay.qq.com","wap_name":"腾讯充值"}}` {
+				t.Error(req)
+			}
+			res := make(Params)
+			res.SetString("return_code", Success).
+				SetString("result_code", Success).
+				SetString("mweb_url", "https://wx.tenpay.com/cgi-bin/mmpayweb-bin/checkmweb?prepay_id=wx2016121516420242444321ca0631331346")
+			res.SetString("sign", client.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+
+	params := make(Params)
+	params.SetString("body", "test").
+		SetString("out_trade_no", "1409811653").
+		SetInt64("total_fee", 1).
+		SetString("spbill_create_ip", "123.12.12.123").
+		SetString("notify_url", "https://notify.TurtleFromBupt.com/notify")
+	mwebUrl, _, err := client.UnifiedOrderH5(params, &H5SceneInfo{Type: H5SceneWap, WapUrl: "https://pay.qq.com", WapName: "腾讯充值"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(mwebUrl, "https://wx.tenpay.com/") {
+		t.Error(mwebUrl)
+	}
+	if _, _, err := client.UnifiedOrderH5(params, &H5SceneInfo{Type: H5SceneIOS}); err == nil {
+		t.Error("expected scene error")
+	}
+	if _, _, err := client.UnifiedOrderH5(params, nil); err == nil {
+		t.Error("expected nil scene error")
+	}
+}
@@ -0,0 +1,31 @@
+package wxpay
+
+// 一次请求最终使用的幂等键
+type IdempotencyKeys struct {
+	Url         string
+	NonceStr    string
+	OutTradeNo  string
+	OutRefundNo string
+	PartnerNo   string // 企业付款、红包等接口的partner_trade_no或mch_billno
+}
+
+// 从已签名的请求参数中读取幂等键
+func IdempotencyKeysOf(url string, params Params) IdempotencyKeys {
+	keys := IdempotencyKeys{
+		Url:         url,
+		NonceStr:    params.GetString("nonce_str"),
+		OutTradeNo:  params.GetString("out_trade_no"),
+		OutRefundNo: params.GetString("out_refund_no"),
+		PartnerNo:   params.GetString("partner_trade_no"),
+	}
+	if keys.PartnerNo == "" {
+		keys.PartnerNo = params.GetString("mch_billno")
+	}
+	return keys
+}
+
+// 设置幂等键回调，在请求签名完成、发送之前调用。
+// 调用方可以在回调中持久化幂等键，以便进程崩溃后使用相同的订单号安全重试；回调返回错误时不发送请求
+func (c *Client) SetIdempotencyHook(hook func(keys IdempotencyKeys) error) {
+	c.idempotencyHook = hook
+}
@@ -0,0 +1,172 @@
+package wxpay
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 凭据类型
+const (
+	CredentialApiKey       = "api_key"        // v2 API密钥，包括仿真测试环境的沙箱密钥
+	CredentialCert         = "cert"           // v2 商户证书
+	CredentialV3PrivateKey = "v3_private_key" // APIv3商户API私钥
+	CredentialApiV3Key     = "api_v3_key"     // APIv3密钥
+)
+
+// 一次凭据使用记录，不包含凭据本身
+type KeyUsage struct {
+	Credential  string // 凭据类型
+	Fingerprint string // 凭据指纹，参见KeyFingerprint
+	MchID       string
+	Api         string // 使用凭据的接口地址，回调通知为notify
+	Time        time.Time
+}
+
+// 凭据使用回调，每次签名、使用证书或解密时调用
+type KeyUsageHook func(u KeyUsage)
+
+// 凭据指纹：SHA256的前8字节，十六进制编码。API密钥和APIv3密钥为密钥本身，
+// 商户证书为证书的DER数据，商户API私钥为PKCS#1编码的公钥
+func KeyFingerprint(material []byte) string {
+	sum := sha256.Sum256(material)
+	return hex.EncodeToString(sum[:8])
+}
+
+func privateKeyFingerprint(key *rsa.PrivateKey) string {
+	return KeyFingerprint(x509.MarshalPKCS1PublicKey(&key.PublicKey))
+}
+
+// 设置凭据使用回调，nil表示不记录
+func (c *Client) SetKeyUsageHook(hook KeyUsageHook) {
+	c.keyUsageHook = hook
+}
+
+func (c *Client) observeKeyUsage(credential string, material []byte, api string) {
+	if c.keyUsageHook == nil {
+		return
+	}
+	c.keyUsageHook(KeyUsage{
+		Credential:  credential,
+		Fingerprint: KeyFingerprint(material),
+		MchID:       c.account.activeMchID(),
+		Api:         api,
+		Time:        c.getClock().Now(),
+	})
+}
+
+// 设置凭据使用回调，nil表示不记录
+func (c *ClientV3) SetKeyUsageHook(hook KeyUsageHook) {
+	c.keyUsageHook = hook
+}
+
+func (c *ClientV3) observeKeyUsage(credential string, fingerprint string, api string) {
+	if c.keyUsageHook == nil {
+		return
+	}
+	c.keyUsageHook(KeyUsage{
+		Credential:  credential,
+		Fingerprint: fingerprint,
+		MchID:       c.mchID,
+		Api:         api,
+		Time:        clockOrSystem(c.clock).Now(),
+	})
+}
+
+// 一个凭据的使用汇总
+type KeyUsageEntry struct {
+	Credential  string
+	Fingerprint string
+	MchID       string
+	FirstUsed   time.Time
+	LastUsed    time.Time
+	LastApi     string
+	Count       int64
+	Apis        map[string]time.Time // 各接口最近一次使用的时间
+}
+
+// 在内存中汇总凭据使用情况，Observe可直接作为KeyUsageHook。
+// 可用于发现长期未使用的凭据，以及确认轮换后的旧凭据已不再被使用
+type KeyUsageTracker struct {
+	mu      sync.Mutex
+	entries map[string]*KeyUsageEntry
+}
+
+// 创建凭据使用统计
+func NewKeyUsageTracker() *KeyUsageTracker {
+	return &KeyUsageTracker{entries: make(map[string]*KeyUsageEntry)}
+}
+
+// 记录一次凭据使用
+func (t *KeyUsageTracker) Observe(u KeyUsage) {
+	key := u.Credential + "/" + u.Fingerprint
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entries[key]
+	if !ok {
+		e = &KeyUsageEntry{
+			Credential:  u.Credential,
+			Fingerprint: u.Fingerprint,
+			MchID:       u.MchID,
+			FirstUsed:   u.Time,
+			Apis:        make(map[string]time.Time),
+		}
+		t.entries[key] = e
+	}
+	e.Count++
+	if !u.Time.Before(e.LastUsed) {
+		e.LastUsed, e.LastApi = u.Time, u.Api
+	}
+	if u.Time.After(e.Apis[u.Api]) {
+		e.Apis[u.Api] = u.Time
+	}
+}
+
+// 所有凭据的使用汇总，按凭据类型和指纹排序
+func (t *KeyUsageTracker) Report() []KeyUsageEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	report := make([]KeyUsageEntry, 0, len(t.entries))
+	for _, e := range t.entries {
+		entry := *e
+		entry.Apis = make(map[string]time.Time, len(e.Apis))
+		for api, used := range e.Apis {
+			entry.Apis[api] = used
+		}
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Credential != report[j].Credential {
+			return report[i].Credential < report[j].Credential
+		}
+		return report[i].Fingerprint < report[j].Fingerprint
+	})
+	return report
+}
+
+// 指纹为fingerprint的凭据最近一次使用的时间，ok为false表示未使用过
+func (t *KeyUsageTracker) LastUsed(fingerprint string) (last time.Time, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, e := range t.entries {
+		if e.Fingerprint == fingerprint && e.LastUsed.After(last) {
+			last, ok = e.LastUsed, true
+		}
+	}
+	return last, ok
+}
+
+// since之后没有再使用过的凭据
+func (t *KeyUsageTracker) UnusedSince(since time.Time) []KeyUsageEntry {
+	var stale []KeyUsageEntry
+	for _, e := range t.Report() {
+		if e.LastUsed.Before(since) {
+			stale = append(stale, e)
+		}
+	}
+	return stale
+}
@@ -0,0 +1,66 @@
+package wxpay
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyUsageTracker(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	tracker := NewKeyUsageTracker()
+	newClient := func(apiKey string) *Client {
+		client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", apiKey, false))
+		client.SetClock(clock)
+		client.SetKeyUsageHook(tracker.Observe)
+		client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				res := make(Params)
+				res.SetString("return_code", Success).SetString("result_code", Success)
+				res.SetString("sign", client.Sign(res))
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+					Header:     make(http.Header),
+				}, nil
+			})
+		})
+		return client
+	}
+
+	oldClient := newClient("old-key")
+	if _, err := oldClient.OrderQuery(Params{"out_trade_no": "1409811653"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oldClient.CloseOrder(Params{"out_trade_no": "1409811653"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮换密钥后只使用新密钥
+	clock.now = clock.now.Add(24 * time.Hour)
+	rotatedAt := clock.now
+	if _, err := newClient("new-key").OrderQuery(Params{"out_trade_no": "1409811654"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := tracker.Report()
+	if len(report) != 2 {
+		t.Fatal(report)
+	}
+	oldFingerprint := KeyFingerprint([]byte("old-key"))
+	stale := tracker.UnusedSince(rotatedAt)
+	if len(stale) != 1 || stale[0].Fingerprint != oldFingerprint || stale[0].Count != 2 || stale[0].LastApi != CloseOrderUrl {
+		t.Error(stale)
+	}
+	if _, ok := stale[0].Apis[OrderQueryUrl]; !ok || stale[0].Credential != CredentialApiKey || stale[0].MchID != "10000100" {
+		t.Error(stale[0])
+	}
+	if last, ok := tracker.LastUsed(KeyFingerprint([]byte("new-key"))); !ok || !last.Equal(rotatedAt) {
+		t.Error(last, ok)
+	}
+	if _, ok := tracker.LastUsed(KeyFingerprint([]byte("unused-key"))); ok {
+		t.Error("unused key reported as used")
+	}
+}
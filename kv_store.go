@@ -0,0 +1,132 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// KVStore中不存在key
+var ErrKeyNotFound = errors.New("key not found")
+
+// 简单的键值存储，用于在多个实例之间共享仿真测试密钥、平台证书等从微信获取的数据，
+// 可以基于文件、Redis等实现
+type KVStore interface {
+	Get(ctx context.Context, key string) ([]byte, error) // key不存在时返回ErrKeyNotFound
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// 支持原子写入的KVStore，多个实例同时写入同一个key时只有一个成功
+type AtomicKVStore interface {
+	KVStore
+	// key不存在时写入value，已存在时不修改。返回写入后key对应的值
+	SetIfAbsent(ctx context.Context, key string, value []byte) ([]byte, error)
+}
+
+// 以文件保存数据的KVStore，每个key对应Dir下的一个文件
+type FileStore struct {
+	Dir string
+}
+
+// 创建FileStore，dir不存在时自动创建
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.Dir, url.PathEscape(key))
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotFound
+	}
+	return data, err
+}
+
+// 先写入临时文件再重命名，避免其他实例读到写了一半的数据
+func (s *FileStore) Set(ctx context.Context, key string, value []byte) error {
+	f, err := ioutil.TempFile(s.Dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
+
+// 写入临时文件后硬链接到目标文件，目标文件已存在时链接失败，返回已有的数据
+func (s *FileStore) SetIfAbsent(ctx context.Context, key string, value []byte) ([]byte, error) {
+	f, err := ioutil.TempFile(s.Dir, ".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Link(f.Name(), s.path(key)); err != nil {
+		if os.IsExist(err) {
+			return s.Get(ctx, key)
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+// 内存中的KVStore，只在单个进程内共享，主要用于测试
+type MemoryStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+// 创建MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string][]byte)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.data[key]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (s *MemoryStore) SetIfAbsent(ctx context.Context, key string, value []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.data[key]; ok {
+		return append([]byte(nil), old...), nil
+	}
+	s.data[key] = append([]byte(nil), value...)
+	return value, nil
+}
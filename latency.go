@@ -0,0 +1,130 @@
+package wxpay
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 慢调用回调，record为脱敏后的调用记录
+type SlowCallHook func(record AuditRecord)
+
+// 按接口地址统计最近的请求耗时
+type LatencyTracker struct {
+	window int
+
+	mu      sync.Mutex
+	samples map[string]*latencyWindow
+}
+
+// 耗时统计
+type LatencyStats struct {
+	Count int // 统计的请求数，最多为window
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+type latencyWindow struct {
+	durations []time.Duration
+	next      int
+}
+
+// 创建LatencyTracker，每个接口保留最近window次请求的耗时
+func NewLatencyTracker(window int) *LatencyTracker {
+	if window < 1 {
+		window = 1000
+	}
+	return &LatencyTracker{window: window, samples: make(map[string]*latencyWindow)}
+}
+
+// 记录一次请求的耗时
+func (t *LatencyTracker) Observe(url string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w, ok := t.samples[url]
+	if !ok {
+		w = &latencyWindow{}
+		t.samples[url] = w
+	}
+	if len(w.durations) < t.window {
+		w.durations = append(w.durations, d)
+		return
+	}
+	w.durations[w.next] = d
+	w.next = (w.next + 1) % t.window
+}
+
+// 返回接口最近请求耗时的百分位数，p取值为0到100
+func (t *LatencyTracker) Percentile(url string, p float64) time.Duration {
+	t.mu.Lock()
+	w, ok := t.samples[url]
+	var sorted []time.Duration
+	if ok {
+		sorted = append(sorted, w.durations...)
+	}
+	t.mu.Unlock()
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return percentile(sorted, p)
+}
+
+// 返回所有接口的耗时统计
+func (t *LatencyTracker) Stats() map[string]LatencyStats {
+	t.mu.Lock()
+	all := make(map[string][]time.Duration, len(t.samples))
+	for url, w := range t.samples {
+		all[url] = append([]time.Duration(nil), w.durations...)
+	}
+	t.mu.Unlock()
+
+	stats := make(map[string]LatencyStats, len(all))
+	for url, sorted := range all {
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats[url] = LatencyStats{
+			Count: len(sorted),
+			P50:   percentile(sorted, 50),
+			P90:   percentile(sorted, 90),
+			P99:   percentile(sorted, 99),
+		}
+	}
+	return stats
+}
+
+// sorted需已按升序排列，使用最近秩法
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p/100*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// 设置请求耗时统计，nil表示不统计
+func (c *Client) SetLatencyTracker(t *LatencyTracker) {
+	c.latency = t
+}
+
+// 设置慢调用回调，请求耗时超过threshold时调用hook，可用于区分微信侧和自身的性能问题
+func (c *Client) SetSlowCallHook(threshold time.Duration, hook SlowCallHook) {
+	c.slowCallThreshold = threshold
+	c.slowCallHook = hook
+}
+
+func (c *Client) observeLatency(url string, request Params, response string, err error, start time.Time) {
+	if c.latency == nil && c.slowCallHook == nil {
+		return
+	}
+	d := c.getClock().Now().Sub(start)
+	if c.latency != nil {
+		c.latency.Observe(url, d)
+	}
+	if c.slowCallHook != nil && d > c.slowCallThreshold {
+		c.slowCallHook(c.auditRecord(url, request, response, err, start))
+	}
+}
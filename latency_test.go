@@ -0,0 +1,21 @@
+package wxpay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	tracker := NewLatencyTracker(100)
+	for i := 1; i <= 200; i++ {
+		tracker.Observe(OrderQueryUrl, time.Duration(i)*time.Millisecond)
+	}
+	// 只保留最近100次
+	stats := tracker.Stats()[OrderQueryUrl]
+	if stats.Count != 100 || stats.P50 != 150*time.Millisecond || stats.P99 != 199*time.Millisecond {
+		t.Error(stats)
+	}
+	if d := tracker.Percentile(RefundUrl, 50); d != 0 {
+		t.Error(d)
+	}
+}
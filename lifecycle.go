@@ -0,0 +1,81 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// 后台组件的统一生命周期：Start在单独的goroutine中运行组件，Stop停止组件并等待goroutine退出
+type Component interface {
+	Start(ctx context.Context) error
+	Stop()
+}
+
+// 将阻塞运行直到ctx取消的函数包装为Component，例如：
+//
+//	NewComponent(monitor.Run)
+//	NewComponent(func(ctx context.Context) { prober.Run(ctx, onError) })
+//	NewComponent(func(ctx context.Context) { certs.Run(ctx, 12*time.Hour, onError) })
+func NewComponent(run func(ctx context.Context)) Component {
+	return &component{run: run}
+}
+
+type component struct {
+	run func(ctx context.Context)
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// 启动组件，ctx取消或调用Stop时停止。组件正在运行时返回错误，Stop之后可以重新启动
+func (c *component) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done != nil {
+		return errors.New("component is already started")
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	done := make(chan struct{})
+	c.done = done
+	go func() {
+		defer close(done)
+		c.run(ctx)
+	}()
+	return nil
+}
+
+// 停止组件并等待run返回，组件未启动时直接返回
+func (c *component) Stop() {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.cancel, c.done = nil, nil
+	c.mu.Unlock()
+	if done == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// 一组后台组件，按顺序启动，按相反的顺序停止
+type ComponentGroup []Component
+
+// 依次启动所有组件，某个组件启动失败时停止已启动的组件并返回错误
+func (g ComponentGroup) Start(ctx context.Context) error {
+	for i, c := range g {
+		if err := c.Start(ctx); err != nil {
+			g[:i].Stop()
+			return err
+		}
+	}
+	return nil
+}
+
+// 按相反的顺序停止所有组件，并等待它们退出
+func (g ComponentGroup) Stop() {
+	for i := len(g) - 1; i >= 0; i-- {
+		g[i].Stop()
+	}
+}
@@ -0,0 +1,83 @@
+package wxpay
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestComponentGroup(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+	newComponent := func(name string) Component {
+		return NewComponent(func(ctx context.Context) {
+			record("start " + name)
+			<-ctx.Done()
+			// 模拟退出前的清理工作，Stop应等待其完成
+			time.Sleep(10 * time.Millisecond)
+			record("stop " + name)
+		})
+	}
+	g := ComponentGroup{newComponent("a"), newComponent("b")}
+	if err := g.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := g[0].Start(context.Background()); err == nil {
+		t.Error("expected already started error")
+	}
+	time.Sleep(10 * time.Millisecond)
+	g.Stop()
+	mu.Lock()
+	got := strings.Join(events, ",")
+	mu.Unlock()
+	if !strings.HasSuffix(got, "stop b,stop a") || len(events) != 4 {
+		t.Error(got)
+	}
+	// 停止后可以重新启动，未启动时Stop直接返回
+	if err := g.Start(context.Background()); err != nil {
+		t.Error(err)
+	}
+	g.Stop()
+	g.Stop()
+}
+
+func TestComponentCouponBudgetMonitor(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	m := &CouponBudgetMonitor{
+		Source: func(ctx context.Context, stockID string) (*CouponStock, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			return &CouponStock{StockID: stockID, RemainingAmount: -1, RemainingQuantity: -1}, nil
+		},
+		StockIDs: []string{"1234567"},
+		Interval: time.Millisecond,
+	}
+	c := NewComponent(m.Run)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	c.Stop()
+	mu.Lock()
+	stopped := calls
+	mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if stopped == 0 || calls != stopped {
+		t.Error(stopped, calls)
+	}
+}
@@ -0,0 +1,76 @@
+package wxpay
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 请求检查发现的问题
+type LintWarning struct {
+	Field   string // 字段名
+	Message string
+	ErrCode string // 发送请求后微信可能返回的错误码
+}
+
+func (w LintWarning) String() string {
+	return w.Field + ": " + w.Message + " (" + w.ErrCode + ")"
+}
+
+// 请求检查回调，url为接口地址，只在有问题时调用
+type LintHook func(url string, warnings []LintWarning)
+
+// 商户订单号只能包含字母、数字和_-|*
+var outTradeNoPattern = regexp.MustCompile(`^[A-Za-z0-9_\-|*]+$`)
+
+const maxBodyLen = 128
+
+// 需要终端IP的接口
+var lintClientIPUrls = map[string]bool{
+	UnifiedOrderUrl:        true,
+	SandboxUnifiedOrderUrl: true,
+	MicroPayUrl:            true,
+	SandboxMicroPayUrl:     true,
+}
+
+// 检查请求中的常见错误，返回发现的问题。只检查请求中出现的字段，不代替微信的校验
+func LintParams(url string, params Params) []LintWarning {
+	var warnings []LintWarning
+	add := func(field, message, errCode string) {
+		warnings = append(warnings, LintWarning{Field: field, Message: message, ErrCode: errCode})
+	}
+	if params.ContainsKey("total_fee") && params.GetInt64("total_fee") <= 0 {
+		add("total_fee", "total_fee must be positive", "PARAM_ERROR")
+	}
+	if body := params.GetString("body"); len(body) > maxBodyLen {
+		add("body", "body exceeds 128 bytes", "PARAM_ERROR")
+	}
+	if notifyUrl := params.GetString("notify_url"); notifyUrl != "" {
+		if !strings.HasPrefix(notifyUrl, "https://") {
+			add("notify_url", "notify_url is not HTTPS", "PARAM_ERROR")
+		}
+		if strings.Contains(notifyUrl, "?") {
+			add("notify_url", "notify_url must not carry query parameters", "PARAM_ERROR")
+		}
+	}
+	if outTradeNo := params.GetString("out_trade_no"); outTradeNo != "" && !outTradeNoPattern.MatchString(outTradeNo) {
+		add("out_trade_no", "out_trade_no contains characters other than letters, digits and _-|*", "INVALID_REQUEST")
+	}
+	if lintClientIPUrls[url] && params.GetString("spbill_create_ip") == "" {
+		add("spbill_create_ip", "spbill_create_ip is missing", "PARAM_ERROR")
+	}
+	return warnings
+}
+
+// 设置请求检查回调，设置后每次发送请求前检查常见错误，建议只在开发环境开启。nil表示关闭
+func (c *Client) SetLintHook(hook LintHook) {
+	c.lintHook = hook
+}
+
+func (c *Client) lint(url string, params Params) {
+	if c.lintHook == nil {
+		return
+	}
+	if warnings := LintParams(url, params); len(warnings) > 0 {
+		c.lintHook(url, warnings)
+	}
+}
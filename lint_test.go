@@ -0,0 +1,29 @@
+package wxpay
+
+import "testing"
+
+func TestLintParams(t *testing.T) {
+	params := make(Params)
+	params.SetString("body", "test").
+		SetString("out_trade_no", "58867657575757").
+		SetInt64("total_fee", 1).
+		SetString("spbill_create_ip", "127.0.0.1").
+		SetString("notify_url", "https://notify.TurtleFromBupt.com/notify")
+	if w := LintParams(UnifiedOrderUrl, params); len(w) != 0 {
+		t.Error(w)
+	}
+
+	params.SetInt64("total_fee", 0).
+		SetString("out_trade_no", "5886 7657").
+		SetString("notify_url", "http://notify.TurtleFromBupt.com/notify")
+	delete(params, "spbill_create_ip")
+	fields := make(map[string]bool)
+	for _, w := range LintParams(UnifiedOrderUrl, params) {
+		fields[w.Field] = true
+	}
+	for _, f := range []string{"total_fee", "out_trade_no", "notify_url", "spbill_create_ip"} {
+		if !fields[f] {
+			t.Error("missing warning for", f)
+		}
+	}
+}
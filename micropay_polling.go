@@ -0,0 +1,134 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 刷卡支付未能在轮询时间内确认支付成功，订单已撤销
+var ErrMicroPayReversed = errors.New("micropay was not confirmed and has been reversed")
+
+// 官方建议的刷卡支付轮询间隔和轮询时间
+const (
+	defaultMicroPayPollInterval = 5 * time.Second
+	defaultMicroPayPollTimeout  = 30 * time.Second
+)
+
+// 返回recall=Y时需要重新撤销，最多撤销的次数
+const maxReverseAttempts = 10
+
+// 设置MicroPayWithPolling查询订单的间隔和总时间，0表示使用默认的5秒和30秒
+func (c *Client) SetMicroPayPolling(interval time.Duration, timeout time.Duration) {
+	c.microPayPollInterval = interval
+	c.microPayPollTimeout = timeout
+}
+
+// 刷卡支付的轮询进度，可用于在收银界面提示“等待用户确认”
+type MicroPayProgress struct {
+	OutTradeNo string
+	Attempt    int           // 第几次查询订单，从1开始
+	Elapsed    time.Duration // 从发起刷卡支付到本次查询完成的时间
+	TradeState string        // 最近一次查询到的交易状态，查询一直失败时为空
+}
+
+// 设置MicroPayWithPolling的进度回调，每次查询订单后调用，nil表示不回调
+func (c *Client) SetMicroPayProgress(progress func(MicroPayProgress)) {
+	c.microPayProgress = progress
+}
+
+// 按官方流程进行刷卡支付：返回USERPAYING、SYSTEMERROR、BANKERROR或请求失败时轮询查询订单，
+// 查询到支付成功时返回查询结果；订单明确未支付或轮询超时后撤销订单，撤销成功时返回撤销结果和ErrMicroPayReversed。
+// ctx只作用于支付和查询，ctx取消后仍会撤销订单，避免用户已扣款而收银端认为支付失败
+func (c *Client) MicroPayWithPolling(ctx context.Context, params Params) (Params, error) {
+	start := c.getClock().Now()
+	p, err := c.MicroPayContext(ctx, params)
+	if !microPayUnknown(p, err) {
+		return p, err
+	}
+
+	query := make(Params)
+	for _, k := range []string{"out_trade_no", "sub_mch_id", "sub_appid"} {
+		if params.ContainsKey(k) {
+			query.SetString(k, params.GetString(k))
+		}
+	}
+	if q, ok := c.pollMicroPay(ctx, query, start); ok {
+		return q, nil
+	}
+	return c.reverseMicroPay(query)
+}
+
+// 刷卡支付结果是否未知，需要查询订单确认
+func microPayUnknown(p Params, err error) bool {
+	if p == nil {
+		return err != nil
+	}
+	switch p.GetString("err_code") {
+	case "USERPAYING", "SYSTEMERROR", "BANKERROR":
+		return true
+	}
+	return false
+}
+
+// 轮询查询订单，支付成功时ok为true；订单明确未支付、轮询超时或ctx取消时ok为false。start为发起支付的时间
+func (c *Client) pollMicroPay(ctx context.Context, query Params, start time.Time) (Params, bool) {
+	interval, timeout := c.microPayPollInterval, c.microPayPollTimeout
+	if interval <= 0 {
+		interval = defaultMicroPayPollInterval
+	}
+	if timeout <= 0 {
+		timeout = defaultMicroPayPollTimeout
+	}
+	clock := c.getClock()
+	deadline := clock.Now().Add(timeout)
+	progress := MicroPayProgress{OutTradeNo: query.GetString("out_trade_no")}
+	for clock.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-clock.After(interval):
+		}
+		// 不使用查询缓存，缓存中的USERPAYING可能导致撤销已支付的订单
+		q, _ := c.InvokeContext(ctx, "OrderQuery", copyParams(query))
+		progress.Attempt++
+		progress.Elapsed = clock.Now().Sub(start)
+		if state := q.GetString("trade_state"); state != "" {
+			progress.TradeState = state
+		}
+		if c.microPayProgress != nil {
+			c.microPayProgress(progress)
+		}
+		switch q.GetString("trade_state") {
+		case "SUCCESS":
+			return q, true
+		case "", "USERPAYING":
+			// 查询失败或用户仍在输入密码，继续查询
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// 撤销订单，返回recall=Y时重新撤销
+func (c *Client) reverseMicroPay(query Params) (Params, error) {
+	var (
+		r   Params
+		err error
+	)
+	for i := 0; i < maxReverseAttempts; i++ {
+		r, err = c.ReverseContext(context.Background(), copyParams(query))
+		if r.GetString("result_code") == Success {
+			return r, ErrMicroPayReversed
+		}
+		if r.GetString("recall") != "Y" {
+			break
+		}
+	}
+	if err != nil {
+		return r, fmt.Errorf("reverse order %s: %w", query.GetString("out_trade_no"), err)
+	}
+	return r, fmt.Errorf("reverse order %s failed: %s%s", query.GetString("out_trade_no"), r.GetString("return_msg"), r.GetString("err_code_des"))
+}
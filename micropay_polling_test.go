@@ -0,0 +1,89 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/tls"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newMicroPayParams(outTradeNo string) Params {
+	params := make(Params)
+	params.SetString("body", "test").
+		SetString("out_trade_no", outTradeNo).
+		SetInt64("total_fee", 1).
+		SetString("spbill_create_ip", "127.0.0.1").
+		SetString("auth_code", FakeAuthCode())
+	return params
+}
+
+func TestMicroPayWithPolling(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.account.cert = &tls.Certificate{}
+	start := time.Unix(1600000000, 0)
+	clock := &fakeClock{now: start}
+	client.SetClock(clock)
+	mock := &MockMicroPay{ApiKey: "xxxxx", UserPayingPolls: 2}
+	client.SetTransportWrapper(mock.Wrap)
+	var progress []MicroPayProgress
+	client.SetMicroPayProgress(func(p MicroPayProgress) { progress = append(progress, p) })
+
+	p, err := client.MicroPayWithPolling(context.Background(), newMicroPayParams("1409811653"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GetString("trade_state") != "SUCCESS" || clock.now.Sub(start) != 15*time.Second {
+		t.Error(p, clock.now.Sub(start))
+	}
+	want := []MicroPayProgress{
+		{OutTradeNo: "1409811653", Attempt: 1, Elapsed: 5 * time.Second, TradeState: "USERPAYING"},
+		{OutTradeNo: "1409811653", Attempt: 2, Elapsed: 10 * time.Second, TradeState: "USERPAYING"},
+		{OutTradeNo: "1409811653", Attempt: 3, Elapsed: 15 * time.Second, TradeState: "SUCCESS"},
+	}
+	if !reflect.DeepEqual(progress, want) {
+		t.Error(progress)
+	}
+
+	// 用户一直未输入密码，轮询超时后撤销
+	mock.UserPayingPolls = 100
+	start = clock.now
+	p, err = client.MicroPayWithPolling(context.Background(), newMicroPayParams("1409811654"))
+	if err != ErrMicroPayReversed || p.GetString("result_code") != Success {
+		t.Fatal(p, err)
+	}
+	if clock.now.Sub(start) != 30*time.Second {
+		t.Error(clock.now.Sub(start))
+	}
+	if last := progress[len(progress)-1]; last.Attempt != 6 || last.Elapsed != 30*time.Second || last.TradeState != "USERPAYING" {
+		t.Error(last)
+	}
+	if q, _ := client.OrderQuery(Params{"out_trade_no": "1409811654"}); q.GetString("trade_state") != "REVOKED" {
+		t.Error(q)
+	}
+}
+
+func TestMicroPayWithPollingDefiniteFailure(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.SetTransportWrapper((&MockMicroPay{ApiKey: "xxxxx"}).Wrap)
+
+	params := newMicroPayParams("1409811653")
+	params.SetString("auth_code", "180000000000000000")
+	p, err := client.MicroPayWithPolling(context.Background(), params)
+	if err != nil || p.GetString("err_code") != "AUTH_CODE_INVALID" {
+		t.Error(p, err)
+	}
+}
+
+func TestMicroPayWithPollingSkipsQueryCache(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.account.cert = &tls.Certificate{}
+	client.SetClock(&fakeClock{now: time.Unix(1600000000, 0)})
+	client.SetTransportWrapper((&MockMicroPay{ApiKey: "xxxxx", UserPayingPolls: 2}).Wrap)
+	client.SetQueryCache(NewMemoryCache(), time.Hour, true)
+
+	p, err := client.MicroPayWithPolling(context.Background(), newMicroPayParams("1409811653"))
+	if err != nil || p.GetString("trade_state") != "SUCCESS" {
+		t.Error(p, err)
+	}
+}
@@ -0,0 +1,291 @@
+package wxpay
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 生成格式正确的付款码，18位数字，以10~15开头。只用于测试
+func FakeAuthCode() string {
+	n, err := rand.Int(rand.Reader, big.NewInt(6e16))
+	if err != nil {
+		panic(err)
+	}
+	// 前两位为10~15，后16位随机
+	prefix := 10 + n.Int64()/1e16
+	return strconv.FormatInt(prefix, 10) + leftPad(strconv.FormatInt(n.Int64()%1e16, 10), 16)
+}
+
+func leftPad(s string, n int) string {
+	if len(s) >= n {
+		return s
+	}
+	return strings.Repeat("0", n-len(s)) + s
+}
+
+// 付款码格式是否正确
+func IsAuthCode(code string) bool {
+	if len(code) != 18 || code[:2] < "10" || code[:2] > "15" {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// 模拟刷卡支付流程的Transport，用于在没有真实设备的情况下测试收银软件的轮询和撤销逻辑。
+// 支持刷卡支付、查询订单和撤销订单（撤销订单仍需要客户端设置证书），通过Wrap作为Client.SetTransportWrapper的参数。
+// 刷卡支付先返回USERPAYING，之后每次查询订单消耗一次UserPayingPolls，用完后订单变为SUCCESS
+type MockMicroPay struct {
+	ApiKey          string // 返回签名使用的密钥
+	UserPayingPolls int    // 用户输入密码前返回USERPAYING的查询次数，0表示刷卡支付直接成功
+
+	mu     sync.Mutex
+	orders map[string]*mockOrder
+	seq    int64
+}
+
+type mockOrder struct {
+	state         string
+	polls         int
+	transactionID string
+	totalFee      string
+}
+
+// 包装Transport，可作为Client.SetTransportWrapper的参数
+func (m *MockMicroPay) Wrap(http.RoundTripper) http.RoundTripper {
+	return m
+}
+
+func (m *MockMicroPay) RoundTrip(req *http.Request) (*http.Response, error) {
+	params, err := readMockRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := m.handle(req.URL.Path, params)
+	if !ok {
+		res = Params{"return_code": Fail, "return_msg": "mock: unsupported api " + req.URL.Path}
+	}
+	return mockResponse(params, res, m.ApiKey), nil
+}
+
+// 处理刷卡支付、查询订单和撤销订单，ok为false表示不支持该接口
+func (m *MockMicroPay) handle(path string, params Params) (res Params, ok bool) {
+	switch {
+	case strings.HasSuffix(path, "/pay/micropay"):
+		return m.microPay(params), true
+	case strings.HasSuffix(path, "/pay/orderquery"):
+		return m.orderQuery(params), true
+	case strings.HasSuffix(path, "/pay/reverse"):
+		return m.reverse(params), true
+	}
+	return nil, false
+}
+
+func readMockRequest(req *http.Request) (Params, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return XmlToMap(string(body))
+}
+
+// 生成返回数据，return_code为SUCCESS时按请求的sign_type签名
+func mockResponse(params Params, res Params, apiKey string) *http.Response {
+	if res.GetString("return_code") == "" {
+		res.SetString("return_code", Success)
+	}
+	if res.GetString("return_code") == Success {
+		signType := params.GetString("sign_type")
+		if signType == "" {
+			signType = MD5
+		}
+		res.SetString("nonce_str", nonceStr())
+		res.SetString(Sign, signParams(res, signType, apiKey))
+	}
+	return mockRawResponse(MustMapToXml(res))
+}
+
+func mockRawResponse(data string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(data)),
+	}
+}
+
+func mockFail(errCode string, errCodeDes string) Params {
+	return Params{"result_code": Fail, "err_code": errCode, "err_code_des": errCodeDes}
+}
+
+func (m *MockMicroPay) microPay(params Params) Params {
+	if !IsAuthCode(params.GetString("auth_code")) {
+		return mockFail("AUTH_CODE_INVALID", "付款码无效")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.orders == nil {
+		m.orders = make(map[string]*mockOrder)
+	}
+	outTradeNo := params.GetString("out_trade_no")
+	if _, ok := m.orders[outTradeNo]; ok {
+		return mockFail("OUT_TRADE_NO_USED", "商户订单号重复")
+	}
+	m.seq++
+	o := &mockOrder{
+		state:         "USERPAYING",
+		polls:         m.UserPayingPolls,
+		transactionID: "42000000" + leftPad(strconv.FormatInt(m.seq, 10), 20),
+		totalFee:      params.GetString("total_fee"),
+	}
+	m.orders[outTradeNo] = o
+	if o.polls <= 0 {
+		o.state = "SUCCESS"
+		return m.orderResult(outTradeNo, o)
+	}
+	return mockFail("USERPAYING", "需要用户输入支付密码")
+}
+
+func (m *MockMicroPay) orderQuery(params Params) Params {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outTradeNo := params.GetString("out_trade_no")
+	o, ok := m.orders[outTradeNo]
+	if !ok {
+		return mockFail("ORDERNOTEXIST", "此交易订单号不存在")
+	}
+	if o.state == "USERPAYING" {
+		o.polls--
+		if o.polls < 0 {
+			o.state = "SUCCESS"
+		}
+	}
+	res := m.orderResult(outTradeNo, o)
+	res.SetString("trade_state", o.state)
+	return res
+}
+
+func (m *MockMicroPay) reverse(params Params) Params {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[params.GetString("out_trade_no")]
+	if !ok {
+		return mockFail("ORDERNOTEXIST", "此交易订单号不存在")
+	}
+	o.state = "REVOKED"
+	return Params{"result_code": Success, "recall": "N"}
+}
+
+func (m *MockMicroPay) orderResult(outTradeNo string, o *mockOrder) Params {
+	return Params{
+		"result_code":    Success,
+		"trade_type":     TradeTypeMicroPay,
+		"out_trade_no":   outTradeNo,
+		"transaction_id": o.transactionID,
+		"total_fee":      o.totalFee,
+	}
+}
+
+// 模拟微信支付接口的Transport，按请求路径分发到Handle、HandleRaw注册的处理函数，
+// 返回数据自动填充return_code并使用ApiKey签名。未注册的刷卡支付、查询订单、撤销订单交给MicroPay处理。
+// 通过Wrap作为Client.SetTransportWrapper的参数，只用于测试
+type MockServer struct {
+	ApiKey   string        // 返回签名使用的密钥
+	MicroPay *MockMicroPay // 可以为nil
+
+	mu   sync.Mutex
+	apis map[string]func(req Params) Params
+	raw  map[string]func(req Params) string
+}
+
+// 注册返回XML的接口，path为请求路径，例如/pay/unifiedorder
+func (s *MockServer) Handle(path string, fn func(req Params) Params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.apis == nil {
+		s.apis = make(map[string]func(req Params) Params)
+	}
+	s.apis[path] = fn
+}
+
+// 注册返回原始数据的接口，例如下载对账单
+func (s *MockServer) HandleRaw(path string, fn func(req Params) string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.raw == nil {
+		s.raw = make(map[string]func(req Params) string)
+	}
+	s.raw[path] = fn
+}
+
+// 包装Transport，可作为Client.SetTransportWrapper的参数
+func (s *MockServer) Wrap(http.RoundTripper) http.RoundTripper {
+	return s
+}
+
+func (s *MockServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	params, err := readMockRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	path := req.URL.Path
+	s.mu.Lock()
+	raw, isRaw := s.raw[path]
+	api, isApi := s.apis[path]
+	s.mu.Unlock()
+	if isRaw {
+		return mockRawResponse(raw(params)), nil
+	}
+	if isApi {
+		return mockResponse(params, api(params), s.ApiKey), nil
+	}
+	if s.MicroPay != nil {
+		if res, ok := s.MicroPay.handle(path, params); ok {
+			return mockResponse(params, res, s.ApiKey), nil
+		}
+	}
+	return mockResponse(params, Params{"return_code": Fail, "return_msg": "mock: unsupported api " + path}, s.ApiKey), nil
+}
+
+// 生成使用ApiKey签名的支付结果通知，params中没有sign_type时使用MD5签名
+func (s *MockServer) Notify(params Params) string {
+	n := copyParams(params)
+	n.SetString("return_code", Success).SetString("nonce_str", nonceStr())
+	signType := n.GetString("sign_type")
+	if signType == "" {
+		signType = MD5
+	}
+	n.SetString(Sign, signParams(n, signType, s.ApiKey))
+	return MustMapToXml(n)
+}
+
+// 生成退款结果通知，info按微信的方式使用ApiKey加密到req_info中
+func (s *MockServer) RefundNotify(info Params) string {
+	plain := []byte(MustMapToXml(info))
+	sum := md5.Sum([]byte(s.ApiKey))
+	block, err := aes.NewCipher([]byte(hex.EncodeToString(sum[:])))
+	if err != nil {
+		panic(err)
+	}
+	size := block.BlockSize()
+	pad := size - len(plain)%size
+	plain = append(plain, bytes.Repeat([]byte{byte(pad)}, pad)...)
+	data := make([]byte, len(plain))
+	for i := 0; i < len(plain); i += size {
+		block.Encrypt(data[i:i+size], plain[i:i+size])
+	}
+	return MustMapToXml(Params{"return_code": Success, "req_info": base64.StdEncoding.EncodeToString(data)})
+}
@@ -0,0 +1,74 @@
+package wxpay
+
+import "testing"
+
+func TestFakeAuthCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if code := FakeAuthCode(); !IsAuthCode(code) {
+			t.Fatal(code)
+		}
+	}
+	if IsAuthCode("180000000000000000") || IsAuthCode("13000000000000000") {
+		t.Error("invalid auth code accepted")
+	}
+}
+
+func TestMockMicroPay(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	mock := &MockMicroPay{ApiKey: "xxxxx", UserPayingPolls: 1}
+	client.SetTransportWrapper(mock.Wrap)
+
+	params := make(Params)
+	params.SetString("body", "test").
+		SetString("out_trade_no", "1409811653").
+		SetInt64("total_fee", 1).
+		SetString("spbill_create_ip", "127.0.0.1").
+		SetString("auth_code", FakeAuthCode())
+	p, err := client.MicroPay(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GetString("err_code") != "USERPAYING" {
+		t.Fatal(p)
+	}
+	for _, want := range []string{"USERPAYING", "SUCCESS"} {
+		p, err = client.OrderQuery(Params{"out_trade_no": "1409811653"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.GetString("trade_state") != want {
+			t.Error(want, p)
+		}
+	}
+}
+
+func TestMockServer(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	mock := &MockServer{ApiKey: "xxxxx", MicroPay: &MockMicroPay{ApiKey: "xxxxx"}}
+	mock.Handle("/pay/unifiedorder", func(req Params) Params {
+		return Params{"result_code": Success, "prepay_id": "wx201410272009395522657a690389285100"}
+	})
+	client.SetTransportWrapper(mock.Wrap)
+
+	p, err := client.UnifiedOrder(Params{"out_trade_no": "1409811653"})
+	if err != nil || p.GetString("prepay_id") != "wx201410272009395522657a690389285100" {
+		t.Error(p, err)
+	}
+	// 未注册的刷卡支付交给MicroPay处理
+	params := make(Params)
+	params.SetString("out_trade_no", "1409811654").SetInt64("total_fee", 1).SetString("auth_code", FakeAuthCode())
+	if p, err := client.MicroPay(params); err != nil || p.GetString("result_code") != Success {
+		t.Error(p, err)
+	}
+	if p, err := client.CloseOrder(Params{"out_trade_no": "1409811653"}); err != nil || p.GetString("return_code") != Fail {
+		t.Error(p, err)
+	}
+
+	if n, err := client.processResponseXml(mock.Notify(Params{"out_trade_no": "1409811653"})); err != nil || n.GetString("out_trade_no") != "1409811653" {
+		t.Error(n, err)
+	}
+	info, err := client.DecryptRefundNotify(mock.RefundNotify(Params{"out_refund_no": "R1409811653", "refund_status": "SUCCESS"}))
+	if err != nil || info.GetString("refund_status") != "SUCCESS" {
+		t.Error(info, err)
+	}
+}
@@ -0,0 +1,187 @@
+package wxpay
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const notifyTimeLayout = "20060102150405"
+
+// 支付结果通知中使用的代金券
+type CouponDetail struct {
+	CouponID   string // 代金券ID
+	CouponType string // 代金券类型
+	CouponFee  Amount // 单个代金券支付金额
+}
+
+// 支付结果通知
+type Notification struct {
+	ReturnCode    string
+	ReturnMsg     string
+	ResultCode    string
+	ErrCode       string
+	ErrCodeDes    string
+	AppID         string
+	MchID         string
+	SubAppID      string // 服务商模式下的子商户appid
+	SubMchID      string // 服务商模式下的子商户号
+	DeviceInfo    string
+	OpenID        string
+	IsSubscribe   string
+	TradeType     string
+	BankType      string
+	TotalFee      Amount
+	CashFee       Amount
+	FeeType       string
+	CouponFee     Amount
+	CouponDetails []CouponDetail
+	TransactionID string
+	OutTradeNo    string
+	Attach        string
+	TimeEnd       time.Time
+	Params        Params // 原始通知参数，包含所有未解析的字段
+}
+
+// 将通知参数转换为Notification
+func NewNotification(params Params) (*Notification, error) {
+	n := &Notification{
+		ReturnCode:    params.GetString("return_code"),
+		ReturnMsg:     params.GetString("return_msg"),
+		ResultCode:    params.GetString("result_code"),
+		ErrCode:       params.GetString("err_code"),
+		ErrCodeDes:    params.GetString("err_code_des"),
+		AppID:         params.GetString("appid"),
+		MchID:         params.GetString("mch_id"),
+		SubAppID:      params.GetString("sub_appid"),
+		SubMchID:      params.GetString("sub_mch_id"),
+		DeviceInfo:    params.GetString("device_info"),
+		OpenID:        params.GetString("openid"),
+		IsSubscribe:   params.GetString("is_subscribe"),
+		TradeType:     params.GetString("trade_type"),
+		BankType:      params.GetString("bank_type"),
+		FeeType:       params.GetString("fee_type"),
+		TransactionID: params.GetString("transaction_id"),
+		OutTradeNo:    params.GetString("out_trade_no"),
+		Attach:        params.GetString("attach"),
+		Params:        params,
+	}
+
+	var err error
+	amounts := []struct {
+		key      string
+		currency string
+		amount   *Amount
+	}{
+		{"total_fee", n.FeeType, &n.TotalFee},
+		{"cash_fee", params.GetString("cash_fee_type"), &n.CashFee},
+		{"coupon_fee", n.FeeType, &n.CouponFee},
+	}
+	for _, a := range amounts {
+		if !params.ContainsKey(a.key) {
+			continue
+		}
+		if *a.amount, err = ParseAmount(params.GetString(a.key), a.currency); err != nil {
+			return nil, err
+		}
+	}
+
+	if params.ContainsKey("time_end") {
+		n.TimeEnd, err = time.ParseInLocation(notifyTimeLayout, params.GetString("time_end"), beijingLocation)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	count := int(params.GetInt64("coupon_count"))
+	for i := 0; i < count; i++ {
+		suffix := "_" + strconv.Itoa(i)
+		coupon := CouponDetail{
+			CouponID:   params.GetString("coupon_id" + suffix),
+			CouponType: params.GetString("coupon_type" + suffix),
+		}
+		if coupon.CouponFee, err = ParseAmount(params.GetString("coupon_fee"+suffix), n.FeeType); err != nil {
+			return nil, err
+		}
+		n.CouponDetails = append(n.CouponDetails, coupon)
+	}
+	return n, nil
+}
+
+// 解析支付结果通知的XML，return_code为SUCCESS时验证签名
+func (c *Client) ParseNotification(xmlStr string) (*Notification, error) {
+	params, err := c.processNotifyXml(xmlStr)
+	if err != nil {
+		return nil, err
+	}
+	return NewNotification(params)
+}
+
+// Notification编码为JSON后的格式，字段名与微信的字段名相同，金额为以分为单位的整数，
+// 时间为RFC3339格式。用于将验证过的通知发布到消息队列，字段只增不改
+type notificationJSON struct {
+	ReturnCode    string             `json:"return_code"`
+	ResultCode    string             `json:"result_code"`
+	ErrCode       string             `json:"err_code,omitempty"`
+	ErrCodeDes    string             `json:"err_code_des,omitempty"`
+	AppID         string             `json:"appid"`
+	MchID         string             `json:"mch_id"`
+	DeviceInfo    string             `json:"device_info,omitempty"`
+	OpenID        string             `json:"openid"`
+	IsSubscribe   string             `json:"is_subscribe,omitempty"`
+	TradeType     string             `json:"trade_type"`
+	BankType      string             `json:"bank_type"`
+	FeeType       string             `json:"fee_type"`
+	TotalFee      int64              `json:"total_fee"`
+	CashFee       int64              `json:"cash_fee"`
+	CashFeeType   string             `json:"cash_fee_type,omitempty"`
+	CouponFee     int64              `json:"coupon_fee,omitempty"`
+	Coupons       []couponDetailJSON `json:"coupons,omitempty"`
+	TransactionID string             `json:"transaction_id"`
+	OutTradeNo    string             `json:"out_trade_no"`
+	Attach        string             `json:"attach,omitempty"`
+	TimeEnd       string             `json:"time_end,omitempty"`
+}
+
+type couponDetailJSON struct {
+	CouponID   string `json:"coupon_id"`
+	CouponType string `json:"coupon_type,omitempty"`
+	CouponFee  int64  `json:"coupon_fee"`
+}
+
+// 编码为固定格式的JSON，见notificationJSON。fee_type为空时为CNY，原始参数Params不编码
+func (n *Notification) MarshalJSON() ([]byte, error) {
+	v := notificationJSON{
+		ReturnCode:    n.ReturnCode,
+		ResultCode:    n.ResultCode,
+		ErrCode:       n.ErrCode,
+		ErrCodeDes:    n.ErrCodeDes,
+		AppID:         n.AppID,
+		MchID:         n.MchID,
+		DeviceInfo:    n.DeviceInfo,
+		OpenID:        n.OpenID,
+		IsSubscribe:   n.IsSubscribe,
+		TradeType:     n.TradeType,
+		BankType:      n.BankType,
+		FeeType:       n.FeeType,
+		TotalFee:      n.TotalFee.Value,
+		CashFee:       n.CashFee.Value,
+		CouponFee:     n.CouponFee.Value,
+		TransactionID: n.TransactionID,
+		OutTradeNo:    n.OutTradeNo,
+		Attach:        n.Attach,
+	}
+	if v.FeeType == "" {
+		v.FeeType = CNY
+	}
+	if n.Params != nil {
+		v.CashFeeType = n.Params.GetString("cash_fee_type")
+	}
+	for _, c := range n.CouponDetails {
+		v.Coupons = append(v.Coupons, couponDetailJSON{CouponID: c.CouponID, CouponType: c.CouponType, CouponFee: c.CouponFee.Value})
+	}
+	if !n.TimeEnd.IsZero() {
+		v.TimeEnd = n.TimeEnd.Format(time.RFC3339)
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,45 @@
+package wxpay
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewNotification(t *testing.T) {
+	params := MustXmlToMap("<xml><return_code><![CDATA[SUCCESS]]></return_code><result_code><![CDATA[SUCCESS]]></result_code><openid><![CDATA[oUpF8uMEb4qRXf22hE3X68TekukE]]></openid><total_fee>101</total_fee><cash_fee>91</cash_fee><coupon_fee>10</coupon_fee><coupon_count>1</coupon_count><coupon_id_0><![CDATA[10000]]></coupon_id_0><coupon_fee_0>10</coupon_fee_0><time_end><![CDATA[20140903131540]]></time_end><transaction_id><![CDATA[1004400740201409030005092168]]></transaction_id><out_trade_no><![CDATA[1409811653]]></out_trade_no><attach><![CDATA[支付测试]]></attach></xml>")
+	n, err := NewNotification(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.TotalFee != NewAmount(101, CNY) || n.TotalFee.String() != "1.01" || n.CashFee.Value != 91 {
+		t.Error(n.TotalFee, n.CashFee)
+	}
+	if len(n.CouponDetails) != 1 || n.CouponDetails[0].CouponFee.Value != 10 {
+		t.Error(n.CouponDetails)
+	}
+	if n.TimeEnd.Format("2006-01-02 15:04:05") != "2014-09-03 13:15:40" {
+		t.Error(n.TimeEnd)
+	}
+	if n.Params.GetString("attach") != "支付测试" {
+		t.Error(n.Params)
+	}
+}
+
+func TestNotificationMarshalJSON(t *testing.T) {
+	params := MustXmlToMap("<xml><return_code><![CDATA[SUCCESS]]></return_code><result_code><![CDATA[SUCCESS]]></result_code><total_fee>101</total_fee><cash_fee>101</cash_fee><time_end><![CDATA[20140903131540]]></time_end><out_trade_no><![CDATA[1409811653]]></out_trade_no></xml>")
+	n, err := NewNotification(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v["total_fee"] != float64(101) || v["fee_type"] != CNY || v["time_end"] != "2014-09-03T13:15:40+08:00" || v["out_trade_no"] != "1409811653" {
+		t.Error(string(data))
+	}
+}
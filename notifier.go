@@ -0,0 +1,60 @@
+package wxpay
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+)
+
+// 支付结果通知中result_code不为SUCCESS，ParseRequest同时返回通知参数
+var ErrPaymentFailed = errors.New("payment result_code is not SUCCESS")
+
+// 支付结果通知的解析及回复
+type Notifier struct {
+	Client *Client
+}
+
+// 创建Notifier
+func NewNotifier(client *Client) *Notifier {
+	return &Notifier{Client: client}
+}
+
+// 读取通知XML并验证签名。return_code不为SUCCESS或签名错误时返回错误；
+// result_code不为SUCCESS时返回通知参数和ErrPaymentFailed，此时仍应回复成功
+func (n *Notifier) ParseRequest(r *http.Request) (Params, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	params, err := n.Client.processNotifyXml(string(body))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotifyReturnCode(params); err != nil {
+		return nil, err
+	}
+	if params.GetString("result_code") != Success {
+		return params, ErrPaymentFailed
+	}
+	return params, nil
+}
+
+// 回复通知处理成功
+func (n *Notifier) ReplySuccess() string {
+	var notifies Notifies
+	return notifies.OK()
+}
+
+// 回复通知处理失败，微信会稍后重发通知
+func (n *Notifier) ReplyFail(msg string) string {
+	var notifies Notifies
+	return notifies.NotOK(msg)
+}
+
+// return_code为FAIL的通知没有签名，其中的数据不可信
+func checkNotifyReturnCode(params Params) error {
+	if params.GetString("return_code") != Success {
+		return errors.New("notify return_code is not SUCCESS: " + params.GetString("return_msg"))
+	}
+	return nil
+}
@@ -7,7 +7,7 @@ func (n *Notifies) OK() string {
 	var params = make(Params)
 	params.SetString("return_code", Success)
 	params.SetString("return_msg", "ok")
-	return MapToXml(params)
+	return MustMapToXml(params)
 }
 
 // 通知不成功
@@ -15,5 +15,5 @@ func (n *Notifies) NotOK(errMsg string) string {
 	var params = make(Params)
 	params.SetString("return_code", Fail)
 	params.SetString("return_msg", errMsg)
-	return MapToXml(params)
+	return MustMapToXml(params)
 }
@@ -0,0 +1,143 @@
+package wxpay
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// 支付结果通知的消费者
+type NotifyConsumer interface {
+	Consume(n *Notification) error
+}
+
+// 将函数包装为NotifyConsumer
+type NotifyConsumerFunc func(n *Notification) error
+
+func (f NotifyConsumerFunc) Consume(n *Notification) error {
+	return f(n)
+}
+
+// 支付结果通知的处理器，实现了http.Handler。
+// 通过Notifier验证签名后调用Primary，Primary成功后再依次调用Consumers。
+// 只有Primary失败时回复FAIL让微信重发通知；其他消费者的失败交给OnError处理，不影响回复
+type NotifyHandler struct {
+	Client    *Client
+	Primary   NotifyConsumer
+	Consumers []NotifyConsumer
+	OnError   func(n *Notification, consumer NotifyConsumer, err error) // 可以为nil
+}
+
+func (h *NotifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	notifier := NewNotifier(h.Client)
+	params, err := notifier.ParseRequest(r)
+	if err != nil && err != ErrPaymentFailed {
+		w.Write([]byte(notifier.ReplyFail(err.Error())))
+		return
+	}
+	notification, err := NewNotification(params)
+	if err != nil {
+		w.Write([]byte(notifier.ReplyFail(err.Error())))
+		return
+	}
+	if err := h.Primary.Consume(notification); err != nil {
+		w.Write([]byte(notifier.ReplyFail(err.Error())))
+		return
+	}
+	for _, consumer := range h.Consumers {
+		if err := consumeSafely(consumer, notification); err != nil && h.OnError != nil {
+			h.OnError(notification, consumer, err)
+		}
+	}
+	w.Write([]byte(notifier.ReplySuccess()))
+}
+
+// 非主要消费者的panic不应影响回复
+func consumeSafely(consumer NotifyConsumer, n *Notification) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("notify consumer panic")
+		}
+	}()
+	return consumer.Consume(n)
+}
+
+// 按attach中的路由key选择消费者，可以作为NotifyHandler的Primary，使一个回调地址服务多个租户或门店。
+// 下单时使用EncodeAttachRoute生成attach
+type NotifyRouter struct {
+	Default NotifyConsumer // 没有匹配的路由时使用，为nil时返回错误
+
+	mu     sync.RWMutex
+	routes map[string]NotifyConsumer
+}
+
+// 创建NotifyRouter
+func NewNotifyRouter() *NotifyRouter {
+	return &NotifyRouter{routes: make(map[string]NotifyConsumer)}
+}
+
+// 注册路由key对应的消费者
+func (r *NotifyRouter) Handle(route string, consumer NotifyConsumer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes[route] = consumer
+}
+
+func (r *NotifyRouter) Consume(n *Notification) error {
+	route := AttachRoute(n.Attach)
+	r.mu.RLock()
+	consumer, ok := r.routes[route]
+	r.mu.RUnlock()
+	if !ok {
+		consumer = r.Default
+	}
+	if consumer == nil {
+		return errors.New("no notify consumer for route " + route)
+	}
+	return consumer.Consume(n)
+}
+
+// 服务商模式下按sub_mch_id、sub_appid选择子商户的消费者，可以作为NotifyHandler的Primary。
+// 服务商模式的通知使用服务商的API密钥签名，NotifyHandler.Client应使用服务商的账号
+type SubMerchantRouter struct {
+	MchID   string         // 服务商商户号，不为空时拒绝mch_id不一致的通知
+	Default NotifyConsumer // 没有注册的子商户使用，为nil时返回错误
+
+	mu     sync.RWMutex
+	routes map[string]NotifyConsumer
+}
+
+// 创建SubMerchantRouter，mchID为服务商商户号
+func NewSubMerchantRouter(mchID string) *SubMerchantRouter {
+	return &SubMerchantRouter{MchID: mchID, routes: make(map[string]NotifyConsumer)}
+}
+
+func subMerchantRoute(subMchID string, subAppID string) string {
+	return subMchID + "/" + subAppID
+}
+
+// 注册子商户的消费者，subAppID为空时匹配该子商户的所有appid
+func (r *SubMerchantRouter) Handle(subMchID string, subAppID string, consumer NotifyConsumer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes[subMerchantRoute(subMchID, subAppID)] = consumer
+}
+
+func (r *SubMerchantRouter) Consume(n *Notification) error {
+	if r.MchID != "" && n.MchID != r.MchID {
+		return errors.New("notify mch_id " + n.MchID + " is not the service provider " + r.MchID)
+	}
+	r.mu.RLock()
+	consumer, ok := r.routes[subMerchantRoute(n.SubMchID, n.SubAppID)]
+	if !ok {
+		consumer, ok = r.routes[subMerchantRoute(n.SubMchID, "")]
+	}
+	r.mu.RUnlock()
+	if !ok {
+		consumer = r.Default
+	}
+	if consumer == nil {
+		return errors.New("no notify consumer for sub_mch_id " + n.SubMchID)
+	}
+	return consumer.Consume(n)
+}
@@ -0,0 +1,129 @@
+package wxpay
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotifyHandler(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	params := make(Params)
+	params.SetString("return_code", Success).
+		SetString("result_code", Success).
+		SetString("out_trade_no", "1409811653").
+		SetString("total_fee", "1")
+	params.SetString("sign", client.Sign(params))
+	body := MustMapToXml(params)
+
+	var analytics []string
+	handler := &NotifyHandler{
+		Client: client,
+		Primary: NotifyConsumerFunc(func(n *Notification) error {
+			return nil
+		}),
+		Consumers: []NotifyConsumer{
+			NotifyConsumerFunc(func(n *Notification) error {
+				return errors.New("crm unavailable")
+			}),
+			NotifyConsumerFunc(func(n *Notification) error {
+				analytics = append(analytics, n.OutTradeNo)
+				return nil
+			}),
+		},
+	}
+	var failed int
+	handler.OnError = func(n *Notification, consumer NotifyConsumer, err error) {
+		failed++
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/notify", strings.NewReader(body)))
+	if reply := MustXmlToMap(w.Body.String()); reply.GetString("return_code") != Success {
+		t.Error(reply)
+	}
+	if failed != 1 || len(analytics) != 1 || analytics[0] != "1409811653" {
+		t.Error(failed, analytics)
+	}
+
+	handler.Primary = NotifyConsumerFunc(func(n *Notification) error {
+		return errors.New("order service unavailable")
+	})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/notify", strings.NewReader(body)))
+	if reply := MustXmlToMap(w.Body.String()); reply.GetString("return_code") != Fail {
+		t.Error(reply)
+	}
+	if len(analytics) != 1 {
+		t.Error(analytics)
+	}
+}
+
+func TestNotifyRouter(t *testing.T) {
+	attach, err := EncodeAttachRoute("store-42", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var routed string
+	router := NewNotifyRouter()
+	router.Handle("store-42", NotifyConsumerFunc(func(n *Notification) error {
+		routed = n.OutTradeNo
+		return nil
+	}))
+	if err := router.Consume(&Notification{OutTradeNo: "1409811653", Attach: attach}); err != nil || routed != "1409811653" {
+		t.Error(routed, err)
+	}
+	if err := router.Consume(&Notification{Attach: "route=store-7"}); err == nil {
+		t.Error("expected unknown route error")
+	}
+}
+
+func TestSubMerchantRouter(t *testing.T) {
+	provider := NewClient(NewAccount("wx8888888888888888", "1900000109", "xxxxx", false))
+	var routed []string
+	consumer := func(name string) NotifyConsumer {
+		return NotifyConsumerFunc(func(n *Notification) error {
+			routed = append(routed, name+":"+n.OutTradeNo)
+			return nil
+		})
+	}
+	router := NewSubMerchantRouter("1900000109")
+	router.Handle("1900000110", "", consumer("shop"))
+	router.Handle("1900000110", "wxd678efh567hg6787", consumer("mini"))
+	handler := &NotifyHandler{Client: provider, Primary: router}
+
+	notify := func(mchID, subMchID, subAppID, outTradeNo, apiKey string) string {
+		params := make(Params)
+		params.SetString("return_code", Success).
+			SetString("result_code", Success).
+			SetString("mch_id", mchID).
+			SetString("sub_mch_id", subMchID).
+			SetString("sub_appid", subAppID).
+			SetString("out_trade_no", outTradeNo).
+			SetString("total_fee", "1")
+		params.SetString("sign", signParams(params, MD5, apiKey))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/notify", strings.NewReader(MustMapToXml(params))))
+		return MustXmlToMap(w.Body.String()).GetString("return_code")
+	}
+	if reply := notify("1900000109", "1900000110", "wxd678efh567hg6787", "1", "xxxxx"); reply != Success {
+		t.Error(reply)
+	}
+	if reply := notify("1900000109", "1900000110", "wx1111111111111111", "2", "xxxxx"); reply != Success {
+		t.Error(reply)
+	}
+	// 未注册的子商户、其他服务商的通知以及子商户密钥签名的通知都被拒绝
+	for _, reply := range []string{
+		notify("1900000109", "1900000111", "", "3", "xxxxx"),
+		notify("1900000200", "1900000110", "", "4", "xxxxx"),
+		notify("1900000109", "1900000110", "", "5", "sub-merchant-key"),
+	} {
+		if reply != Fail {
+			t.Error(reply)
+		}
+	}
+	if strings.Join(routed, ",") != "mini:1,shop:2" {
+		t.Error(routed)
+	}
+}
@@ -0,0 +1,280 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// 委托代扣签约请求，JSAPI、APP、H5和小程序签约共用
+type EntrustRequest struct {
+	PlanID                 string // 模板id
+	ContractCode           string // 商户侧的签约协议号
+	RequestSerial          int64  // 商户请求签约时的序列号，要求唯一
+	ContractDisplayAccount string // 签约用户的名称，用于页面展示
+	NotifyUrl              string // 签约结果通知地址
+	ClientIP               string // 用户客户端IP，H5签约时必填
+	ReturnWeb              bool   // 公众号签约完成后是否返回商户页面
+}
+
+func (r *EntrustRequest) params() (Params, error) {
+	if r.PlanID == "" || r.ContractCode == "" || r.RequestSerial == 0 || r.ContractDisplayAccount == "" || r.NotifyUrl == "" {
+		return nil, errors.New("entrust: plan_id, contract_code, request_serial, contract_display_account and notify_url are required")
+	}
+	p := make(Params)
+	p.SetString("plan_id", r.PlanID).
+		SetString("contract_code", r.ContractCode).
+		SetInt64("request_serial", r.RequestSerial).
+		SetString("contract_display_account", r.ContractDisplayAccount).
+		SetString("notify_url", r.NotifyUrl).
+		SetString("version", "1.0")
+	return p, nil
+}
+
+// 生成前端签约使用的参数，包含appid、mch_id、timestamp和sign
+func (c *Client) entrustParams(req *EntrustRequest, signType string) (Params, error) {
+	p, err := req.params()
+	if err != nil {
+		return nil, err
+	}
+	p.SetString("appid", c.account.appID).
+		SetString("mch_id", c.account.activeMchID()).
+		SetInt64("timestamp", c.getClock().Now().Unix())
+	p.SetString(Sign, c.signWithType(p, signType))
+	return p, nil
+}
+
+func encodeEntrustUrl(base string, p Params) string {
+	values := url.Values{}
+	for k, v := range p {
+		values.Set(k, v)
+	}
+	return base + "?" + values.Encode()
+}
+
+// 生成公众号签约地址，在微信内打开即进入签约页面
+func (c *Client) EntrustWebURL(req *EntrustRequest) (string, error) {
+	p, err := c.entrustParams(req, MD5)
+	if err != nil {
+		return "", err
+	}
+	if req.ReturnWeb {
+		p.SetString("return_web", "1")
+	}
+	return encodeEntrustUrl(EntrustWebUrl, p), nil
+}
+
+// 生成小程序签约的extraData，通过wx.navigateToMiniProgram打开微信签约小程序时传入
+func (c *Client) MiniProgramEntrustData(req *EntrustRequest) (Params, error) {
+	return c.entrustParams(req, MD5)
+}
+
+// APP签约预下单，返回的pre_entrustweb_id传给APP SDK
+func (c *Client) PreEntrustWeb(req *EntrustRequest) (Params, error) {
+	return c.PreEntrustWebContext(context.Background(), req)
+}
+
+// APP签约预下单，ctx可用于取消请求
+func (c *Client) PreEntrustWebContext(ctx context.Context, req *EntrustRequest) (Params, error) {
+	p, err := req.params()
+	if err != nil {
+		return nil, err
+	}
+	p.SetInt64("timestamp", c.getClock().Now().Unix())
+	return c.InvokeContext(ctx, "PreEntrustWeb", p)
+}
+
+// H5签约，返回的redirect_url为签约页面地址。H5签约只支持HMAC-SHA256签名
+func (c *Client) H5Entrust(req *EntrustRequest) (Params, error) {
+	return c.H5EntrustContext(context.Background(), req)
+}
+
+// H5签约，ctx可用于取消请求
+func (c *Client) H5EntrustContext(ctx context.Context, req *EntrustRequest) (Params, error) {
+	if err := c.account.checkProduct(Endpoint{Name: "H5Entrust", Product: ProductPapay}); err != nil {
+		return nil, err
+	}
+	if req.ClientIP == "" {
+		return nil, errors.New("entrust: clientip is required for H5")
+	}
+	p, err := req.params()
+	if err != nil {
+		return nil, err
+	}
+	p.SetString("clientip", req.ClientIP).
+		SetString("appid", c.account.appID).
+		SetString("mch_id", c.account.activeMchID()).
+		SetInt64("timestamp", c.getClock().Now().Unix())
+	p.SetString(Sign, c.signWithType(p, HMACSHA256))
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, encodeEntrustUrl(H5EntrustWebUrl, p), nil)
+	if err != nil {
+		return nil, err
+	}
+	h := &http.Client{Transport: c.plainTransport(), Timeout: c.timeoutFor(H5EntrustWebUrl)}
+	response, err := h.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	return c.processResponseXmlWithType(string(body), true, HMACSHA256)
+}
+
+// 申请扣款，params中需要body、out_trade_no、total_fee、spbill_create_ip、notify_url、contract_id，
+// trade_type默认为PAP。扣款结果通过扣款结果通知返回
+func (c *Client) PapPayApply(params Params) (Params, error) {
+	return c.PapPayApplyContext(context.Background(), params)
+}
+
+// 申请扣款，ctx可用于取消请求
+func (c *Client) PapPayApplyContext(ctx context.Context, params Params) (Params, error) {
+	if err := requireParams("pappayapply", params, "body", "out_trade_no", "total_fee", "spbill_create_ip", "notify_url", "contract_id"); err != nil {
+		return nil, err
+	}
+	if !params.ContainsKey("trade_type") {
+		params.SetString("trade_type", "PAP")
+	}
+	return c.InvokeContext(ctx, "PapPayApply", params)
+}
+
+// 签约协议的定位参数，contract_id与plan_id+contract_code二选一
+func checkContractKey(op string, params Params) error {
+	if params.GetString("contract_id") == "" && (params.GetString("plan_id") == "" || params.GetString("contract_code") == "") {
+		return errors.New(op + ": contract_id or plan_id and contract_code is required")
+	}
+	if !params.ContainsKey("version") {
+		params.SetString("version", "1.0")
+	}
+	return nil
+}
+
+// 查询签约关系
+func (c *Client) QueryContract(params Params) (Params, error) {
+	return c.QueryContractContext(context.Background(), params)
+}
+
+// 查询签约关系，ctx可用于取消请求
+func (c *Client) QueryContractContext(ctx context.Context, params Params) (Params, error) {
+	if err := checkContractKey("querycontract", params); err != nil {
+		return nil, err
+	}
+	return c.InvokeContext(ctx, "QueryContract", params)
+}
+
+// 申请解约，params中需要contract_termination_remark
+func (c *Client) DeleteContract(params Params) (Params, error) {
+	return c.DeleteContractContext(context.Background(), params)
+}
+
+// 申请解约，ctx可用于取消请求
+func (c *Client) DeleteContractContext(ctx context.Context, params Params) (Params, error) {
+	if err := checkContractKey("deletecontract", params); err != nil {
+		return nil, err
+	}
+	if err := requireParams("deletecontract", params, "contract_termination_remark"); err != nil {
+		return nil, err
+	}
+	return c.InvokeContext(ctx, "DeleteContract", params)
+}
+
+// 签约、解约结果通知中的change_type
+const (
+	ContractChangeAdd    = "ADD"
+	ContractChangeDelete = "DELETE"
+)
+
+const contractTimeLayout = "2006-01-02 15:04:05"
+
+// 签约、解约结果通知
+type ContractNotification struct {
+	ResultCode              string
+	MchID                   string
+	ContractCode            string
+	PlanID                  string
+	OpenID                  string
+	ChangeType              string // ADD签约，DELETE解约
+	OperateTime             time.Time
+	ContractID              string
+	ContractExpiredTime     time.Time
+	ContractTerminationMode string // 解约方式，解约时返回
+	RequestSerial           int64
+	Params                  Params // 原始通知参数
+}
+
+// 将通知参数转换为ContractNotification
+func NewContractNotification(params Params) (*ContractNotification, error) {
+	n := &ContractNotification{
+		ResultCode:              params.GetString("result_code"),
+		MchID:                   params.GetString("mch_id"),
+		ContractCode:            params.GetString("contract_code"),
+		PlanID:                  params.GetString("plan_id"),
+		OpenID:                  params.GetString("openid"),
+		ChangeType:              params.GetString("change_type"),
+		ContractID:              params.GetString("contract_id"),
+		ContractTerminationMode: params.GetString("contract_termination_mode"),
+		Params:                  params,
+	}
+	if s := params.GetString("request_serial"); s != "" {
+		serial, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		n.RequestSerial = serial
+	}
+	times := []struct {
+		key string
+		t   *time.Time
+	}{
+		{"operate_time", &n.OperateTime},
+		{"contract_expired_time", &n.ContractExpiredTime},
+	}
+	for _, f := range times {
+		if !params.ContainsKey(f.key) {
+			continue
+		}
+		t, err := time.ParseInLocation(contractTimeLayout, params.GetString(f.key), beijingLocation)
+		if err != nil {
+			return nil, err
+		}
+		*f.t = t
+	}
+	return n, nil
+}
+
+// 解析签约、解约结果通知的XML，按MD5验证签名，return_code不为SUCCESS时返回错误
+func (c *Client) ParseContractNotification(xmlStr string) (*ContractNotification, error) {
+	params, err := c.processResponseXmlWithType(xmlStr, true, MD5)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotifyReturnCode(params); err != nil {
+		return nil, err
+	}
+	return NewContractNotification(params)
+}
+
+// 扣款结果通知，格式与支付结果通知相同，另外包含contract_id
+type PapPayNotification struct {
+	*Notification
+	ContractID string
+}
+
+// 解析扣款结果通知的XML并验证签名，return_code不为SUCCESS时返回错误
+func (c *Client) ParsePapPayNotification(xmlStr string) (*PapPayNotification, error) {
+	n, err := c.ParseNotification(xmlStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotifyReturnCode(n.Params); err != nil {
+		return nil, err
+	}
+	return &PapPayNotification{Notification: n, ContractID: n.Params.GetString("contract_id")}, nil
+}
@@ -0,0 +1,80 @@
+package wxpay
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestEntrustWebURL(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.SetSignType(HMACSHA256)
+	u, err := client.EntrustWebURL(&EntrustRequest{
+		PlanID:                 "12535",
+		ContractCode:           "100000",
+		RequestSerial:          1000,
+		ContractDisplayAccount: "微信代扣",
+		NotifyUrl:              "https://www.qq.com/test/papay",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make(Params)
+	for k := range parsed.Query() {
+		p.SetString(k, parsed.Query().Get(k))
+	}
+	// 签约页面只支持MD5签名
+	if p.GetString("sign") != client.signWithType(p, MD5) {
+		t.Error("invalid entrust sign", u)
+	}
+	if !strings.HasPrefix(u, EntrustWebUrl+"?") || p.GetString("notify_url") != "https://www.qq.com/test/papay" {
+		t.Error(u)
+	}
+
+	if _, err := client.QueryContract(Params{"plan_id": "12535"}); err == nil {
+		t.Error("expected contract_code error")
+	}
+}
+
+func TestNewContractNotification(t *testing.T) {
+	params := Params{
+		"result_code":           Success,
+		"contract_code":         "100000",
+		"change_type":           ContractChangeAdd,
+		"operate_time":          "2015-07-01 10:00:00",
+		"contract_expired_time": "2016-07-01 10:00:00",
+		"request_serial":        "1000",
+	}
+	n, err := NewContractNotification(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.RequestSerial != 1000 || n.OperateTime.Hour() != 10 || n.ContractExpiredTime.Year() != 2016 {
+		t.Error(n)
+	}
+}
+
+func TestParseContractNotificationForgedFail(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	forged := "<xml><return_code>FAIL</return_code><result_code>SUCCESS</result_code>" +
+		"<change_type>ADD</change_type><contract_id>X</contract_id></xml>"
+	if n, err := client.ParseContractNotification(forged); err == nil {
+		t.Error("forged notification accepted:", n)
+	}
+	forged = "<xml><return_code>FAIL</return_code><result_code>SUCCESS</result_code>" +
+		"<out_trade_no>1409811653</out_trade_no><contract_id>X</contract_id></xml>"
+	if n, err := client.ParsePapPayNotification(forged); err == nil {
+		t.Error("forged notification accepted:", n)
+	}
+
+	params := Params{"return_code": Success, "result_code": Success, "change_type": ContractChangeAdd, "contract_id": "X"}
+	params.SetString(Sign, signParams(params, MD5, "xxxxx"))
+	n, err := client.ParseContractNotification(MustMapToXml(params))
+	if err != nil || n.ContractID != "X" {
+		t.Error(n, err)
+	}
+}
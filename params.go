@@ -10,6 +10,15 @@ func (p Params) SetString(k, s string) Params {
 	return p
 }
 
+// 服务商模式下指定本次请求的子商户，subAppID为空时不设置sub_appid
+func (p Params) SetSubMerchant(subAppID string, subMchID string) Params {
+	p["sub_mch_id"] = subMchID
+	if subAppID != "" {
+		p["sub_appid"] = subAppID
+	}
+	return p
+}
+
 func (p Params) GetString(k string) string {
 	s, _ := p[k]
 	return s
@@ -30,3 +39,52 @@ func (p Params) ContainsKey(key string) bool {
 	_, ok := p[key]
 	return ok
 }
+
+// 返回Params的副本
+func (p Params) Clone() Params {
+	c := make(Params, len(p))
+	for k, v := range p {
+		c[k] = v
+	}
+	return c
+}
+
+// 返回只读视图，视图持有副本，之后修改p不影响视图
+func (p Params) View() ParamsView {
+	return ParamsView{p: p.Clone()}
+}
+
+// 只读的Params，可以在多个goroutine之间共享，例如将通知参数交给多个worker处理
+type ParamsView struct {
+	p Params
+}
+
+func (v ParamsView) GetString(k string) string {
+	return v.p.GetString(k)
+}
+
+func (v ParamsView) GetInt64(k string) int64 {
+	return v.p.GetInt64(k)
+}
+
+func (v ParamsView) ContainsKey(key string) bool {
+	return v.p.ContainsKey(key)
+}
+
+func (v ParamsView) Len() int {
+	return len(v.p)
+}
+
+// 遍历所有字段，f返回false时停止
+func (v ParamsView) Range(f func(k, s string) bool) {
+	for k, s := range v.p {
+		if !f(k, s) {
+			return
+		}
+	}
+}
+
+// 返回可修改的副本
+func (v ParamsView) Params() Params {
+	return v.p.Clone()
+}
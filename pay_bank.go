@@ -0,0 +1,181 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// 获取企业付款到银行卡使用的RSA公钥，返回的pub_key为PKCS#1格式的PEM。
+// 该接口只支持MD5签名
+func (c *Client) GetPublicKey() (Params, error) {
+	return c.GetPublicKeyContext(context.Background())
+}
+
+// 获取RSA公钥，ctx可用于取消请求
+func (c *Client) GetPublicKeyContext(ctx context.Context) (Params, error) {
+	params := make(Params)
+	params.SetString("mch_id", c.account.activeMchID()).
+		SetString("nonce_str", nonceStr()).
+		SetString("sign_type", MD5)
+	params.SetString(Sign, c.signWithType(params, MD5))
+	xmlStr, err := c.RawPostWithCert(ctx, GetPublicKeyUrl, params, false)
+	if err != nil {
+		return nil, err
+	}
+	return c.processResponseXml(xmlStr, false)
+}
+
+// 获取并解析RSA公钥
+func (c *Client) fetchPublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	res, err := c.GetPublicKeyContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if res.GetString("return_code") != Success || res.GetString("result_code") != Success {
+		return nil, errors.New("getpublickey failed: " + res.GetString("return_msg") + res.GetString("err_code_des"))
+	}
+	return ParseRSAPublicKey([]byte(res.GetString("pub_key")))
+}
+
+// 解析PEM格式的RSA公钥，支持PKCS#1和PKCS#8
+func ParseRSAPublicKey(pemData []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("invalid public key pem")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not RSA")
+	}
+	return rsaKey, nil
+}
+
+// 使用RSA公钥加密，填充方式为RSA_PKCS1_OAEP_PADDING，返回base64编码的密文
+func EncryptRSAOAEP(key *rsa.PublicKey, plain string) (string, error) {
+	data, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, key, []byte(plain), nil)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// 企业付款到银行卡。params中的enc_bank_no和enc_true_name填写明文，发送前使用RSA公钥加密
+func (c *Client) PayBank(params Params) (Params, error) {
+	return c.PayBankContext(context.Background(), params)
+}
+
+// 企业付款到银行卡，ctx可用于取消请求。
+// 微信因公钥不匹配无法解密时（例如商户平台更换了公钥），重新获取公钥加密后再请求一次
+func (c *Client) PayBankContext(ctx context.Context, params Params) (Params, error) {
+	plain, err := bankFieldsOf(params)
+	if err != nil {
+		return nil, err
+	}
+	release, err := c.reservePayout(ProductTransfer, params.GetInt64("amount"))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.encryptBankFields(ctx, params, plain); err != nil {
+		release()
+		return nil, err
+	}
+	p, err := c.InvokeContext(ctx, "PayBank", params)
+	if isPublicKeyError(p) {
+		c.InvalidatePublicKey()
+		if err := c.encryptBankFields(ctx, params, plain); err != nil {
+			release()
+			return nil, err
+		}
+		p, err = c.InvokeContext(ctx, "PayBank", params)
+	}
+	if payoutFailed(p) {
+		release()
+	}
+	return p, err
+}
+
+// 微信无法解密enc_bank_no、enc_true_name时返回的错误码
+var publicKeyErrCodes = map[string]bool{
+	"ENCRYPT_ERROR":     true,
+	"RSA_DECRYPT_ERROR": true,
+	"DECRYPT_ERROR":     true,
+}
+
+// 返回数据是否表示加密使用的公钥已失效
+func isPublicKeyError(p Params) bool {
+	if p.GetString("result_code") != Fail {
+		return false
+	}
+	return publicKeyErrCodes[p.GetString("err_code")] || strings.Contains(p.GetString("err_code_des"), "解密")
+}
+
+// 缓存的RSA公钥
+type publicKeyCache struct {
+	mu  sync.Mutex
+	key *rsa.PublicKey
+}
+
+// 返回缓存的RSA公钥，没有缓存时调用getpublickey获取
+func (c *Client) PublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	c.publicKey.mu.Lock()
+	defer c.publicKey.mu.Unlock()
+	if c.publicKey.key != nil {
+		return c.publicKey.key, nil
+	}
+	key, err := c.fetchPublicKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.publicKey.key = key
+	return key, nil
+}
+
+// 清除缓存的RSA公钥，下次使用时重新获取，例如商户在商户平台更换了公钥
+func (c *Client) InvalidatePublicKey() {
+	c.publicKey.mu.Lock()
+	c.publicKey.key = nil
+	c.publicKey.mu.Unlock()
+}
+
+// 需要加密的字段的明文
+func bankFieldsOf(params Params) (map[string]string, error) {
+	plain := make(map[string]string, 2)
+	for _, k := range []string{"enc_bank_no", "enc_true_name"} {
+		if !params.ContainsKey(k) {
+			return nil, errors.New("missing " + k)
+		}
+		plain[k] = params.GetString(k)
+	}
+	return plain, nil
+}
+
+// 使用缓存的公钥加密明文，并写入params
+func (c *Client) encryptBankFields(ctx context.Context, params Params, plain map[string]string) error {
+	key, err := c.PublicKey(ctx)
+	if err != nil {
+		return err
+	}
+	for k, v := range plain {
+		enc, err := EncryptRSAOAEP(key, v)
+		if err != nil {
+			return err
+		}
+		params.SetString(k, enc)
+	}
+	return nil
+}
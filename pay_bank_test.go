@@ -0,0 +1,93 @@
+package wxpay
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEncryptRSAOAEP(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})
+	pub, err := ParseRSAPublicKey(pemData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := EncryptRSAOAEP(pub, "6225760008888888")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := base64.StdEncoding.DecodeString(enc)
+	plain, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, key, data, nil)
+	if err != nil || string(plain) != "6225760008888888" {
+		t.Error(string(plain), err)
+	}
+}
+
+func TestPayBankRefreshesPublicKey(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	serverKey := oldKey
+
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.account.cert = &tls.Certificate{}
+	var fetches, payments int
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(r.Body)
+			req := MustXmlToMap(string(body))
+			res := Params{"return_code": Success}
+			if strings.HasSuffix(r.URL.Path, "/getpublickey") {
+				fetches++
+				res.SetString("result_code", Success).
+					SetString("pub_key", string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&serverKey.PublicKey)})))
+			} else {
+				payments++
+				data, _ := base64.StdEncoding.DecodeString(req.GetString("enc_bank_no"))
+				if plain, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, serverKey, data, nil); err != nil || string(plain) != "6225760008888888" {
+					res.SetString("result_code", Fail).SetString("err_code", "RSA_DECRYPT_ERROR")
+				} else {
+					res.SetString("result_code", Success).SetString("payment_no", "10000600500852017030900000020006012")
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+	newParams := func() Params {
+		params := make(Params)
+		params.SetString("partner_trade_no", "1212121221278").
+			SetString("enc_bank_no", "6225760008888888").
+			SetString("enc_true_name", "张三").
+			SetString("bank_code", "1002").
+			SetInt64("amount", 500)
+		return params
+	}
+	if p, err := client.PayBank(newParams()); err != nil || p.GetString("result_code") != Success {
+		t.Fatal(p, err)
+	}
+
+	// 商户平台更换公钥后，缓存的旧公钥加密的数据无法解密
+	serverKey = newKey
+	p, err := client.PayBank(newParams())
+	if err != nil || p.GetString("result_code") != Success {
+		t.Fatal(p, err)
+	}
+	if fetches != 2 || payments != 3 {
+		t.Error(fetches, payments)
+	}
+}
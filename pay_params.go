@@ -0,0 +1,60 @@
+package wxpay
+
+import (
+	"context"
+	"strconv"
+)
+
+// 生成公众号内JSAPI支付的参数，包含appId、timeStamp、nonceStr、package、signType、paySign，
+// 可直接传给WeixinJSBridge的getBrandWCPayRequest或wx.chooseWXPay。
+// 前端参数的字段名为驼峰形式，签名时同样按这些字段名排序，与请求签名不同
+func (c *Client) GetJSAPIPayParams(prepayID string) Params {
+	return c.jsapiPayParams(c.account.appID, prepayID)
+}
+
+// 小程序统一下单，trade_type为JSAPI，appid使用Account.SetMiniProgramAppID设置的appid，
+// 返回的prepay_id可用于GetMiniProgramPayParams
+func (c *Client) UnifiedOrderMiniProgram(params Params) (Params, error) {
+	return c.UnifiedOrderMiniProgramContext(context.Background(), params)
+}
+
+// 小程序统一下单，ctx可用于取消请求
+func (c *Client) UnifiedOrderMiniProgramContext(ctx context.Context, params Params) (Params, error) {
+	params.SetString("appid", c.account.miniProgramAppID()).
+		SetString("trade_type", TradeTypeJSAPI)
+	return c.UnifiedOrderContext(ctx, params)
+}
+
+// 生成小程序wx.requestPayment的参数，包含timeStamp、nonceStr、package、signType、paySign。
+// 签名时使用Account.SetMiniProgramAppID设置的appid，prepay_id需由UnifiedOrderMiniProgram生成，appId本身不需要传给wx.requestPayment
+func (c *Client) GetMiniProgramPayParams(prepayID string) Params {
+	params := c.jsapiPayParams(c.account.miniProgramAppID(), prepayID)
+	delete(params, "appId")
+	return params
+}
+
+func (c *Client) jsapiPayParams(appID string, prepayID string) Params {
+	signType := c.currentSignType()
+	params := make(Params)
+	params.SetString("appId", appID).
+		SetString("timeStamp", strconv.FormatInt(c.getClock().Now().Unix(), 10)).
+		SetString("nonceStr", nonceStr()).
+		SetString("package", "prepay_id="+prepayID).
+		SetString("signType", signType)
+	params.SetString("paySign", c.signWithType(params, signType))
+	return params
+}
+
+// 生成APP支付的参数，包含appid、partnerid、prepayid、package、noncestr、timestamp、sign，
+// 用于APP SDK调起支付。签名类型与统一下单一致
+func (c *Client) GetAppPayParams(prepayID string) Params {
+	params := make(Params)
+	params.SetString("appid", c.account.appID).
+		SetString("partnerid", c.account.activeMchID()).
+		SetString("prepayid", prepayID).
+		SetString("package", "Sign=WXPay").
+		SetString("noncestr", nonceStr()).
+		SetString("timestamp", strconv.FormatInt(c.getClock().Now().Unix(), 10))
+	params.SetString("sign", c.Sign(params))
+	return params
+}
@@ -0,0 +1,87 @@
+package wxpay
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetJSAPIPayParams(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	p := client.GetJSAPIPayParams("wx201410272009395522657a690389285100")
+	if p.GetString("package") != "prepay_id=wx201410272009395522657a690389285100" || p.GetString("signType") != MD5 {
+		t.Error(p)
+	}
+	s := "appId=wx2421b1c4370ec43b&nonceStr=" + p.GetString("nonceStr") +
+		"&package=prepay_id=wx201410272009395522657a690389285100&signType=MD5&timeStamp=" + p.GetString("timeStamp") + "&key=xxxxx"
+	sum := md5.Sum([]byte(s))
+	if p.GetString("paySign") != strings.ToUpper(hex.EncodeToString(sum[:])) {
+		t.Error("invalid paySign", p)
+	}
+}
+
+func TestGetAppPayParams(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	p := client.GetAppPayParams("wx201410272009395522657a690389285100")
+	if p.GetString("partnerid") != "10000100" || p.GetString("package") != "Sign=WXPay" || !client.ValidSign(p) {
+		t.Error(p)
+	}
+}
+
+func TestGetMiniProgramPayParams(t *testing.T) {
+	account := NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false)
+	account.SetMiniProgramAppID("wxd678efh567hg6787")
+	client := NewClient(account)
+	p := client.GetMiniProgramPayParams("wx201410272009395522657a690389285100")
+	if len(p) != 5 || p.ContainsKey("appId") {
+		t.Error(p)
+	}
+	signed := make(Params)
+	for k, v := range p {
+		signed[k] = v
+	}
+	signed.SetString("appId", "wxd678efh567hg6787")
+	delete(signed, "paySign")
+	if p.GetString("paySign") != client.signWithType(signed, MD5) {
+		t.Error("paySign not signed with mini program appid")
+	}
+}
+
+func TestUnifiedOrderMiniProgram(t *testing.T) {
+	account := NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false)
+	account.SetMiniProgramAppID("wxd678efh567hg6787")
+	client := NewClient(account)
+	var appIDs []string
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(r.Body)
+			appIDs = append(appIDs, MustXmlToMap(string(body)).GetString("appid"))
+			res := make(Params)
+			res.SetString("return_code", Success).SetString("result_code", Success)
+			res.SetString("sign", client.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+
+	params := make(Params)
+	params.SetString("body", "test").SetString("out_trade_no", "1409811653").SetInt64("total_fee", 1)
+	if _, err := client.UnifiedOrderMiniProgram(params); err != nil {
+		t.Fatal(err)
+	}
+	// 其他appid仍被替换为账号的appid
+	params = make(Params)
+	params.SetString("appid", "wxother").SetString("out_trade_no", "1409811654")
+	if _, err := client.UnifiedOrder(params); err != nil {
+		t.Fatal(err)
+	}
+	if len(appIDs) != 2 || appIDs[0] != "wxd678efh567hg6787" || appIDs[1] != "wx2421b1c4370ec43b" {
+		t.Error(appIDs)
+	}
+}
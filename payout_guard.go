@@ -0,0 +1,86 @@
+package wxpay
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// 超出付款额度
+var ErrPayoutLimitExceeded = errors.New("payout limit exceeded")
+
+// 付款额度计数器，多实例部署时应使用共享存储实现
+type PayoutCounter interface {
+	// 将day当日的付款金额增加amount（分，可以为负数），返回增加后的当日总额
+	Add(day string, amount int64) (int64, error)
+}
+
+// 内存中的付款额度计数器
+type MemoryPayoutCounter struct {
+	mu     sync.Mutex
+	totals map[string]int64
+}
+
+func (m *MemoryPayoutCounter) Add(day string, amount int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.totals == nil {
+		m.totals = make(map[string]int64)
+	}
+	m.totals[day] += amount
+	return m.totals[day], nil
+}
+
+// 付款额度保护，在调用付款接口前检查单笔和当日付款上限，作为程序错误导致资金流失的最后防线
+type PayoutGuard struct {
+	MaxPerTransaction int64         // 单笔付款上限（分），0表示不限制
+	MaxPerDay         int64         // 当日付款上限（分），0表示不限制
+	Counter           PayoutCounter // 当日付款金额计数器
+}
+
+// 设置付款额度保护，nil表示不检查
+func (c *Client) SetPayoutGuard(g *PayoutGuard) {
+	c.payoutGuard = g
+}
+
+// 检查并占用额度，返回的release用于在付款明确失败时释放额度。
+// 占用额度前先检查账号是否开通了product，避免未发送的付款占用额度
+func (c *Client) reservePayout(product string, amount int64) (release func(), err error) {
+	if !c.account.HasProduct(product) {
+		return nil, fmt.Errorf("%w: payout requires %s", ErrProductNotEnabled, product)
+	}
+	g := c.payoutGuard
+	if g == nil {
+		return func() {}, nil
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid payout amount %d", amount)
+	}
+	if g.MaxPerTransaction > 0 && amount > g.MaxPerTransaction {
+		return nil, fmt.Errorf("%w: amount %d exceeds per-transaction limit %d", ErrPayoutLimitExceeded, amount, g.MaxPerTransaction)
+	}
+	if g.MaxPerDay <= 0 || g.Counter == nil {
+		return func() {}, nil
+	}
+
+	day := c.getClock().Now().In(beijingLocation).Format("2006-01-02")
+	total, err := g.Counter.Add(day, amount)
+	if err != nil {
+		return nil, err
+	}
+	if total > g.MaxPerDay {
+		g.Counter.Add(day, -amount)
+		return nil, fmt.Errorf("%w: daily total %d exceeds limit %d", ErrPayoutLimitExceeded, total, g.MaxPerDay)
+	}
+	return func() {
+		g.Counter.Add(day, -amount)
+	}, nil
+}
+
+// 付款是否明确失败，SYSTEMERROR等结果未知的情况不释放额度
+func payoutFailed(params Params) bool {
+	if params.GetString("return_code") == Fail {
+		return true
+	}
+	return params.GetString("result_code") == Fail && params.GetString("err_code") != "SYSTEMERROR"
+}
@@ -0,0 +1,376 @@
+package wxpay
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 平台证书找不到时两次刷新之间的最小间隔，避免伪造的序列号导致频繁请求
+const minCertRefreshInterval = time.Minute
+
+// 定时刷新平台证书的默认间隔
+const defaultCertRefreshInterval = 12 * time.Hour
+
+// 平台证书序列号不存在
+var ErrCertNotFound = errors.New("platform certificate not found")
+
+// 平台证书管理器，下载并缓存微信支付平台证书，用于验证返回和回调的签名
+type PlatformCertManager struct {
+	client *ClientV3
+
+	mu          sync.RWMutex
+	certs       map[string]*x509.Certificate // 序列号 -> 证书
+	lastRefresh time.Time
+	store       KVStore
+	loaded      bool // 是否已从store中读取
+}
+
+// 创建平台证书管理器，并设置为client验证返回签名使用的证书
+func NewPlatformCertManager(client *ClientV3) *PlatformCertManager {
+	m := &PlatformCertManager{client: client, certs: make(map[string]*x509.Certificate)}
+	client.certs = m
+	return m
+}
+
+// 设置保存平台证书的KVStore，多个实例可以共享下载的证书，重启后不必重新下载
+func (m *PlatformCertManager) SetStore(store KVStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+	m.loaded = false
+}
+
+func (m *PlatformCertManager) storeKey() string {
+	return "wxpay/v3/platform_certs/" + m.client.mchID
+}
+
+// 从store中读取平台证书，忽略已过期的证书
+func (m *PlatformCertManager) load(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store == nil || m.loaded {
+		return nil
+	}
+	data, err := m.store.Get(ctx, m.storeKey())
+	if errors.Is(err, ErrKeyNotFound) {
+		m.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var pems map[string]string
+	if err := json.Unmarshal(data, &pems); err != nil {
+		return err
+	}
+	now := clockOrSystem(m.client.clock).Now()
+	for serial, p := range pems {
+		cert, err := parseCertificate([]byte(p))
+		if err != nil {
+			return err
+		}
+		if now.Before(cert.NotAfter) {
+			m.certs[serial] = cert
+		}
+	}
+	m.loaded = true
+	return nil
+}
+
+// 将当前的平台证书保存到store
+func (m *PlatformCertManager) save(ctx context.Context) error {
+	m.mu.RLock()
+	store := m.store
+	pems := make(map[string]string, len(m.certs))
+	for serial, cert := range m.certs {
+		pems[serial] = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+	m.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+	data, err := json.Marshal(pems)
+	if err != nil {
+		return err
+	}
+	return store.Set(ctx, m.storeKey(), data)
+}
+
+type certificatesResponse struct {
+	Data []struct {
+		SerialNo           string     `json:"serial_no"`
+		EffectiveTime      string     `json:"effective_time"`
+		ExpireTime         string     `json:"expire_time"`
+		EncryptCertificate V3Resource `json:"encrypt_certificate"`
+	} `json:"data"`
+}
+
+// APIv3 中使用APIv3密钥加密的数据，用于平台证书和回调通知
+type V3Resource struct {
+	Algorithm      string `json:"algorithm"`
+	Nonce          string `json:"nonce"`
+	AssociatedData string `json:"associated_data"`
+	Ciphertext     string `json:"ciphertext"`
+}
+
+// 从/v3/certificates下载平台证书，使用APIv3密钥解密，并用下载到的证书验证返回的签名
+func (m *PlatformCertManager) Refresh(ctx context.Context) error {
+	header, body, err := m.client.doRaw(ctx, http.MethodGet, "/v3/certificates", nil)
+	if err != nil {
+		return err
+	}
+	res := &certificatesResponse{}
+	if err := json.Unmarshal(body, res); err != nil {
+		return err
+	}
+
+	certs := make(map[string]*x509.Certificate, len(res.Data))
+	m.client.observeKeyUsage(CredentialApiV3Key, KeyFingerprint([]byte(m.client.apiV3Key)), "/v3/certificates")
+	for _, item := range res.Data {
+		e := item.EncryptCertificate
+		plain, err := DecryptAES256GCM(m.client.apiV3Key, e.AssociatedData, e.Nonce, e.Ciphertext)
+		if err != nil {
+			return err
+		}
+		cert, err := parseCertificate(plain)
+		if err != nil {
+			return err
+		}
+		certs[item.SerialNo] = cert
+	}
+
+	serial := header.Get("Wechatpay-Serial")
+	cert, ok := certs[serial]
+	if !ok {
+		return ErrCertNotFound
+	}
+	if err := verifyV3Signature(cert, header, body); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	for serial, cert := range certs {
+		m.certs[serial] = cert
+	}
+	// 删除已过期的证书
+	now := clockOrSystem(m.client.clock).Now()
+	for serial, cert := range m.certs {
+		if now.After(cert.NotAfter) {
+			delete(m.certs, serial)
+		}
+	}
+	m.lastRefresh = now
+	m.mu.Unlock()
+	return m.save(ctx)
+}
+
+// 按序列号获取平台证书。本地没有时先从store中读取，仍然没有时刷新一次，用于处理平台证书轮换
+func (m *PlatformCertManager) Get(ctx context.Context, serial string) (*x509.Certificate, error) {
+	if cert, ok := m.lookup(serial); ok {
+		return cert, nil
+	}
+	if err := m.load(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	cert, ok := m.certs[serial]
+	lastRefresh := m.lastRefresh
+	m.mu.RUnlock()
+	if ok {
+		return cert, nil
+	}
+
+	if clockOrSystem(m.client.clock).Now().Sub(lastRefresh) < minCertRefreshInterval {
+		return nil, ErrCertNotFound
+	}
+	if err := m.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	if cert, ok := m.lookup(serial); ok {
+		return cert, nil
+	}
+	return nil, ErrCertNotFound
+}
+
+func (m *PlatformCertManager) lookup(serial string) (*x509.Certificate, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cert, ok := m.certs[serial]
+	return cert, ok
+}
+
+// 添加平台证书，用于无法访问/v3/certificates的环境预置证书。serial为空时使用证书的序列号，已过期的证书返回错误
+func (m *PlatformCertManager) AddCertificate(serial string, cert *x509.Certificate) error {
+	if !clockOrSystem(m.client.clock).Now().Before(cert.NotAfter) {
+		return fmt.Errorf("platform certificate %X has expired", cert.SerialNumber)
+	}
+	if serial == "" {
+		serial = fmt.Sprintf("%X", cert.SerialNumber)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.certs[serial] = cert
+	return nil
+}
+
+// 添加PEM格式的平台证书，pemData可以包含多个证书，例如Export导出的数据
+func (m *PlatformCertManager) LoadPEM(pemData []byte) error {
+	certs, err := parseCertificates(pemData)
+	if err != nil {
+		return err
+	}
+	for _, cert := range certs {
+		if err := m.AddCertificate("", cert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 以PEM格式导出当前的平台证书，可以保存为文件，由其他实例使用LoadPEM或Verifier.AddPlatformCertPEM加载
+func (m *PlatformCertManager) Export() []byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	serials := make([]string, 0, len(m.certs))
+	for serial := range m.certs {
+		serials = append(serials, serial)
+	}
+	sort.Strings(serials)
+	var data []byte
+	for _, serial := range serials {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: m.certs[serial].Raw})...)
+	}
+	return data
+}
+
+// 返回最晚过期的平台证书，用于加密敏感字段
+func (m *PlatformCertManager) Newest() (string, *x509.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var (
+		newestSerial string
+		newest       *x509.Certificate
+	)
+	for serial, cert := range m.certs {
+		if newest == nil || cert.NotAfter.After(newest.NotAfter) {
+			newestSerial, newest = serial, cert
+		}
+	}
+	if newest == nil {
+		return "", nil, ErrCertNotFound
+	}
+	return newestSerial, newest, nil
+}
+
+// 按interval定时刷新平台证书，直到ctx取消。interval不大于0时为12小时，刷新失败时调用onError（可以为nil），可以用NewComponent包装以统一启动和停止
+func (m *PlatformCertManager) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	clock := clockOrSystem(m.client.clock)
+	if interval <= 0 {
+		interval = defaultCertRefreshInterval
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(interval):
+			if err := m.Refresh(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// 使用平台证书验证APIv3返回或回调的签名
+func (c *ClientV3) VerifySignature(ctx context.Context, header http.Header, body []byte) error {
+	if c.certs == nil {
+		return errors.New("platform certificate manager is not set")
+	}
+	cert, err := c.certs.Get(ctx, header.Get("Wechatpay-Serial"))
+	if err != nil {
+		return err
+	}
+	return verifyV3Signature(cert, header, body)
+}
+
+// 验签串为 时间戳\n随机串\n报文主体\n
+func verifyV3Signature(cert *x509.Certificate, header http.Header, body []byte) error {
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("platform certificate public key is not RSA")
+	}
+	signature, err := base64.StdEncoding.DecodeString(header.Get("Wechatpay-Signature"))
+	if err != nil {
+		return err
+	}
+	message := header.Get("Wechatpay-Timestamp") + "\n" + header.Get("Wechatpay-Nonce") + "\n" + string(body) + "\n"
+	hashed := sha256.Sum256([]byte(message))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], signature); err != nil {
+		return fmt.Errorf("invalid wechatpay signature: %v", err)
+	}
+	return nil
+}
+
+// 使用APIv3密钥解密AEAD_AES_256_GCM加密的数据，ciphertext为base64编码
+func DecryptAES256GCM(apiV3Key string, associatedData string, nonce string, ciphertext string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher([]byte(apiV3Key))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// nonce长度不对时gcm.Open会panic
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid AEAD_AES_256_GCM nonce length %d", len(nonce))
+	}
+	return gcm.Open(nil, []byte(nonce), data, []byte(associatedData))
+}
+
+// 解析PEM数据中的所有证书
+func parseCertificates(pemData []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("invalid certificate pem")
+	}
+	return certs, nil
+}
+
+func parseCertificate(pemData []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("invalid certificate pem")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
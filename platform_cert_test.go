@@ -0,0 +1,315 @@
+package wxpay
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testApiV3Key = "0123456789abcdef0123456789abcdef"
+
+// 模拟微信支付平台，使用平台私钥对返回签名
+type testPlatform struct {
+	key    *rsa.PrivateKey
+	serial string
+	cert   []byte // PEM
+}
+
+func newTestPlatform(t *testing.T, serial string) *testPlatform {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Tenpay.com Root CA"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testPlatform{key: key, serial: serial, cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (p *testPlatform) write(w http.ResponseWriter, body []byte) {
+	p.writeAt(w, body, time.Now(), nonceStr())
+}
+
+func (p *testPlatform) writeAt(w http.ResponseWriter, body []byte, now time.Time, nonce string) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	hashed := sha256.Sum256([]byte(timestamp + "\n" + nonce + "\n" + string(body) + "\n"))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, hashed[:])
+	w.Header().Set("Wechatpay-Serial", p.serial)
+	w.Header().Set("Wechatpay-Timestamp", timestamp)
+	w.Header().Set("Wechatpay-Nonce", nonce)
+	w.Header().Set("Wechatpay-Signature", base64.StdEncoding.EncodeToString(signature))
+	w.Write(body)
+}
+
+func (p *testPlatform) certificates(t *testing.T) []byte {
+	block, _ := aes.NewCipher([]byte(testApiV3Key))
+	gcm, _ := cipher.NewGCM(block)
+	nonce, aad := "27fd2a2e3f3b", "certificate"
+	ciphertext := gcm.Seal(nil, []byte(nonce), p.cert, []byte(aad))
+	body, err := json.Marshal(map[string]interface{}{"data": []interface{}{map[string]interface{}{
+		"serial_no": p.serial,
+		"encrypt_certificate": map[string]string{
+			"algorithm":       "AEAD_AES_256_GCM",
+			"nonce":           nonce,
+			"associated_data": aad,
+			"ciphertext":      base64.StdEncoding.EncodeToString(ciphertext),
+		},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestPlatformCertManager(t *testing.T) {
+	platform := newTestPlatform(t, "5157F09EFDC096DE15EBE81A47057A7232F1B8E1")
+	tamper := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3/certificates" {
+			platform.write(w, platform.certificates(t))
+			return
+		}
+		if tamper {
+			w.Header().Set("Wechatpay-Signature", base64.StdEncoding.EncodeToString([]byte("forged")))
+			w.Write([]byte(`{"refund_id":"50000000382019052709732678859"}`))
+			return
+		}
+		platform.write(w, []byte(`{"refund_id":"50000000382019052709732678859"}`))
+	}))
+	defer server.Close()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	client := NewClientV3("1900000001", "SERIAL", key, testApiV3Key)
+	client.baseUrl = server.URL
+	certs := NewPlatformCertManager(client)
+
+	// 本地没有证书时自动下载
+	refund, err := client.QueryRefund(context.Background(), "1217752501201407033233368018")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refund.RefundID != "50000000382019052709732678859" {
+		t.Error(refund)
+	}
+	if serial, _, err := certs.Newest(); err != nil || serial != platform.serial {
+		t.Error(serial, err)
+	}
+
+	tamper = true
+	if _, err := client.QueryRefund(context.Background(), "1217752501201407033233368018"); err == nil {
+		t.Error("expected signature error")
+	}
+}
+
+func TestClientV3_ParseNotify(t *testing.T) {
+	platform := newTestPlatform(t, "5157F09EFDC096DE15EBE81A47057A7232F1B8E1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		platform.write(w, platform.certificates(t))
+	}))
+	defer server.Close()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	client := NewClientV3("1900000001", "SERIAL", key, testApiV3Key)
+	client.baseUrl = server.URL
+	NewPlatformCertManager(client)
+
+	block, _ := aes.NewCipher([]byte(testApiV3Key))
+	gcm, _ := cipher.NewGCM(block)
+	plain := `{"out_trade_no":"1217752501201407033233368018","trade_state":"SUCCESS","amount":{"total":100}}`
+	ciphertext := gcm.Seal(nil, []byte("fdasflkja484"), []byte(plain), []byte("transaction"))
+	body, _ := json.Marshal(map[string]interface{}{
+		"id":         "EV-2018022511223320873",
+		"event_type": "TRANSACTION.SUCCESS",
+		"resource": map[string]string{
+			"algorithm":       "AEAD_AES_256_GCM",
+			"nonce":           "fdasflkja484",
+			"associated_data": "transaction",
+			"ciphertext":      base64.StdEncoding.EncodeToString(ciphertext),
+		},
+	})
+	w := httptest.NewRecorder()
+	platform.write(w, body)
+
+	transaction := &V3Transaction{}
+	notify, err := client.ParseNotify(context.Background(), w.Header(), body, transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notify.EventType != "TRANSACTION.SUCCESS" || transaction.TradeState != Success || transaction.Amount.Total != 100 {
+		t.Error(notify, transaction)
+	}
+
+	body[len(body)-2] = ' '
+	if _, err := client.ParseNotify(context.Background(), w.Header(), body, nil); err == nil {
+		t.Error("expected signature error")
+	}
+}
+
+func TestPlatformCertManager_Store(t *testing.T) {
+	platform := newTestPlatform(t, "5157F09EFDC096DE15EBE81A47057A7232F1B8E1")
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3/certificates" {
+			downloads++
+			platform.write(w, platform.certificates(t))
+			return
+		}
+		platform.write(w, []byte(`{}`))
+	}))
+	defer server.Close()
+
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	for i := 0; i < 2; i++ {
+		client := NewClientV3("1900000001", "SERIAL", key, testApiV3Key)
+		client.baseUrl = server.URL
+		NewPlatformCertManager(client).SetStore(store)
+		if _, err := client.QueryRefund(context.Background(), "1217752501201407033233368018"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if downloads != 1 {
+		t.Error("downloads", downloads)
+	}
+}
+
+func TestVerifier_ParseV3Notify(t *testing.T) {
+	platform := newTestPlatform(t, "5157F09EFDC096DE15EBE81A47057A7232F1B8E1")
+	verifier := NewVerifier("", testApiV3Key)
+	cert, _ := parseCertificate(platform.cert)
+	verifier.AddPlatformCert(platform.serial, cert)
+
+	block, _ := aes.NewCipher([]byte(testApiV3Key))
+	gcm, _ := cipher.NewGCM(block)
+	ciphertext := gcm.Seal(nil, []byte("fdasflkja484"), []byte(`{"out_refund_no":"1217752501201407033233368018"}`), []byte("refund"))
+	body, _ := json.Marshal(map[string]interface{}{
+		"event_type": "REFUND.SUCCESS",
+		"resource": map[string]string{
+			"algorithm":       "AEAD_AES_256_GCM",
+			"nonce":           "fdasflkja484",
+			"associated_data": "refund",
+			"ciphertext":      base64.StdEncoding.EncodeToString(ciphertext),
+		},
+	})
+	w := httptest.NewRecorder()
+	platform.write(w, body)
+
+	refund := &V3RefundNotify{}
+	if _, err := verifier.ParseV3Notify(w.Header(), body, refund); err != nil {
+		t.Fatal(err)
+	}
+	if refund.OutRefundNo != "1217752501201407033233368018" {
+		t.Error(refund)
+	}
+}
+
+func TestPlatformCertManager_RunDefaultInterval(t *testing.T) {
+	platform := newTestPlatform(t, "5157F09EFDC096DE15EBE81A47057A7232F1B8E1")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var refreshes int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshes++
+		if refreshes == 2 {
+			cancel()
+		}
+		platform.write(w, platform.certificates(t))
+	}))
+	defer server.Close()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	client := NewClientV3("1900000001", "SERIAL", key, testApiV3Key)
+	client.baseUrl = server.URL
+	start := time.Unix(1600000000, 0)
+	clock := &fakeClock{now: start}
+	client.SetClock(clock)
+	certs := NewPlatformCertManager(client)
+
+	// interval为0时每12小时刷新一次，而不是不停地下载证书
+	certs.Run(ctx, 0, nil)
+	if elapsed := clock.now.Sub(start); elapsed < 24*time.Hour || elapsed%(12*time.Hour) != 0 {
+		t.Error(elapsed, refreshes)
+	}
+}
+
+func TestDecryptAES256GCMInvalidNonce(t *testing.T) {
+	if _, err := DecryptAES256GCM(testApiV3Key, "", "", base64.StdEncoding.EncodeToString([]byte("ciphertext"))); err == nil {
+		t.Error("expected nonce error")
+	}
+}
+
+func TestPlatformCertManager_LoadPEMAndExport(t *testing.T) {
+	// 序列号与证书的SerialNumber一致，LoadPEM按证书的序列号保存
+	platform := newTestPlatform(t, "1")
+	var downloads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		platform.write(w, platform.certificates(t))
+	}))
+	defer server.Close()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	downloader := NewClientV3("1900000001", "SERIAL", key, testApiV3Key)
+	downloader.baseUrl = server.URL
+	downloaded := NewPlatformCertManager(downloader)
+	if err := downloaded.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 离线实例加载导出的证书后不需要下载
+	offline := NewClientV3("1900000001", "SERIAL", key, testApiV3Key)
+	offline.baseUrl = server.URL
+	certs := NewPlatformCertManager(offline)
+	if err := certs.LoadPEM(downloaded.Export()); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	platform.write(w, []byte(`{}`))
+	if err := offline.VerifySignature(context.Background(), w.Header(), []byte(`{}`)); err != nil {
+		t.Error(err)
+	}
+	if downloads != 1 {
+		t.Error("downloads", downloads)
+	}
+	verifier := NewVerifier("", testApiV3Key)
+	if err := verifier.AddPlatformCertPEM(certs.Export()); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.VerifySignature(w.Header(), []byte(`{}`)); err != nil {
+		t.Error(err)
+	}
+
+	// 已过期的证书不能预置
+	offline.SetClock(&fakeClock{now: time.Now().Add(48 * time.Hour)})
+	if err := NewPlatformCertManager(offline).LoadPEM(platform.cert); err == nil {
+		t.Error("expected expired certificate error")
+	}
+	if err := certs.LoadPEM([]byte("not a pem")); err == nil {
+		t.Error("expected pem error")
+	}
+}
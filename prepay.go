@@ -0,0 +1,107 @@
+package wxpay
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// prepay_id的有效期
+const PrepayIDValidity = 2 * time.Hour
+
+// 剩余有效期不足该时间时视为过期，避免用户拿到即将失效的prepay_id
+const prepayExpiryMargin = 5 * time.Minute
+
+// 统一下单得到的预支付交易会话
+type Prepay struct {
+	PrepayID       string
+	OutTradeNo     string    // 本次下单使用的商户订单号
+	BaseOutTradeNo string    // 首次下单使用的商户订单号
+	Attempt        int       // 重新下单的次数，首次下单为0
+	CreatedAt      time.Time // prepay_id的创建时间
+	params         Params    // 下单参数，不含签名字段
+}
+
+// prepay_id是否已经过期
+func (p *Prepay) Expired(now time.Time) bool {
+	return !now.Before(p.CreatedAt.Add(PrepayIDValidity - prepayExpiryMargin))
+}
+
+// 统一下单并记录prepay_id的创建时间
+func (c *Client) CreatePrepay(params Params) (*Prepay, Params, error) {
+	p := &Prepay{
+		OutTradeNo:     params.GetString("out_trade_no"),
+		BaseOutTradeNo: params.GetString("out_trade_no"),
+		params:         copyParams(params),
+	}
+	return c.issuePrepay(p)
+}
+
+// 商户订单号的最大长度
+const maxOutTradeNoLen = 32
+
+// prepay_id未过期时直接返回p；已过期时先关闭原订单，再以带序号后缀的新商户订单号重新下单。
+// 只有确认原订单已关闭后才会重新下单，避免同一笔交易存在两个可支付的订单
+func (c *Client) RenewPrepay(p *Prepay) (*Prepay, Params, error) {
+	if !p.Expired(c.getClock().Now()) {
+		return p, nil, nil
+	}
+
+	renewed := &Prepay{
+		BaseOutTradeNo: p.BaseOutTradeNo,
+		Attempt:        p.Attempt + 1,
+		params:         copyParams(p.params),
+	}
+	renewed.OutTradeNo = fmt.Sprintf("%s_%d", p.BaseOutTradeNo, renewed.Attempt)
+	if len(renewed.OutTradeNo) > maxOutTradeNoLen {
+		return nil, nil, fmt.Errorf("out_trade_no %s exceeds %d characters", renewed.OutTradeNo, maxOutTradeNoLen)
+	}
+
+	closeParams := make(Params)
+	closeParams.SetString("out_trade_no", p.OutTradeNo)
+	res, err := c.CloseOrder(closeParams)
+	if res.GetString("err_code") == "ORDERPAID" {
+		return nil, res, errors.New("order " + p.OutTradeNo + " is already paid")
+	}
+	if !orderClosed(res) {
+		if err != nil {
+			return nil, res, err
+		}
+		return nil, res, errors.New("close order " + p.OutTradeNo + " failed: " + res.GetString("return_msg") + res.GetString("err_code_des"))
+	}
+
+	renewed.params.SetString("out_trade_no", renewed.OutTradeNo)
+	return c.issuePrepay(renewed)
+}
+
+// 关单成功或订单已关闭
+func orderClosed(res Params) bool {
+	if res.GetString("return_code") != Success {
+		return false
+	}
+	return res.GetString("result_code") == Success || res.GetString("err_code") == "ORDERCLOSED"
+}
+
+func (c *Client) issuePrepay(p *Prepay) (*Prepay, Params, error) {
+	p.CreatedAt = c.getClock().Now()
+	res, err := c.UnifiedOrder(copyParams(p.params))
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.GetString("return_code") != Success || res.GetString("result_code") != Success {
+		return nil, res, errors.New("unified order failed: " + res.GetString("return_msg") + res.GetString("err_code_des"))
+	}
+	p.PrepayID = res.GetString("prepay_id")
+	return p, res, nil
+}
+
+// 复制参数，并去掉签名相关的字段，便于重新签名
+func copyParams(params Params) Params {
+	p := make(Params, len(params))
+	for k, v := range params {
+		p[k] = v
+	}
+	delete(p, "sign")
+	delete(p, "nonce_str")
+	return p
+}
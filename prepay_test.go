@@ -0,0 +1,89 @@
+package wxpay
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenewPrepay(t *testing.T) {
+	cases := []struct {
+		name    string
+		close   Params
+		renewed bool
+	}{
+		{"closed", Params{"return_code": Success, "result_code": Success}, true},
+		{"already closed", Params{"return_code": Success, "result_code": Fail, "err_code": "ORDERCLOSED"}, true},
+		{"paid", Params{"return_code": Success, "result_code": Fail, "err_code": "ORDERPAID"}, false},
+		{"system error", Params{"return_code": Success, "result_code": Fail, "err_code": "SYSTEMERROR"}, false},
+		{"return fail", Params{"return_code": Fail, "return_msg": "签名错误"}, false},
+	}
+	for _, tc := range cases {
+		client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+		clock := &fakeClock{now: time.Unix(1500000000, 0)}
+		client.SetClock(clock)
+		var orders []string
+		closeRes := tc.close
+		client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				body, _ := ioutil.ReadAll(r.Body)
+				res := make(Params)
+				if strings.HasSuffix(r.URL.Path, "/closeorder") {
+					for k, v := range closeRes {
+						res[k] = v
+					}
+				} else {
+					orders = append(orders, MustXmlToMap(string(body)).GetString("out_trade_no"))
+					res.SetString("return_code", Success).SetString("result_code", Success).
+						SetString("prepay_id", "wx201410272009395522657a690389285100")
+				}
+				if res.GetString("return_code") == Success {
+					res.SetString("sign", client.Sign(res))
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+					Header:     make(http.Header),
+				}, nil
+			})
+		})
+
+		params := make(Params)
+		params.SetString("body", "test").SetString("out_trade_no", "1409811653").SetInt64("total_fee", 1)
+		p, _, err := client.CreatePrepay(params)
+		if err != nil {
+			t.Fatal(tc.name, err)
+		}
+		clock.now = clock.now.Add(PrepayIDValidity)
+		renewed, _, err := client.RenewPrepay(p)
+		if tc.renewed {
+			if err != nil || renewed.OutTradeNo != "1409811653_1" || len(orders) != 2 || orders[1] != "1409811653_1" {
+				t.Error(tc.name, renewed, err, orders)
+			}
+		} else if err == nil || len(orders) != 1 {
+			t.Error(tc.name, err, orders)
+		}
+	}
+}
+
+func TestRenewPrepayOutTradeNoTooLong(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	var calls int
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			calls++
+			return nil, http.ErrHandlerTimeout
+		})
+	})
+	p := &Prepay{
+		OutTradeNo:     "20170309123456789012345678901234",
+		BaseOutTradeNo: "20170309123456789012345678901234",
+		CreatedAt:      time.Unix(0, 0),
+		params:         make(Params),
+	}
+	if _, _, err := client.RenewPrepay(p); err == nil || calls != 0 {
+		t.Error(err, calls)
+	}
+}
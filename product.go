@@ -0,0 +1,40 @@
+package wxpay
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 需要单独开通的产品
+const (
+	ProductRefund        = "refund"         // 退款
+	ProductTransfer      = "transfer"       // 企业付款到零钱、银行卡
+	ProductRedPack       = "redpack"        // 现金红包
+	ProductProfitSharing = "profit_sharing" // 分账
+	ProductCoupon        = "coupon"         // 代金券
+	ProductPapay         = "papay"          // 委托代扣
+)
+
+// 调用了账号未声明开通的产品
+var ErrProductNotEnabled = errors.New("product is not enabled for the account")
+
+// 声明账号已开通的产品。声明后调用未声明产品的接口会直接返回ErrProductNotEnabled，
+// 不发送请求；未声明任何产品时不做检查
+func (a *Account) SetProducts(products ...string) {
+	a.products = make(map[string]bool, len(products))
+	for _, p := range products {
+		a.products[p] = true
+	}
+}
+
+// 账号是否可以使用product，product为空表示基础支付接口，始终可用
+func (a *Account) HasProduct(product string) bool {
+	return product == "" || a.products == nil || a.products[product]
+}
+
+func (a *Account) checkProduct(e Endpoint) error {
+	if a.HasProduct(e.Product) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s requires %s", ErrProductNotEnabled, e.Name, e.Product)
+}
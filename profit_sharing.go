@@ -0,0 +1,70 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+)
+
+// 检查必填参数，op用于错误信息
+func requireParams(op string, params Params, keys ...string) error {
+	for _, k := range keys {
+		if params.GetString(k) == "" {
+			return errors.New(op + ": " + k + " is required")
+		}
+	}
+	return nil
+}
+
+// 请求单次分账，params中需要transaction_id、out_order_no、receivers，
+// receivers可以使用EncodeProfitSharingReceivers生成。分账完成后剩余资金自动解冻给本商户。
+// 分账接口固定使用HMAC-SHA256签名，与客户端设置的签名类型无关
+func (c *Client) ProfitSharing(params Params) (Params, error) {
+	return c.ProfitSharingContext(context.Background(), params)
+}
+
+// 请求单次分账，ctx可用于取消请求
+func (c *Client) ProfitSharingContext(ctx context.Context, params Params) (Params, error) {
+	if err := requireParams("profitsharing", params, "transaction_id", "out_order_no", "receivers"); err != nil {
+		return nil, err
+	}
+	return c.InvokeContext(ctx, "ProfitSharing", params)
+}
+
+// 请求多次分账，参数同ProfitSharing，分账后剩余资金需要调用ProfitSharingFinish解冻
+func (c *Client) MultiProfitSharing(params Params) (Params, error) {
+	return c.MultiProfitSharingContext(context.Background(), params)
+}
+
+// 请求多次分账，ctx可用于取消请求
+func (c *Client) MultiProfitSharingContext(ctx context.Context, params Params) (Params, error) {
+	if err := requireParams("multiprofitsharing", params, "transaction_id", "out_order_no", "receivers"); err != nil {
+		return nil, err
+	}
+	return c.InvokeContext(ctx, "MultiProfitSharing", params)
+}
+
+// 查询分账结果，params中需要transaction_id、out_order_no
+func (c *Client) ProfitSharingQuery(params Params) (Params, error) {
+	return c.ProfitSharingQueryContext(context.Background(), params)
+}
+
+// 查询分账结果，ctx可用于取消请求
+func (c *Client) ProfitSharingQueryContext(ctx context.Context, params Params) (Params, error) {
+	if err := requireParams("profitsharingquery", params, "transaction_id", "out_order_no"); err != nil {
+		return nil, err
+	}
+	return c.InvokeContext(ctx, "ProfitSharingQuery", params)
+}
+
+// 完结分账，解冻剩余资金，params中需要transaction_id、out_order_no、amount、description
+func (c *Client) ProfitSharingFinish(params Params) (Params, error) {
+	return c.ProfitSharingFinishContext(context.Background(), params)
+}
+
+// 完结分账，ctx可用于取消请求
+func (c *Client) ProfitSharingFinishContext(ctx context.Context, params Params) (Params, error) {
+	if err := requireParams("profitsharingfinish", params, "transaction_id", "out_order_no", "amount", "description"); err != nil {
+		return nil, err
+	}
+	return c.InvokeContext(ctx, "ProfitSharingFinish", params)
+}
@@ -0,0 +1,143 @@
+package wxpay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// 分账接收方类型
+const (
+	ReceiverTypeMerchantID        = "MERCHANT_ID"         // 商户号
+	ReceiverTypePersonalOpenID    = "PERSONAL_OPENID"     // 个人openid，由服务商的appid转换得到
+	ReceiverTypePersonalSubOpenID = "PERSONAL_SUB_OPENID" // 个人sub_openid，由子商户的appid转换得到
+)
+
+// 与分账方的关系类型
+const (
+	RelationServiceProvider = "SERVICE_PROVIDER"
+	RelationStore           = "STORE"
+	RelationStaff           = "STAFF"
+	RelationStoreOwner      = "STORE_OWNER"
+	RelationPartner         = "PARTNER"
+	RelationHeadquarter     = "HEADQUARTER"
+	RelationBrand           = "BRAND"
+	RelationDistributor     = "DISTRIBUTOR"
+	RelationUser            = "USER"
+	RelationSupplier        = "SUPPLIER"
+	RelationCustom          = "CUSTOM" // 自定义，需要填写CustomRelation
+)
+
+// 默认的最大分账比例，单位为万分之一
+const DefaultMaxProfitSharingRatio = 3000
+
+const (
+	maxCustomRelationLen = 10
+	maxReceiverDescLen   = 80
+)
+
+var relationTypes = map[string]bool{
+	RelationServiceProvider: true,
+	RelationStore:           true,
+	RelationStaff:           true,
+	RelationStoreOwner:      true,
+	RelationPartner:         true,
+	RelationHeadquarter:     true,
+	RelationBrand:           true,
+	RelationDistributor:     true,
+	RelationUser:            true,
+	RelationSupplier:        true,
+	RelationCustom:          true,
+}
+
+// 分账接收方，添加接收方和请求分账时编码为receiver或receivers字段的JSON
+type ProfitSharingReceiver struct {
+	Type           string `json:"type"`
+	Account        string `json:"account"`
+	Name           string `json:"name,omitempty"`
+	RelationType   string `json:"relation_type,omitempty"`
+	CustomRelation string `json:"custom_relation,omitempty"`
+	Amount         int64  `json:"amount,omitempty"`
+	Description    string `json:"description,omitempty"`
+}
+
+func (r *ProfitSharingReceiver) validateAccount(checkName bool) error {
+	if r.Account == "" {
+		return errors.New("receiver account is empty")
+	}
+	switch r.Type {
+	case ReceiverTypeMerchantID:
+		// 商户号类型必须填写商户全称
+		if r.Name == "" {
+			return fmt.Errorf("receiver %s: name is required for %s", r.Account, r.Type)
+		}
+	case ReceiverTypePersonalOpenID, ReceiverTypePersonalSubOpenID:
+		if checkName && r.Name == "" {
+			return fmt.Errorf("receiver %s: name is required when checking the real name", r.Account)
+		}
+	default:
+		return fmt.Errorf("receiver %s: invalid type %q", r.Account, r.Type)
+	}
+	return nil
+}
+
+// 校验添加分账接收方的参数
+func (r *ProfitSharingReceiver) ValidateForAdd() error {
+	if err := r.validateAccount(false); err != nil {
+		return err
+	}
+	if !relationTypes[r.RelationType] {
+		return fmt.Errorf("receiver %s: invalid relation_type %q", r.Account, r.RelationType)
+	}
+	if r.RelationType == RelationCustom {
+		if r.CustomRelation == "" {
+			return fmt.Errorf("receiver %s: custom_relation is required for %s", r.Account, RelationCustom)
+		}
+		if utf8.RuneCountInString(r.CustomRelation) > maxCustomRelationLen {
+			return fmt.Errorf("receiver %s: custom_relation exceeds %d characters", r.Account, maxCustomRelationLen)
+		}
+	}
+	return nil
+}
+
+// 校验请求分账的接收方。totalFee为订单金额，maxRatio为最大分账比例（万分之一），
+// 0表示使用DefaultMaxProfitSharingRatio；checkName表示是否校验个人接收方的姓名
+func ValidateProfitSharingReceivers(receivers []ProfitSharingReceiver, totalFee int64, maxRatio int64, checkName bool) error {
+	if len(receivers) == 0 {
+		return errors.New("no profit sharing receiver")
+	}
+	if maxRatio == 0 {
+		maxRatio = DefaultMaxProfitSharingRatio
+	}
+	var sum int64
+	for i := range receivers {
+		r := &receivers[i]
+		if err := r.validateAccount(checkName); err != nil {
+			return err
+		}
+		if r.Amount <= 0 {
+			return fmt.Errorf("receiver %s: amount must be positive", r.Account)
+		}
+		if r.Description == "" || utf8.RuneCountInString(r.Description) > maxReceiverDescLen {
+			return fmt.Errorf("receiver %s: description must be 1-%d characters", r.Account, maxReceiverDescLen)
+		}
+		sum += r.Amount
+	}
+	if sum*10000 > totalFee*maxRatio {
+		return fmt.Errorf("profit sharing amount %d exceeds %d.%02d%% of %d", sum, maxRatio/100, maxRatio%100, totalFee)
+	}
+	return nil
+}
+
+// 校验并编码请求分账的receivers字段
+func EncodeProfitSharingReceivers(receivers []ProfitSharingReceiver, totalFee int64, maxRatio int64, checkName bool) (string, error) {
+	if err := ValidateProfitSharingReceivers(receivers, totalFee, maxRatio, checkName); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(receivers)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,28 @@
+package wxpay
+
+import "testing"
+
+func TestValidateProfitSharingReceivers(t *testing.T) {
+	receivers := []ProfitSharingReceiver{
+		{Type: ReceiverTypeMerchantID, Account: "190001001", Name: "示例商户全称", Amount: 100, Description: "分到商户"},
+		{Type: ReceiverTypePersonalOpenID, Account: "86693952", Amount: 888, Description: "分到个人"},
+	}
+	if err := ValidateProfitSharingReceivers(receivers, 10000, 0, false); err != nil {
+		t.Error(err)
+	}
+	if err := ValidateProfitSharingReceivers(receivers, 10000, 0, true); err == nil {
+		t.Error("expected missing name error")
+	}
+	if err := ValidateProfitSharingReceivers(receivers, 3000, 0, false); err == nil {
+		t.Error("expected ratio error")
+	}
+
+	r := ProfitSharingReceiver{Type: ReceiverTypePersonalOpenID, Account: "86693952", RelationType: RelationCustom}
+	if err := r.ValidateForAdd(); err == nil {
+		t.Error("expected missing custom_relation error")
+	}
+	r.CustomRelation = "代理商"
+	if err := r.ValidateForAdd(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,45 @@
+package wxpay
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProfitSharingQueryUsesHMACSHA256(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(r.Body)
+			req := MustXmlToMap(string(body))
+			if req.GetString("sign_type") != HMACSHA256 || req.GetString("sign") != client.signWithType(req, HMACSHA256) {
+				t.Errorf("request not signed with HMAC-SHA256: %v", req)
+			}
+			if req.ContainsKey("appid") {
+				t.Error("profitsharingquery should not send appid")
+			}
+			params := make(Params)
+			params.SetString("return_code", Success).
+				SetString("result_code", Success).
+				SetString("status", "FINISHED")
+			params.SetString("sign", client.signWithType(params, HMACSHA256))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(params))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+
+	p, err := client.ProfitSharingQuery(Params{"transaction_id": "4208450740201411110007820472", "out_order_no": "P20150806125346"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GetString("status") != "FINISHED" {
+		t.Error(p)
+	}
+	if _, err := client.ProfitSharingQuery(Params{"transaction_id": "4208450740201411110007820472"}); err == nil {
+		t.Error("expected out_order_no error")
+	}
+}
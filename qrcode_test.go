@@ -0,0 +1,110 @@
+package wxpay
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestQRRemainder(t *testing.T) {
+	// 版本1-M的HELLO WORLD
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := qrRemainder(data, qrGenerator(10)); !bytes.Equal(got, want) {
+		t.Error(got)
+	}
+}
+
+func TestNewQRCode(t *testing.T) {
+	const codeURL = "weixin://wxpay/bizpayurl?pr=yBkkzQ5zz"
+	qr, err := newQRCode(codeURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qr.version != 3 || qr.size != 29 {
+		t.Fatal(qr.version, qr.size)
+	}
+
+	// 按格式信息取出掩码，反向读取码字并解码
+	var format int
+	for i := 14; i >= 9; i-- {
+		format = format<<1 | b2i(qr.modules[8][14-i])
+	}
+	format = format<<1 | b2i(qr.modules[8][7])
+	format = format<<1 | b2i(qr.modules[8][8])
+	format = format<<1 | b2i(qr.modules[7][8])
+	for i := 5; i >= 0; i-- {
+		format = format<<1 | b2i(qr.modules[i][8])
+	}
+	format ^= 0x5412
+	if format>>13 != 0 {
+		t.Fatalf("error correction level is not M: %015b", format)
+	}
+	qr.applyMask(format >> 10 & 7)
+
+	var codewords []byte
+	var cur byte
+	n := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = qr.size - 1 - vert
+				}
+				if qr.isFunction[y][x] {
+					continue
+				}
+				cur = cur<<1 | byte(b2i(qr.modules[y][x]))
+				if n++; n%8 == 0 {
+					codewords = append(codewords, cur)
+					cur = 0
+				}
+			}
+		}
+	}
+	if len(codewords) != 70 {
+		t.Fatal(len(codewords))
+	}
+	if !bytes.Equal(codewords[44:], qrRemainder(codewords[:44], qrGenerator(26))) {
+		t.Error("invalid error correction codewords")
+	}
+	if codewords[0]>>4 != 4 {
+		t.Fatal("not byte mode")
+	}
+	length := int(codewords[0]&0xF)<<4 | int(codewords[1]>>4)
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = codewords[i+1]<<4 | codewords[i+2]>>4
+	}
+	if string(text) != codeURL {
+		t.Error(string(text))
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestGenerateNativeQRCode(t *testing.T) {
+	data, err := GenerateNativeQRCode("weixin://wxpay/bizpayurl?pr=yBkkzQ5zz", 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 256 || b.Dy() != 256 {
+		t.Error(b)
+	}
+	if _, err := GenerateNativeQRCode("weixin://wxpay/bizpayurl?pr=yBkkzQ5zz", 20); err == nil {
+		t.Error("expected size error")
+	}
+}
@@ -0,0 +1,115 @@
+package wxpay
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 查询结果缓存
+type ResponseCache interface {
+	Get(key string) (Params, bool)
+	Set(key string, params Params, ttl time.Duration)
+}
+
+// 内存中的查询结果缓存
+type MemoryCache struct {
+	Clock Clock // 为nil时使用SystemClock
+
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	params  Params
+	expires time.Time
+}
+
+// 创建内存缓存
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (m *MemoryCache) Get(key string) (Params, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !clockOrSystem(m.Clock).Now().Before(entry.expires) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.params.Clone(), true
+}
+
+func (m *MemoryCache) Set(key string, params Params, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryCacheEntry{params: params.Clone(), expires: clockOrSystem(m.Clock).Now().Add(ttl)}
+}
+
+// 设置OrderQuery、RefundQuery的结果缓存，cache为nil表示不缓存。
+// 默认只缓存终态结果，cacheNonTerminal为true时也缓存NOTPAY、USERPAYING、PROCESSING等中间状态
+func (c *Client) SetQueryCache(cache ResponseCache, ttl time.Duration, cacheNonTerminal bool) {
+	c.queryCache = cache
+	c.queryCacheTTL = ttl
+	c.cacheNonTerminal = cacheNonTerminal
+}
+
+// 按查询条件生成缓存key，没有查询条件时返回空字符串。
+// 服务商模式下不同子商户的商户订单号可能相同，key中包含请求指定或账号默认的子商户
+func (c *Client) queryCacheKey(api string, params Params, fields ...string) string {
+	if c.queryCache == nil {
+		return ""
+	}
+	subAppID, subMchID := params.GetString("sub_appid"), params.GetString("sub_mch_id")
+	if subMchID == "" {
+		subAppID, subMchID = c.account.subAppID, c.account.subMchID
+	}
+	for _, f := range fields {
+		if v := params.GetString(f); v != "" {
+			return strings.Join([]string{api, c.account.appID, c.account.activeMchID(), subAppID, subMchID, f, v}, ":")
+		}
+	}
+	return ""
+}
+
+func (c *Client) cachedQuery(key string) (Params, bool) {
+	if key == "" {
+		return nil, false
+	}
+	return c.queryCache.Get(key)
+}
+
+func (c *Client) storeQuery(key string, params Params, terminal bool) {
+	if key == "" || params.GetString("return_code") != Success || params.GetString("result_code") != Success {
+		return
+	}
+	if terminal || c.cacheNonTerminal {
+		c.queryCache.Set(key, params, c.queryCacheTTL)
+	}
+}
+
+// 订单是否处于终态
+func orderQueryTerminal(params Params) bool {
+	switch params.GetString("trade_state") {
+	case "NOTPAY", "USERPAYING", "":
+		return false
+	}
+	return true
+}
+
+// 所有退款单是否都处于终态
+func refundQueryTerminal(params Params) bool {
+	count := int(params.GetInt64("refund_count"))
+	for i := 0; i < count; i++ {
+		switch params.GetString("refund_status_" + strconv.Itoa(i)) {
+		case "PROCESSING", "":
+			return false
+		}
+	}
+	return count > 0
+}
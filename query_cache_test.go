@@ -0,0 +1,53 @@
+package wxpay
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryCacheSubMerchant(t *testing.T) {
+	client := NewClient(NewAccount("wx8888888888888888", "1900000109", "xxxxx", false))
+	var calls int
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			calls++
+			body, _ := ioutil.ReadAll(r.Body)
+			req := MustXmlToMap(string(body))
+			res := make(Params)
+			res.SetString("return_code", Success).
+				SetString("result_code", Success).
+				SetString("trade_state", "SUCCESS").
+				SetString("sub_mch_id", req.GetString("sub_mch_id")).
+				SetString("out_trade_no", req.GetString("out_trade_no"))
+			res.SetString("sign", client.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+	client.SetQueryCache(NewMemoryCache(), time.Minute, false)
+
+	query := func(subMchID string) Params {
+		params := make(Params).SetSubMerchant("", subMchID)
+		params.SetString("out_trade_no", "1409811653")
+		p, err := client.OrderQuery(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	if p := query("1900000110"); p.GetString("sub_mch_id") != "1900000110" {
+		t.Error(p)
+	}
+	if p := query("1900000111"); p.GetString("sub_mch_id") != "1900000111" {
+		t.Error("sub merchants share a cache entry:", p)
+	}
+	if p := query("1900000110"); p.GetString("sub_mch_id") != "1900000110" || calls != 2 {
+		t.Error(calls, p)
+	}
+}
@@ -0,0 +1,126 @@
+package wxpay
+
+import (
+	"context"
+	"fmt"
+)
+
+// 红包使用场景，发放金额小于1元或大于200元时必须填写scene_id
+const (
+	RedPackSceneProduct1 = "PRODUCT_1" // 商品促销
+	RedPackSceneProduct2 = "PRODUCT_2" // 抽奖
+	RedPackSceneProduct3 = "PRODUCT_3" // 虚拟物品兑奖
+	RedPackSceneProduct4 = "PRODUCT_4" // 企业内部福利
+	RedPackSceneProduct5 = "PRODUCT_5" // 渠道分润
+	RedPackSceneProduct6 = "PRODUCT_6" // 保险回馈
+	RedPackSceneProduct7 = "PRODUCT_7" // 彩票派奖
+	RedPackSceneProduct8 = "PRODUCT_8" // 税务刮奖
+)
+
+const (
+	redPackMinAmount    = 100   // 不填写scene_id时单个红包的最小金额，单位为分
+	redPackMaxAmount    = 20000 // 不填写scene_id时单个红包的最大金额，单位为分
+	groupRedPackMinNum  = 3
+	groupRedPackMaxNum  = 20
+	redPackMchBillNoLen = 28
+)
+
+var redPackScenes = map[string]bool{
+	RedPackSceneProduct1: true,
+	RedPackSceneProduct2: true,
+	RedPackSceneProduct3: true,
+	RedPackSceneProduct4: true,
+	RedPackSceneProduct5: true,
+	RedPackSceneProduct6: true,
+	RedPackSceneProduct7: true,
+	RedPackSceneProduct8: true,
+}
+
+// 校验红包参数，group表示裂变红包，校验失败时返回说明具体原因的错误
+func validateRedPack(params Params, group bool) error {
+	for _, k := range []string{"mch_billno", "send_name", "re_openid", "total_amount", "total_num", "wishing", "act_name", "remark"} {
+		if params.GetString(k) == "" {
+			return fmt.Errorf("redpack: %s is required", k)
+		}
+	}
+	if n := len(params.GetString("mch_billno")); n > redPackMchBillNoLen {
+		return fmt.Errorf("redpack: mch_billno is %d characters, at most %d", n, redPackMchBillNoLen)
+	}
+	amount := params.GetInt64("total_amount")
+	num := params.GetInt64("total_num")
+	if amount <= 0 {
+		return fmt.Errorf("redpack: invalid total_amount %q", params.GetString("total_amount"))
+	}
+	if group {
+		if num < groupRedPackMinNum || num > groupRedPackMaxNum {
+			return fmt.Errorf("redpack: total_num of group redpack must be %d-%d, got %d", groupRedPackMinNum, groupRedPackMaxNum, num)
+		}
+	} else if num != 1 {
+		return fmt.Errorf("redpack: total_num must be 1, got %d", num)
+	}
+
+	scene := params.GetString("scene_id")
+	if scene != "" && !redPackScenes[scene] {
+		return fmt.Errorf("redpack: invalid scene_id %q", scene)
+	}
+	// 裂变红包按平均每个红包的金额判断
+	perPack := amount / num
+	if scene == "" && (amount < redPackMinAmount*num || perPack > redPackMaxAmount) {
+		return fmt.Errorf("redpack: scene_id is required when each redpack is less than %d or more than %d fen", redPackMinAmount, redPackMaxAmount)
+	}
+	return nil
+}
+
+// 发放普通红包
+func (c *Client) SendRedPack(params Params) (Params, error) {
+	return c.SendRedPackContext(context.Background(), params)
+}
+
+// 发放普通红包，ctx可用于取消请求
+func (c *Client) SendRedPackContext(ctx context.Context, params Params) (Params, error) {
+	if err := validateRedPack(params, false); err != nil {
+		return nil, err
+	}
+	return c.sendRedPack(ctx, "SendRedPack", params)
+}
+
+// 发放裂变红包，amt_type为空时使用ALL_RAND
+func (c *Client) SendGroupRedPack(params Params) (Params, error) {
+	return c.SendGroupRedPackContext(context.Background(), params)
+}
+
+// 发放裂变红包，ctx可用于取消请求
+func (c *Client) SendGroupRedPackContext(ctx context.Context, params Params) (Params, error) {
+	if !params.ContainsKey("amt_type") {
+		params.SetString("amt_type", "ALL_RAND")
+	}
+	if err := validateRedPack(params, true); err != nil {
+		return nil, err
+	}
+	return c.sendRedPack(ctx, "SendGroupRedPack", params)
+}
+
+func (c *Client) sendRedPack(ctx context.Context, name string, params Params) (Params, error) {
+	release, err := c.reservePayout(ProductRedPack, params.GetInt64("total_amount"))
+	if err != nil {
+		return nil, err
+	}
+	p, err := c.InvokeContext(ctx, name, params)
+	if payoutFailed(p) {
+		release()
+	}
+	return p, err
+}
+
+// 查询红包记录，bill_type为空时使用MCHT（按商户订单号查询）
+func (c *Client) GetHbInfo(params Params) (Params, error) {
+	return c.GetHbInfoContext(context.Background(), params)
+}
+
+// 查询红包记录，ctx可用于取消请求
+func (c *Client) GetHbInfoContext(ctx context.Context, params Params) (Params, error) {
+	if !params.ContainsKey("bill_type") {
+		params.SetString("bill_type", "MCHT")
+	}
+	return c.InvokeContext(ctx, "GetHbInfo", params)
+}
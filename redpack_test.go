@@ -0,0 +1,40 @@
+package wxpay
+
+import "testing"
+
+func TestValidateRedPack(t *testing.T) {
+	newParams := func(amount, num string) Params {
+		p := make(Params)
+		p.SetString("mch_billno", "10000098201411111234567890").
+			SetString("send_name", "天虹百货").
+			SetString("re_openid", "oxTWIuGaIt6gTKsQRLau2M0yL16E").
+			SetString("total_amount", amount).
+			SetString("total_num", num).
+			SetString("wishing", "感谢您参加猜灯谜活动").
+			SetString("act_name", "猜灯谜抢红包活动").
+			SetString("remark", "猜越多得越多")
+		return p
+	}
+
+	if err := validateRedPack(newParams("1000", "1"), false); err != nil {
+		t.Error(err)
+	}
+	if err := validateRedPack(newParams("1000", "2"), false); err == nil {
+		t.Error("expected total_num error")
+	}
+	// 超过200元需要scene_id
+	p := newParams("30000", "1")
+	if err := validateRedPack(p, false); err == nil {
+		t.Error("expected scene_id error")
+	}
+	p.SetString("scene_id", RedPackSceneProduct4)
+	if err := validateRedPack(p, false); err != nil {
+		t.Error(err)
+	}
+	if err := validateRedPack(newParams("200", "3"), true); err == nil {
+		t.Error("expected per-packet minimum error")
+	}
+	if err := validateRedPack(newParams("300", "3"), true); err != nil {
+		t.Error(err)
+	}
+}
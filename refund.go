@@ -0,0 +1,56 @@
+package wxpay
+
+import "fmt"
+
+// 退款资金来源，对应refund_account
+type RefundAccount string
+
+const (
+	// 未结算资金退款（默认使用未结算资金退款）
+	RefundSourceUnsettledFunds RefundAccount = "REFUND_SOURCE_UNSETTLED_FUNDS"
+	// 可用余额退款，未结算资金不足时使用
+	RefundSourceRechargeFunds RefundAccount = "REFUND_SOURCE_RECHARGE_FUNDS"
+)
+
+// refund_desc的最大长度（字节）
+const MaxRefundDescLen = 80
+
+// 退款选项
+type RefundOption func(params Params) error
+
+// 指定退款资金来源
+func WithRefundAccount(account RefundAccount) RefundOption {
+	return func(params Params) error {
+		if account != RefundSourceUnsettledFunds && account != RefundSourceRechargeFunds {
+			return fmt.Errorf("invalid refund_account %q", account)
+		}
+		params.SetString("refund_account", string(account))
+		return nil
+	}
+}
+
+// 指定退款原因
+func WithRefundDesc(desc string) RefundOption {
+	return func(params Params) error {
+		params.SetString("refund_desc", desc)
+		return nil
+	}
+}
+
+// 应用退款选项并校验refund_account、refund_desc
+func applyRefundOptions(params Params, opts []RefundOption) error {
+	for _, opt := range opts {
+		if err := opt(params); err != nil {
+			return err
+		}
+	}
+	if params.ContainsKey("refund_account") {
+		if err := WithRefundAccount(RefundAccount(params.GetString("refund_account")))(params); err != nil {
+			return err
+		}
+	}
+	if desc := params.GetString("refund_desc"); len(desc) > MaxRefundDescLen {
+		return fmt.Errorf("refund_desc is %d bytes, exceeds %d", len(desc), MaxRefundDescLen)
+	}
+	return nil
+}
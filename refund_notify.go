@@ -0,0 +1,97 @@
+package wxpay
+
+import (
+	"crypto/aes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+)
+
+// 解密退款结果通知，返回req_info中的退款信息
+// 退款结果通知没有签名，能够用apiKey正确解密即说明通知来自微信
+func (c *Client) DecryptRefundNotify(xmlStr string) (Params, error) {
+	return decryptRefundNotify(c.account.activeApiKey(), xmlStr)
+}
+
+func decryptRefundNotify(apiKey string, xmlStr string) (Params, error) {
+	params, err := XmlToMap(xmlStr)
+	if err != nil {
+		return nil, err
+	}
+	if params.GetString("return_code") != Success {
+		return nil, errors.New("refund notify return_code is not SUCCESS: " + params.GetString("return_msg"))
+	}
+	return decryptReqInfo(apiKey, params.GetString("req_info"))
+}
+
+// req_info为AES-256-ECB加密，密钥为apiKey的32位小写md5
+func decryptReqInfo(apiKey string, reqInfo string) (Params, error) {
+	data, err := base64.StdEncoding.DecodeString(reqInfo)
+	if err != nil {
+		return nil, err
+	}
+	sum := md5.Sum([]byte(apiKey))
+	block, err := aes.NewCipher([]byte(hex.EncodeToString(sum[:])))
+	if err != nil {
+		return nil, err
+	}
+	size := block.BlockSize()
+	if len(data) == 0 || len(data)%size != 0 {
+		return nil, errors.New("invalid req_info length")
+	}
+	plain := make([]byte, len(data))
+	for i := 0; i < len(data); i += size {
+		block.Decrypt(plain[i:i+size], data[i:i+size])
+	}
+	// PKCS7 填充
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > size {
+		return nil, errors.New("invalid req_info padding")
+	}
+	info, err := XmlToMap(string(plain[:len(plain)-pad]))
+	if err != nil {
+		return nil, err
+	}
+	delete(info, "root")
+	return info, nil
+}
+
+// 商户保存的退款记录，按商户退款单号查询
+type RefundStore interface {
+	FindRefund(outRefundNo string) (interface{}, error)
+}
+
+// 退款结果通知的处理器，实现了http.Handler。
+// 解密通知后通过Store查找对应的退款记录，再调用Callback；Callback返回nil时回复成功
+type RefundNotifyHandler struct {
+	Client   *Client
+	Store    RefundStore
+	Callback func(record interface{}, info Params) error
+}
+
+func (h *RefundNotifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var n Notifies
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.Write([]byte(n.NotOK(err.Error())))
+		return
+	}
+	info, err := h.Client.DecryptRefundNotify(string(body))
+	if err != nil {
+		w.Write([]byte(n.NotOK(err.Error())))
+		return
+	}
+	record, err := h.Store.FindRefund(info.GetString("out_refund_no"))
+	if err != nil {
+		w.Write([]byte(n.NotOK(err.Error())))
+		return
+	}
+	if err := h.Callback(record, info); err != nil {
+		w.Write([]byte(n.NotOK(err.Error())))
+		return
+	}
+	w.Write([]byte(n.OK()))
+}
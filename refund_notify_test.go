@@ -0,0 +1,36 @@
+package wxpay
+
+import (
+	"crypto/aes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func encryptReqInfo(apiKey string, plain string) string {
+	sum := md5.Sum([]byte(apiKey))
+	block, _ := aes.NewCipher([]byte(hex.EncodeToString(sum[:])))
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	data := []byte(plain)
+	for i := 0; i < pad; i++ {
+		data = append(data, byte(pad))
+	}
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += aes.BlockSize {
+		block.Encrypt(out[i:i+aes.BlockSize], data[i:i+aes.BlockSize])
+	}
+	return base64.StdEncoding.EncodeToString(out)
+}
+
+func TestClient_DecryptRefundNotify(t *testing.T) {
+	client := NewClient(NewAccount("xxxxx", "xxx", "xxxxx", false))
+	reqInfo := encryptReqInfo("xxxxx", "<root><out_refund_no><![CDATA[131811191610442717309]]></out_refund_no><refund_status><![CDATA[SUCCESS]]></refund_status></root>")
+	info, err := client.DecryptRefundNotify("<xml><return_code>SUCCESS</return_code><req_info><![CDATA[" + reqInfo + "]]></req_info></xml>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.GetString("out_refund_no") != "131811191610442717309" || info.GetString("refund_status") != Success {
+		t.Error(info)
+	}
+}
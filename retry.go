@@ -0,0 +1,88 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+// 重试策略，只作用于Endpoint.Idempotent为true、可以安全重复请求的接口
+type RetryPolicy struct {
+	MaxAttempts int           // 最多请求次数，包含首次请求，小于2表示不重试
+	Backoff     time.Duration // 第一次重试前的等待时间，之后每次翻倍，默认100ms
+	MaxBackoff  time.Duration // 等待时间的上限，0表示不限制
+	// 每次请求（包含容灾域名切换）的超时时间，0表示不单独限制。
+	// 单次请求超时而ctx未结束时继续重试，避免一次缓慢的请求用完整个ctx的时间
+	AttemptTimeout time.Duration
+	// 判断本次请求是否需要重试，res为处理后的返回数据，请求失败时为nil。为nil时使用DefaultRetryable
+	Retryable func(res Params, err error) bool
+}
+
+// 默认的重试判断：网络错误、连接被意外关闭以及返回SYSTEMERROR时重试，ctx取消或超时时不重试
+func DefaultRetryable(res Params, err error) bool {
+	if err == nil {
+		return res.GetString("err_code") == "SYSTEMERROR"
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// 设置幂等接口的重试策略，nil表示不重试。每次请求单独使用SetApiClassTimeout设置的超时时间，
+// 重复请求时重新生成nonce_str并签名
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// 第attempt次重试前的等待时间，attempt从1开始
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	if d <= 0 {
+		d = 100 * time.Millisecond
+	}
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// 按策略重复调用call，直到不需要重试、次数用完或ctx结束，返回最后一次的结果
+func (c *Client) withRetry(ctx context.Context, policy *RetryPolicy, call func(ctx context.Context) (Params, error)) (Params, error) {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	for attempt := 1; ; attempt++ {
+		res, timedOut, err := policy.attempt(ctx, call)
+		if attempt >= policy.MaxAttempts || !(timedOut || retryable(res, err)) {
+			return res, err
+		}
+		select {
+		case <-ctx.Done():
+			return res, err
+		case <-c.getClock().After(policy.backoff(attempt)):
+		}
+	}
+}
+
+// 使用AttemptTimeout调用一次call，timedOut表示单次请求超时而ctx未结束
+func (p *RetryPolicy) attempt(ctx context.Context, call func(ctx context.Context) (Params, error)) (res Params, timedOut bool, err error) {
+	if p.AttemptTimeout <= 0 {
+		res, err = call(ctx)
+		return res, false, err
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, p.AttemptTimeout)
+	defer cancel()
+	res, err = call(attemptCtx)
+	return res, err != nil && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil, err
+}
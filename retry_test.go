@@ -0,0 +1,103 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	client.SetClock(clock)
+	var calls int
+	nonces := make(map[string]bool)
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			calls++
+			body, _ := ioutil.ReadAll(r.Body)
+			nonces[MustXmlToMap(string(body)).GetString("nonce_str")] = true
+			if calls < 3 {
+				return nil, &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+			}
+			res := make(Params)
+			res.SetString("return_code", Success).
+				SetString("result_code", Success).
+				SetString("trade_state", "SUCCESS")
+			res.SetString("sign", client.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+	client.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, Backoff: time.Second})
+
+	params := make(Params)
+	params.SetString("out_trade_no", "1409811653")
+	p, err := client.OrderQuery(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || len(nonces) != 3 || p.GetString("trade_state") != "SUCCESS" {
+		t.Error(calls, nonces, p)
+	}
+	// 两次重试分别等待1s和2s
+	if waited := clock.now.Sub(time.Unix(1600000000, 0)); waited != 3*time.Second {
+		t.Error(waited)
+	}
+
+	// 非幂等接口不重试
+	calls = 0
+	params = make(Params)
+	params.SetString("out_trade_no", "1409811653").SetString("auth_code", "120061098828009406")
+	if _, err := client.MicroPay(params); err == nil || calls != 1 {
+		t.Error(calls, err)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := &RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := p.backoff(i + 1); got != want {
+			t.Errorf("attempt %d: got %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+func TestRetryAttemptTimeout(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	var calls int
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				<-r.Context().Done()
+				return nil, r.Context().Err()
+			}
+			res := make(Params)
+			res.SetString("return_code", Success).SetString("result_code", Success)
+			res.SetString("sign", client.Sign(res))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+	client.SetRetryPolicy(&RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond, AttemptTimeout: 50 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	params := make(Params)
+	params.SetString("out_trade_no", "1409811653")
+	if _, err := client.OrderQueryContext(ctx, params); err != nil || calls != 2 {
+		t.Error(calls, err)
+	}
+}
@@ -0,0 +1,67 @@
+package wxpay
+
+// 风控错误的建议处理方式
+type RiskAction int
+
+const (
+	RiskActionNone       RiskAction = iota
+	RiskActionRetryLater            // 稍后使用相同的商户订单号重试
+	RiskActionSkip                  // 跳过本笔付款，通常是收款用户或金额的问题
+	RiskActionPause                 // 暂停该商户号的付款，需要人工处理
+)
+
+func (a RiskAction) String() string {
+	switch a {
+	case RiskActionRetryLater:
+		return "retry_later"
+	case RiskActionSkip:
+		return "skip"
+	case RiskActionPause:
+		return "pause"
+	default:
+		return "none"
+	}
+}
+
+// 返回结果中的风控信息
+type RiskInfo struct {
+	ErrCode    string
+	ErrCodeDes string
+	Action     RiskAction
+	Reason     string
+}
+
+var riskErrCodes = map[string]struct {
+	action RiskAction
+	reason string
+}{
+	"FREQ_LIMIT":               {RiskActionRetryLater, "超过频率限制"},
+	"SYSTEMERROR":              {RiskActionRetryLater, "微信系统繁忙"},
+	"SEND_FAILED":              {RiskActionRetryLater, "付款失败，需使用原单号重试"},
+	"V2_ACCOUNT_SIMPLE_BAN":    {RiskActionSkip, "收款用户未实名"},
+	"NAME_MISMATCH":            {RiskActionSkip, "收款用户姓名校验不一致"},
+	"OPENID_ERROR":             {RiskActionSkip, "openid与appid不匹配"},
+	"RECV_ACCOUNT_NOT_ALLOWED": {RiskActionSkip, "收款账户不在收款账户列表"},
+	"AMOUNT_LIMIT":             {RiskActionSkip, "付款金额超出限制"},
+	"NO_AUTH":                  {RiskActionPause, "商户号没有付款权限或被风控拦截"},
+	"NOTENOUGH":                {RiskActionPause, "商户余额不足"},
+	"MONEY_LIMIT":              {RiskActionPause, "已达到付款给此用户或当日的额度上限"},
+	"SENDNUM_LIMIT":            {RiskActionPause, "付款次数超过限制"},
+	"PAY_CHANNEL_NOT_ALLOWED":  {RiskActionPause, "本商户号未配置API发起能力"},
+	"CA_ERROR":                 {RiskActionPause, "商户证书校验错误"},
+}
+
+// 从返回结果中提取风控信息，非风控类错误返回nil
+func RiskInfoOf(params Params) *RiskInfo {
+	errCode := params.GetString("err_code")
+	risk, ok := riskErrCodes[errCode]
+	if !ok {
+		return nil
+	}
+	return &RiskInfo{
+		ErrCode:    errCode,
+		ErrCodeDes: params.GetString("err_code_des"),
+		Action:     risk.action,
+		Reason:     risk.reason,
+	}
+}
@@ -0,0 +1,103 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 调用getsignkey获取仿真测试环境的签名密钥，同时根据返回的Date头计算时钟偏差
+func (c *Client) getSignKey(ctx context.Context) (string, time.Duration, error) {
+	params := make(Params)
+	params.SetString("mch_id", c.account.mchID).
+		SetString("nonce_str", nonceStr())
+	params.SetString(Sign, c.signWithKey(params, MD5, c.account.apiKey))
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, SandboxGetSignKeyUrl, strings.NewReader(MustMapToXml(params)))
+	if err != nil {
+		return "", 0, err
+	}
+	request.Header.Set("Content-Type", bodyType)
+	h := &http.Client{Transport: c.plainTransport(), Timeout: c.timeoutFor(SandboxGetSignKeyUrl)}
+	clock := c.getClock()
+	start := clock.Now()
+	response, err := h.Do(request)
+	if err != nil {
+		return "", 0, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var skew time.Duration
+	if date, err := http.ParseTime(response.Header.Get("Date")); err == nil {
+		local := start.Add(clock.Now().Sub(start) / 2)
+		skew = local.Sub(date).Truncate(time.Second)
+	}
+
+	res, err := XmlToMap(string(body))
+	if err != nil {
+		return "", skew, err
+	}
+	if res.GetString("return_code") != Success {
+		return "", skew, fmt.Errorf("getsignkey failed: %s", res.GetString("return_msg"))
+	}
+	return res.GetString("sandbox_signkey"), skew, nil
+}
+
+func sandboxSignKeyStoreKey(mchID string) string {
+	return "wxpay/sandbox_signkey/" + mchID
+}
+
+// 获取仿真测试环境的签名密钥并设置到账号上。store不为nil时优先使用store中保存的密钥，
+// 获取到新的密钥后保存到store，多个实例可以共享同一个密钥
+func (c *Client) LoadSandboxSignKey(ctx context.Context, store KVStore) error {
+	mchID := c.account.mchID
+	if store != nil {
+		key, err := store.Get(ctx, sandboxSignKeyStoreKey(mchID))
+		if err == nil && len(key) > 0 {
+			c.account.SetSandboxCredentials(mchID, string(key))
+			return nil
+		}
+		if err != nil && !errors.Is(err, ErrKeyNotFound) {
+			return err
+		}
+	}
+
+	key, _, err := c.getSignKey(ctx)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return errors.New("getsignkey returned empty sandbox_signkey")
+	}
+	c.account.SetSandboxCredentials(mchID, key)
+	if store != nil {
+		return store.Set(ctx, sandboxSignKeyStoreKey(mchID), []byte(key))
+	}
+	return nil
+}
+
+// 设置自动获取的仿真测试密钥的存储，多个实例可以共享同一个密钥，nil表示只缓存在账号上
+func (c *Client) SetSandboxSignKeyStore(store KVStore) {
+	c.sandboxKeyStore = store
+}
+
+// 仿真测试环境下未设置密钥时自动获取，之后缓存在账号上
+func (c *Client) ensureSandboxSignKey(ctx context.Context) error {
+	if !c.account.isSandbox {
+		return nil
+	}
+	c.sandboxKeyMu.Lock()
+	defer c.sandboxKeyMu.Unlock()
+	if c.account.sandboxApiKey != "" {
+		return nil
+	}
+	return c.LoadSandboxSignKey(ctx, c.sandboxKeyStore)
+}
@@ -0,0 +1,50 @@
+package wxpay
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSandboxSignKeyAutoFetch(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", true))
+	fetches := 0
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(r.Body)
+			req := MustXmlToMap(string(body))
+			res := make(Params)
+			res.SetString("return_code", Success)
+			if r.URL.String() == SandboxGetSignKeyUrl {
+				fetches++
+				res.SetString("sandbox_signkey", "013467007045764")
+			} else {
+				if req.GetString("sign") != signParams(req, MD5, "013467007045764") {
+					t.Error("request not signed with sandbox key")
+				}
+				res.SetString("result_code", Success).
+					SetString("trade_state", "SUCCESS")
+				res.SetString("sign", signParams(res, MD5, "013467007045764"))
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(res))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+
+	for i := 0; i < 2; i++ {
+		p, err := client.OrderQuery(Params{"out_trade_no": "1409811653"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.GetString("trade_state") != "SUCCESS" {
+			t.Error(p)
+		}
+	}
+	if fetches != 1 {
+		t.Error("fetched sandbox signkey", fetches, "times")
+	}
+}
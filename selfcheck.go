@@ -0,0 +1,87 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 可接受的本地时钟与微信服务器时钟之差
+const maxClockSkew = 5 * time.Minute
+
+// 单项检查结果
+type SelfCheckItem struct {
+	Name    string
+	OK      bool
+	Message string
+}
+
+// 启动自检报告
+type SelfCheckReport struct {
+	Items     []SelfCheckItem
+	ClockSkew time.Duration // 本地时钟减去微信服务器时钟
+}
+
+// 所有检查项是否都通过
+func (r *SelfCheckReport) OK() bool {
+	for _, item := range r.Items {
+		if !item.OK {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *SelfCheckReport) add(name string, err error) {
+	item := SelfCheckItem{Name: name, OK: err == nil}
+	if err != nil {
+		item.Message = err.Error()
+	}
+	r.Items = append(r.Items, item)
+}
+
+// 检查证书能否解析、证书是否属于商户号、apiKey是否正确以及本地时钟偏差。
+// apiKey通过仿真测试环境的getsignkey接口校验，不会产生交易
+func (c *Client) SelfCheck(ctx context.Context) *SelfCheckReport {
+	report := &SelfCheckReport{}
+
+	if c.account.cert == nil {
+		report.add("cert", errors.New("证书数据为空"))
+	} else {
+		report.add("cert", c.checkCert())
+	}
+
+	skew, err := c.checkApiKey(ctx)
+	report.add("apiKey", err)
+	report.ClockSkew = skew
+	if err == nil || skew != 0 {
+		if skew > maxClockSkew || skew < -maxClockSkew {
+			report.add("clock", fmt.Errorf("clock skew %s exceeds %s", skew, maxClockSkew))
+		} else {
+			report.add("clock", nil)
+		}
+	}
+	return report
+}
+
+func (c *Client) checkCert() error {
+	x509Cert, err := x509.ParseCertificate(c.account.cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	if x509Cert.Subject.CommonName != c.account.mchID {
+		return fmt.Errorf("cert subject %q does not match mch_id %q", x509Cert.Subject.CommonName, c.account.mchID)
+	}
+	if c.getClock().Now().After(x509Cert.NotAfter) {
+		return fmt.Errorf("cert expired at %s", x509Cert.NotAfter)
+	}
+	return nil
+}
+
+// 调用getsignkey校验apiKey，同时返回时钟偏差
+func (c *Client) checkApiKey(ctx context.Context) (time.Duration, error) {
+	_, skew, err := c.getSignKey(ctx)
+	return skew, err
+}
@@ -0,0 +1,202 @@
+package wxpay
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// 通过Service暴露的接口，key为请求路径中的接口名
+var serviceOperations = map[string]func(c *Client, ctx context.Context, params Params) (Params, error){
+	"unifiedorder":     (*Client).UnifiedOrderContext,
+	"micropay":         (*Client).MicroPayContext,
+	"orderquery":       (*Client).OrderQueryContext,
+	"reverse":          (*Client).ReverseContext,
+	"closeorder":       (*Client).CloseOrderContext,
+	"refundquery":      (*Client).RefundQueryContext,
+	"downloadbill":     (*Client).DownloadBillContext,
+	"report":           (*Client).ReportContext,
+	"shorturl":         (*Client).ShortUrlContext,
+	"authcodetoopenid": (*Client).AuthCodeToOpenidContext,
+	"refund": func(c *Client, ctx context.Context, params Params) (Params, error) {
+		return c.RefundContext(ctx, params)
+	},
+}
+
+// 将Client的接口暴露为JSON服务，实现了http.Handler。
+// 只有部署Service的机器持有商户密钥和证书，其他服务通过RemoteClient调用。
+// 请求为 POST /{接口名}，请求体和返回体均为Params的JSON；出错时返回 {"error": "..."}
+type Service struct {
+	Client *Client
+}
+
+// 创建Service
+func NewService(client *Client) *Service {
+	return &Service{Client: client}
+}
+
+type serviceError struct {
+	Error string `json:"error"`
+}
+
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		writeServiceError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	op, ok := serviceOperations[strings.Trim(r.URL.Path, "/")]
+	if !ok {
+		writeServiceError(w, http.StatusNotFound, errors.New("unknown operation "+r.URL.Path))
+		return
+	}
+	params := make(Params)
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	res, err := op(s.Client, r.Context(), params)
+	if err != nil {
+		writeServiceError(w, http.StatusBadGateway, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func writeServiceError(w http.ResponseWriter, status int, err error) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(serviceError{Error: err.Error()})
+}
+
+// Service的客户端，不持有商户密钥
+type RemoteClient struct {
+	baseUrl    string
+	httpClient *http.Client
+}
+
+// 创建RemoteClient，baseUrl为Service的地址；httpClient为nil时使用http.DefaultClient
+func NewRemoteClient(baseUrl string, httpClient *http.Client) *RemoteClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &RemoteClient{baseUrl: strings.TrimRight(baseUrl, "/"), httpClient: httpClient}
+}
+
+// 调用Service上的接口，op为接口名，如orderquery
+func (rc *RemoteClient) Call(ctx context.Context, op string, params Params) (Params, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, rc.baseUrl+"/"+op, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := rc.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		var e serviceError
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			return nil, errors.New(e.Error)
+		}
+		return nil, fmt.Errorf("wxpay service: http status %d", response.StatusCode)
+	}
+	res := make(Params)
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// 统一下单
+func (rc *RemoteClient) UnifiedOrder(ctx context.Context, params Params) (Params, error) {
+	return rc.Call(ctx, "unifiedorder", params)
+}
+
+// 刷卡支付
+func (rc *RemoteClient) MicroPay(ctx context.Context, params Params) (Params, error) {
+	return rc.Call(ctx, "micropay", params)
+}
+
+// 订单查询
+func (rc *RemoteClient) OrderQuery(ctx context.Context, params Params) (Params, error) {
+	return rc.Call(ctx, "orderquery", params)
+}
+
+// 撤销订单
+func (rc *RemoteClient) Reverse(ctx context.Context, params Params) (Params, error) {
+	return rc.Call(ctx, "reverse", params)
+}
+
+// 关闭订单
+func (rc *RemoteClient) CloseOrder(ctx context.Context, params Params) (Params, error) {
+	return rc.Call(ctx, "closeorder", params)
+}
+
+// 退款
+func (rc *RemoteClient) Refund(ctx context.Context, params Params) (Params, error) {
+	return rc.Call(ctx, "refund", params)
+}
+
+// 退款查询
+func (rc *RemoteClient) RefundQuery(ctx context.Context, params Params) (Params, error) {
+	return rc.Call(ctx, "refundquery", params)
+}
+
+// 生成Service使用的双向TLS配置，要求调用方提供由caFile签发的证书
+func ServiceTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, pool, err := loadMutualTLS(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// 生成RemoteClient使用的双向TLS配置，只信任由caFile签发的Service证书
+func RemoteClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, pool, err := loadMutualTLS(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func loadMutualTLS(certFile, keyFile, caFile string) (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	caData, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return tls.Certificate{}, nil, errors.New("no certificate found in " + caFile)
+	}
+	return cert, pool, nil
+}
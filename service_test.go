@@ -0,0 +1,48 @@
+package wxpay
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestService(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	client.SetTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			params := make(Params)
+			params.SetString("return_code", Success).
+				SetString("result_code", Success).
+				SetString("trade_state", "SUCCESS")
+			params.SetString("sign", client.Sign(params))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(MustMapToXml(params))),
+				Header:     make(http.Header),
+			}, nil
+		})
+	})
+	server := httptest.NewServer(NewService(client))
+	defer server.Close()
+
+	remote := NewRemoteClient(server.URL, nil)
+	res, err := remote.OrderQuery(context.Background(), Params{"out_trade_no": "1409811653"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.GetString("trade_state") != "SUCCESS" {
+		t.Error(res)
+	}
+	if _, err := remote.Call(context.Background(), "transfers", Params{}); err == nil {
+		t.Error("expected unknown operation error")
+	}
+}
@@ -0,0 +1,119 @@
+package wxpay
+
+// 手续费在对账单中保留五位小数
+const serviceFeeDecimals = 5
+
+// 交易汇总的维度
+type SettlementKey struct {
+	Date      string // 交易日期，格式2006-01-02
+	TradeType string
+	FeeType   string
+}
+
+// 交易汇总金额
+type SettlementTotal struct {
+	Count              int
+	SettlementTotalFee Amount // 应结订单金额
+	CouponFee          Amount // 代金券金额
+	RefundFee          Amount // 退款金额
+	ServiceFee         Amount // 手续费，按五位小数累加后四舍五入
+	serviceFee         int64
+}
+
+// 按交易日期、交易类型、币种汇总对账单记录。
+// 手续费按原始精度累加，汇总完成后统一四舍五入到币种的最小单位，避免逐笔舍入造成的误差
+func SummarizeBill(records []BillRecord) (map[SettlementKey]*SettlementTotal, error) {
+	totals := make(map[SettlementKey]*SettlementTotal)
+	for i := range records {
+		r := &records[i]
+		feeType := r.FeeType
+		if feeType == "" {
+			feeType = CNY
+		}
+		key := SettlementKey{Date: r.TradeTime.In(beijingLocation).Format("2006-01-02"), TradeType: r.TradeType, FeeType: feeType}
+		t, ok := totals[key]
+		if !ok {
+			t = &SettlementTotal{
+				SettlementTotalFee: NewAmount(0, feeType),
+				CouponFee:          NewAmount(0, feeType),
+				RefundFee:          NewAmount(0, feeType),
+				ServiceFee:         NewAmount(0, feeType),
+			}
+			totals[key] = t
+		}
+		t.Count++
+
+		sums := []struct {
+			s   string
+			sum *Amount
+		}{
+			{r.SettlementTotalFee, &t.SettlementTotalFee},
+			{r.CouponFee, &t.CouponFee},
+			{r.RefundFee, &t.RefundFee},
+		}
+		for _, f := range sums {
+			if f.s == "" {
+				continue
+			}
+			a, err := ParseDecimalAmount(f.s, feeType)
+			if err != nil {
+				return nil, err
+			}
+			if *f.sum, err = f.sum.Add(a); err != nil {
+				return nil, err
+			}
+		}
+		if r.ServiceFee != "" {
+			fee, err := parseDecimal(r.ServiceFee, serviceFeeDecimals)
+			if err != nil {
+				return nil, err
+			}
+			t.serviceFee += fee
+		}
+	}
+	for _, t := range totals {
+		t.ServiceFee.Value = roundDecimal(t.serviceFee, serviceFeeDecimals, t.ServiceFee.decimals())
+	}
+	return totals, nil
+}
+
+// 资金流水汇总的维度
+type FundFlowKey struct {
+	Date    string // 记账日期，格式2006-01-02
+	BizType string
+}
+
+// 资金流水汇总金额，资金账单均为人民币
+type FundFlowTotal struct {
+	IncomeCount  int
+	Income       Amount
+	ExpenseCount int
+	Expense      Amount
+}
+
+// 按记账日期、业务类型汇总资金流水
+func SummarizeFundFlow(records []FundFlowRecord) (map[FundFlowKey]*FundFlowTotal, error) {
+	totals := make(map[FundFlowKey]*FundFlowTotal)
+	for i := range records {
+		r := &records[i]
+		key := FundFlowKey{Date: r.Time.In(beijingLocation).Format("2006-01-02"), BizType: r.BizType}
+		t, ok := totals[key]
+		if !ok {
+			t = &FundFlowTotal{Income: NewAmount(0, CNY), Expense: NewAmount(0, CNY)}
+			totals[key] = t
+		}
+		a, err := ParseDecimalAmount(r.Amount, CNY)
+		if err != nil {
+			return nil, err
+		}
+		switch r.Direction {
+		case FundFlowIncome:
+			t.IncomeCount++
+			t.Income.Value += a.Value
+		case FundFlowExpense:
+			t.ExpenseCount++
+			t.Expense.Value += a.Value
+		}
+	}
+	return totals, nil
+}
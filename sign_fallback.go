@@ -0,0 +1,84 @@
+package wxpay
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 签名类型诊断信息
+type SignDiagnostic struct {
+	SignType          string // 出现签名错误时使用的签名类型
+	ConsecutiveErrors int    // 连续签名错误的次数
+	FallbackApplied   bool   // 是否已自动改用MD5签名
+	Message           string
+}
+
+// HMAC-SHA256签名连续失败的检测，部分老商户号只支持MD5签名
+type signFallback struct {
+	threshold    int
+	autoFallback bool
+	onDiagnostic func(SignDiagnostic)
+
+	mu          sync.Mutex
+	consecutive int
+	active      int32 // 是否已改用MD5，原子读写
+}
+
+// 设置签名类型诊断：使用HMAC-SHA256时连续threshold次返回签名错误会调用onDiagnostic，
+// autoFallback为true时同时改用MD5签名。threshold小于1表示关闭检测
+func (c *Client) SetSignFallback(threshold int, autoFallback bool, onDiagnostic func(SignDiagnostic)) {
+	if threshold < 1 {
+		c.signFallback = nil
+		return
+	}
+	c.signFallback = &signFallback{threshold: threshold, autoFallback: autoFallback, onDiagnostic: onDiagnostic}
+}
+
+// 实际使用的签名类型
+func (c *Client) currentSignType() string {
+	if f := c.signFallback; f != nil && atomic.LoadInt32(&f.active) == 1 {
+		return MD5
+	}
+	return c.signType
+}
+
+func isSignError(params Params) bool {
+	return params.GetString("err_code") == "SIGNERROR" ||
+		(params.GetString("return_code") == Fail && strings.Contains(params.GetString("return_msg"), "签名错误"))
+}
+
+// 根据返回结果统计连续的签名错误，signType为请求使用的签名类型，固定使用MD5签名的接口不参与统计
+func (c *Client) observeSignResult(params Params, signType string) {
+	f := c.signFallback
+	if f == nil || signType != HMACSHA256 || c.currentSignType() != HMACSHA256 {
+		return
+	}
+	f.mu.Lock()
+	if !isSignError(params) {
+		f.consecutive = 0
+		f.mu.Unlock()
+		return
+	}
+	f.consecutive++
+	if f.consecutive < f.threshold {
+		f.mu.Unlock()
+		return
+	}
+	d := SignDiagnostic{
+		SignType:          HMACSHA256,
+		ConsecutiveErrors: f.consecutive,
+		Message:           fmt.Sprintf("%d consecutive sign errors with %s, the merchant account may only accept %s", f.consecutive, HMACSHA256, MD5),
+	}
+	f.consecutive = 0
+	if f.autoFallback {
+		atomic.StoreInt32(&f.active, 1)
+		d.FallbackApplied = true
+	}
+	f.mu.Unlock()
+
+	if f.onDiagnostic != nil {
+		f.onDiagnostic(d)
+	}
+}
@@ -0,0 +1,115 @@
+package wxpay
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// 回调验签结果缓存。微信会重复发送同一通知，缓存验签通过的通知体，在有效期内重复收到时不再验签。
+// 只缓存验签通过的结果，容量固定，超过容量时淘汰最早加入的通知
+type SignatureCache struct {
+	ttl   time.Duration
+	clock Clock
+
+	mu      sync.Mutex
+	expires map[[sha256.Size]byte]time.Time
+	keys    [][sha256.Size]byte // 按加入顺序循环使用
+	next    int
+	hits    int64
+	misses  int64
+}
+
+// 命中统计
+type SignatureCacheStats struct {
+	Hits   int64
+	Misses int64
+	Size   int
+}
+
+// 命中率，没有查询时为0
+func (s SignatureCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// 创建SignatureCache，最多缓存size个通知，每个通知缓存ttl
+func NewSignatureCache(size int, ttl time.Duration) *SignatureCache {
+	if size < 1 {
+		size = 1024
+	}
+	return &SignatureCache{
+		ttl:     ttl,
+		expires: make(map[[sha256.Size]byte]time.Time, size),
+		keys:    make([][sha256.Size]byte, 0, size),
+	}
+}
+
+// 设置缓存使用的时钟，nil表示使用SystemClock
+func (c *SignatureCache) SetClock(clock Clock) {
+	c.clock = clock
+}
+
+// 通知体是否在有效期内验签通过过
+func (c *SignatureCache) lookup(body string) bool {
+	key := sha256.Sum256([]byte(body))
+	now := clockOrSystem(c.clock).Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if expire, ok := c.expires[key]; ok && now.Before(expire) {
+		c.hits++
+		return true
+	}
+	c.misses++
+	return false
+}
+
+// 记录验签通过的通知体
+func (c *SignatureCache) add(body string) {
+	key := sha256.Sum256([]byte(body))
+	expire := clockOrSystem(c.clock).Now().Add(c.ttl)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.expires[key]; ok {
+		c.expires[key] = expire
+		return
+	}
+	if len(c.keys) < cap(c.keys) {
+		c.keys = append(c.keys, key)
+	} else {
+		delete(c.expires, c.keys[c.next])
+		c.keys[c.next] = key
+		c.next = (c.next + 1) % len(c.keys)
+	}
+	c.expires[key] = expire
+}
+
+// 返回命中统计
+func (c *SignatureCache) Stats() SignatureCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return SignatureCacheStats{Hits: c.hits, Misses: c.misses, Size: len(c.expires)}
+}
+
+// 设置回调验签结果缓存，nil表示不缓存。缓存只用于Notifier、NotifyHandler和ParseNotification
+func (c *Client) SetSignatureCache(cache *SignatureCache) {
+	c.signatureCache = cache
+}
+
+// 处理回调通知的XML，设置了SignatureCache时跳过重复通知的验签
+func (c *Client) processNotifyXml(xmlStr string) (Params, error) {
+	cache := c.signatureCache
+	if cache != nil && cache.lookup(xmlStr) {
+		return c.processResponseXml(xmlStr, false)
+	}
+	params, err := c.processResponseXml(xmlStr)
+	if params.GetString("return_code") == Success {
+		c.observeKeyUsage(CredentialApiKey, []byte(c.account.activeApiKey()), "notify")
+	}
+	if err == nil && cache != nil && params.GetString("return_code") == Success {
+		cache.add(xmlStr)
+	}
+	return params, err
+}
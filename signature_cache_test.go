@@ -0,0 +1,38 @@
+package wxpay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignatureCache(t *testing.T) {
+	client := NewClient(NewAccount("wx2421b1c4370ec43b", "10000100", "xxxxx", false))
+	cache := NewSignatureCache(1, time.Minute)
+	client.SetSignatureCache(cache)
+
+	params := make(Params)
+	params.SetString("return_code", Success).
+		SetString("result_code", Success).
+		SetString("out_trade_no", "1409811653")
+	xmlStr := client.generateSignedXml(params)
+	for i := 0; i < 2; i++ {
+		if _, err := client.ParseNotification(xmlStr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := cache.Stats(); s.Hits != 1 || s.Misses != 1 || s.HitRate() != 0.5 {
+		t.Error(s)
+	}
+
+	// 签名错误的通知不缓存
+	params.SetString("sign", "BAD")
+	bad := MustMapToXml(params)
+	for i := 0; i < 2; i++ {
+		if _, err := client.ParseNotification(bad); err == nil {
+			t.Error("expected sign error")
+		}
+	}
+	if s := cache.Stats(); s.Hits != 1 || s.Size != 1 {
+		t.Error(s)
+	}
+}
@@ -0,0 +1,68 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+)
+
+// 服务商模式下子商户的配置
+type SubMerchant struct {
+	SubMchID      string
+	SubAppID      string // 子商户的公众账号ID，可以为空
+	ProfitSharing bool   // 下单时默认设置profit_sharing=Y
+	NotifyUrl     string // 子商户的通知地址，下单、退款没有指定notify_url时使用，可以为空
+}
+
+// 按sub_mch_id查询子商户配置，服务商可以基于自己的数据库实现，子商户不存在时返回ErrKeyNotFound
+type SubMerchantStore interface {
+	SubMerchant(ctx context.Context, subMchID string) (*SubMerchant, error)
+}
+
+// 使用子商户配置填充的字段和接口
+var (
+	profitSharingUrls = map[string]bool{
+		UnifiedOrderUrl:        true,
+		SandboxUnifiedOrderUrl: true,
+		MicroPayUrl:            true,
+		SandboxMicroPayUrl:     true,
+	}
+	notifyUrls = map[string]bool{
+		UnifiedOrderUrl:        true,
+		SandboxUnifiedOrderUrl: true,
+		RefundUrl:              true,
+		SandboxRefundUrl:       true,
+	}
+)
+
+// 设置子商户配置，请求只指定了sub_mch_id时按配置填充sub_appid等字段，nil表示不使用
+func (c *Client) SetSubMerchantStore(store SubMerchantStore) {
+	c.subMerchantStore = store
+}
+
+// 按SubMerchantStore填充请求中没有的子商户字段，子商户不在store中时不填充
+func (c *Client) fillSubMerchantSettings(ctx context.Context, url string, params Params) error {
+	subMchID := params.GetString("sub_mch_id")
+	if c.subMerchantStore == nil || subMchID == "" {
+		return nil
+	}
+	sub, err := c.subMerchantStore.SubMerchant(ctx, subMchID)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	setIfAbsent := func(k string, v string) {
+		if v != "" && !params.ContainsKey(k) {
+			params.SetString(k, v)
+		}
+	}
+	setIfAbsent("sub_appid", sub.SubAppID)
+	if sub.ProfitSharing && profitSharingUrls[url] {
+		setIfAbsent("profit_sharing", "Y")
+	}
+	if notifyUrls[url] {
+		setIfAbsent("notify_url", sub.NotifyUrl)
+	}
+	return nil
+}
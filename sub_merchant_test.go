@@ -0,0 +1,57 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type subMerchantMap map[string]*SubMerchant
+
+func (m subMerchantMap) SubMerchant(ctx context.Context, subMchID string) (*SubMerchant, error) {
+	if subMchID == "1900000199" {
+		return nil, errors.New("database unavailable")
+	}
+	sub, ok := m[subMchID]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return sub, nil
+}
+
+func TestSubMerchantStore(t *testing.T) {
+	client := NewClient(NewAccount("wx8888888888888888", "1900000109", "xxxxx", false))
+	client.SetSubMerchantStore(subMerchantMap{
+		"1900000110": {SubMchID: "1900000110", SubAppID: "wx1111111111111111", ProfitSharing: true, NotifyUrl: "https://sub.example.com/notify"},
+	})
+	ctx := context.Background()
+
+	p, err := client.fillRequestData(ctx, UnifiedOrderUrl, make(Params).SetSubMerchant("", "1900000110"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GetString("sub_appid") != "wx1111111111111111" || p.GetString("profit_sharing") != "Y" || p.GetString("notify_url") != "https://sub.example.com/notify" {
+		t.Error(p)
+	}
+	if !client.ValidSign(p) {
+		t.Error("filled fields are not signed")
+	}
+
+	// 请求中已有的字段不被覆盖，只用于下单的字段不用于其他接口
+	params := make(Params).SetSubMerchant("wx2222222222222222", "1900000110")
+	params.SetString("notify_url", "https://example.com/notify")
+	if p, _ = client.fillRequestData(ctx, UnifiedOrderUrl, params); p.GetString("sub_appid") != "wx2222222222222222" || p.GetString("notify_url") != "https://example.com/notify" {
+		t.Error(p)
+	}
+	if p, _ = client.fillRequestData(ctx, OrderQueryUrl, make(Params).SetSubMerchant("", "1900000110")); p.ContainsKey("profit_sharing") || p.ContainsKey("notify_url") || p.GetString("sub_appid") != "wx1111111111111111" {
+		t.Error(p)
+	}
+
+	// 不在store中的子商户按原样发送，store出错时不发送请求
+	if p, err = client.fillRequestData(ctx, UnifiedOrderUrl, make(Params).SetSubMerchant("", "1900000111")); err != nil || p.ContainsKey("sub_appid") {
+		t.Error(p, err)
+	}
+	if _, err := client.UnifiedOrder(make(Params).SetSubMerchant("", "1900000199")); err == nil {
+		t.Error("expected store error")
+	}
+}
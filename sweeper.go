@@ -0,0 +1,64 @@
+package wxpay
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 提供已过期但未支付的订单
+type OrderSource interface {
+	// 返回在before之前过期且未支付的商户订单号
+	ExpiredUnpaidOrders(before time.Time) ([]string, error)
+}
+
+// 单个订单的关闭结果
+type CloseResult struct {
+	OutTradeNo string
+	Result     Params // CloseOrder的返回数据，result_code为FAIL且err_code为ORDERPAID时说明订单已支付
+	Err        error
+}
+
+// 过期订单清理器，对过期未支付的订单调用CloseOrder，避免本地过期后prepay_id仍被支付
+type OrderSweeper struct {
+	Client      *Client
+	Source      OrderSource
+	Concurrency int // 并发关闭的订单数，小于1时为1
+}
+
+// 关闭当前已过期的订单并返回每个订单的结果，ctx取消后不再关闭剩余订单
+func (s *OrderSweeper) Sweep(ctx context.Context) ([]CloseResult, error) {
+	orders, err := s.Source.ExpiredUnpaidOrders(s.Client.getClock().Now())
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := s.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]CloseResult, len(orders))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, outTradeNo := range orders {
+		results[i].OutTradeNo = outTradeNo
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(r *CloseResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			params := make(Params)
+			params.SetString("out_trade_no", r.OutTradeNo)
+			r.Result, r.Err = s.Client.CloseOrderContext(ctx, params)
+		}(&results[i])
+	}
+	wg.Wait()
+	return results, nil
+}
@@ -0,0 +1,96 @@
+package wxpay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// 门店信息，对应scene_info中的store_info
+type StoreInfo struct {
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+	AreaCode string `json:"area_code,omitempty"`
+	Address  string `json:"address,omitempty"`
+}
+
+// 收银终端配置
+type Terminal struct {
+	DeviceInfo string     // 设备号
+	Store      *StoreInfo // 终端所在门店
+	Params     Params     // 其他默认参数，例如spbill_create_ip
+}
+
+// 终端注册表，按终端ID保存终端配置，可并发使用
+type TerminalRegistry struct {
+	mu        sync.RWMutex
+	terminals map[string]Terminal
+}
+
+// 创建终端注册表
+func NewTerminalRegistry() *TerminalRegistry {
+	return &TerminalRegistry{terminals: make(map[string]Terminal)}
+}
+
+// 注册或更新终端
+func (r *TerminalRegistry) Register(terminalID string, t Terminal) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.terminals[terminalID] = t
+}
+
+// 删除终端
+func (r *TerminalRegistry) Remove(terminalID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.terminals, terminalID)
+}
+
+// 获取终端配置
+func (r *TerminalRegistry) Get(terminalID string) (Terminal, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.terminals[terminalID]
+	return t, ok
+}
+
+// 将终端的device_info、scene_info和默认参数填入params，params中已有的字段不会被覆盖
+func (r *TerminalRegistry) Apply(terminalID string, params Params) error {
+	t, ok := r.Get(terminalID)
+	if !ok {
+		return fmt.Errorf("unknown terminal %q", terminalID)
+	}
+	for k, v := range t.Params {
+		if !params.ContainsKey(k) {
+			params.SetString(k, v)
+		}
+	}
+	if t.DeviceInfo != "" && !params.ContainsKey("device_info") {
+		params.SetString("device_info", t.DeviceInfo)
+	}
+	if t.Store != nil && !params.ContainsKey("scene_info") {
+		data, err := json.Marshal(map[string]*StoreInfo{"store_info": t.Store})
+		if err != nil {
+			return err
+		}
+		params.SetString("scene_info", string(data))
+	}
+	return nil
+}
+
+// 设置终端注册表
+func (c *Client) SetTerminalRegistry(r *TerminalRegistry) {
+	c.terminals = r
+}
+
+// 使用注册表中的终端配置进行刷卡支付
+func (c *Client) MicroPayOnTerminal(terminalID string, params Params) (Params, error) {
+	if c.terminals == nil {
+		return nil, errors.New("terminal registry is not set")
+	}
+	if err := c.terminals.Apply(terminalID, params); err != nil {
+		return nil, err
+	}
+	return c.MicroPay(params)
+}
@@ -0,0 +1,49 @@
+package wxpay
+
+import "time"
+
+// 接口类型，不同类型的接口使用不同的超时时间
+type ApiClass int
+
+const (
+	ApiClassFast        ApiClass = iota // 普通接口，例如订单查询
+	ApiClassSlow                        // 返回数据量大的接口，例如下载对账单
+	ApiClassInteractive                 // 需要等待用户操作的接口，例如刷卡支付时用户输入密码
+)
+
+// 各类型接口的默认超时时间，ApiClassFast默认使用连接超时与读取超时之和
+var defaultApiClassTimeouts = map[ApiClass]time.Duration{
+	ApiClassSlow:        60 * time.Second,
+	ApiClassInteractive: 45 * time.Second,
+}
+
+var apiClasses = map[string]ApiClass{
+	MicroPayUrl:                ApiClassInteractive,
+	SandboxMicroPayUrl:         ApiClassInteractive,
+	DownloadBillUrl:            ApiClassSlow,
+	SandboxDownloadBillUrl:     ApiClassSlow,
+	DownloadFundFlowUrl:        ApiClassSlow,
+	SandboxDownloadFundFlowUrl: ApiClassSlow,
+}
+
+// 设置某类接口的超时时间，0表示恢复默认值。
+// 超时时间作用于单次http请求，与ctx的截止时间相互独立。超时后不会重试或切换容灾域名，
+// 需要时使用RetryPolicy.AttemptTimeout、DomainFailover.AttemptTimeout
+func (c *Client) SetApiClassTimeout(class ApiClass, timeout time.Duration) {
+	if c.apiClassTimeouts == nil {
+		c.apiClassTimeouts = make(map[ApiClass]time.Duration)
+	}
+	c.apiClassTimeouts[class] = timeout
+}
+
+// 请求url的超时时间
+func (c *Client) timeoutFor(url string) time.Duration {
+	class := apiClasses[url]
+	if d := c.apiClassTimeouts[class]; d > 0 {
+		return d
+	}
+	if d, ok := defaultApiClassTimeouts[class]; ok {
+		return d
+	}
+	return time.Duration(c.httpConnectTimeoutMs+c.httpReadTimeoutMs) * time.Millisecond
+}
@@ -0,0 +1,72 @@
+package wxpay
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid payment token")
+	ErrTokenExpired = errors.New("payment token expired")
+)
+
+// 支付令牌签发器，内部服务持有令牌即可请求生成指定订单号和金额的支付参数，无需持有apiKey
+type TokenSigner struct {
+	Secret []byte        // 签名密钥，不要使用apiKey
+	TTL    time.Duration // 令牌有效期
+	Clock  Clock         // 为nil时使用SystemClock
+}
+
+// 创建支付令牌签发器
+func NewTokenSigner(secret []byte, ttl time.Duration) *TokenSigner {
+	return &TokenSigner{Secret: secret, TTL: ttl}
+}
+
+// 签发绑定商户订单号和金额（分）的令牌
+func (s *TokenSigner) Mint(outTradeNo string, amount int64) string {
+	expires := clockOrSystem(s.Clock).Now().Add(s.TTL).Unix()
+	payload := strings.Join([]string{outTradeNo, strconv.FormatInt(amount, 10), strconv.FormatInt(expires, 10)}, "|")
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded))
+}
+
+// 校验令牌是否由本签发器签发、未过期且与商户订单号和金额一致
+func (s *TokenSigner) Verify(token string, outTradeNo string, amount int64) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return ErrInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, s.mac(parts[0])) {
+		return ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrInvalidToken
+	}
+	// 商户订单号中可能包含|，金额和过期时间从右侧取
+	fields := strings.Split(string(payload), "|")
+	n := len(fields)
+	if n < 3 || strings.Join(fields[:n-2], "|") != outTradeNo || fields[n-2] != strconv.FormatInt(amount, 10) {
+		return ErrInvalidToken
+	}
+	expires, err := strconv.ParseInt(fields[n-1], 10, 64)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if clockOrSystem(s.Clock).Now().Unix() >= expires {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+func (s *TokenSigner) mac(payload string) []byte {
+	h := hmac.New(sha256.New, s.Secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -0,0 +1,56 @@
+package wxpay
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.now = f.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- f.now
+	return ch
+}
+
+func TestTokenSigner(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	signer := NewTokenSigner([]byte("secret"), time.Minute)
+	signer.Clock = clock
+
+	token := signer.Mint("1409811653", 100)
+	if err := signer.Verify(token, "1409811653", 100); err != nil {
+		t.Error(err)
+	}
+	if err := signer.Verify(token, "1409811653", 101); err != ErrInvalidToken {
+		t.Error(err)
+	}
+	if err := NewTokenSigner([]byte("other"), time.Minute).Verify(token, "1409811653", 100); err != ErrInvalidToken {
+		t.Error(err)
+	}
+	clock.now = clock.now.Add(2 * time.Minute)
+	if err := signer.Verify(token, "1409811653", 100); err != ErrTokenExpired {
+		t.Error(err)
+	}
+}
+
+func TestTokenSignerPipeInOutTradeNo(t *testing.T) {
+	signer := NewTokenSigner([]byte("secret"), time.Minute)
+	token := signer.Mint("a|b", 100)
+	if err := signer.Verify(token, "a|b", 100); err != nil {
+		t.Error(err)
+	}
+	if err := signer.Verify(token, "a", 100); err != ErrInvalidToken {
+		t.Error(err)
+	}
+	if err := signer.Verify(signer.Mint("a", 100), "a|100", 100); err != ErrInvalidToken {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,53 @@
+package wxpay
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// 客户端复用的Transport，避免每次请求都新建连接。
+// 修改账号、DNS缓存或Transport包装函数后重新创建
+type transportCache struct {
+	mu      sync.Mutex
+	plain   http.RoundTripper
+	cert    http.RoundTripper
+	certFor *tls.Certificate // 创建cert时使用的证书，证书变化后重新创建
+}
+
+func (t *transportCache) reset() {
+	t.mu.Lock()
+	t.plain, t.cert, t.certFor = nil, nil, nil
+	t.mu.Unlock()
+}
+
+// 不使用证书的Transport
+func (c *Client) plainTransport() http.RoundTripper {
+	c.transports.mu.Lock()
+	defer c.transports.mu.Unlock()
+	if c.transports.plain == nil {
+		c.transports.plain = c.wrapTransport(c.newTransport(nil))
+	}
+	return c.transports.plain
+}
+
+// 使用商户证书的Transport
+func (c *Client) certTransport() (http.RoundTripper, error) {
+	cert := c.account.cert
+	if cert == nil {
+		return nil, errors.New("证书数据为空")
+	}
+	c.transports.mu.Lock()
+	defer c.transports.mu.Unlock()
+	if c.transports.cert != nil && c.transports.certFor == cert {
+		return c.transports.cert, nil
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{*cert},
+	}
+	c.transports.cert = c.wrapTransport(c.newTransport(config))
+	c.transports.certFor = cert
+	return c.transports.cert, nil
+}
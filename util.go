@@ -5,49 +5,74 @@ import (
 	"crypto/tls"
 	"encoding/pem"
 	"encoding/xml"
+	"fmt"
 	"golang.org/x/crypto/pkcs12"
-	"log"
+	"io"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
-func XmlToMap(xmlStr string) Params {
+// 微信支付接口中的时间均为北京时间
+var beijingLocation = time.FixedZone("CST", 8*60*60)
 
+// 解析XML为Params，XML格式错误时返回已解析的部分和错误
+func XmlToMap(xmlStr string) (Params, error) {
 	params := make(Params)
 	decoder := xml.NewDecoder(strings.NewReader(xmlStr))
 
-	var (
-		key   string
-		value string
-	)
-
-	for t, err := decoder.Token(); err == nil; t, err = decoder.Token() {
+	var key string
+	for {
+		t, err := decoder.Token()
+		if err == io.EOF {
+			return params, nil
+		}
+		if err != nil {
+			return params, err
+		}
 		switch token := t.(type) {
 		case xml.StartElement: // 开始标签
 			key = token.Name.Local
+			if key != "xml" {
+				params.SetString(key, "")
+			}
 		case xml.CharData: // 标签内容
-			content := string([]byte(token))
-			value = content
-		}
-		if key != "xml" {
-			if value != "\n" {
-				params.SetString(key, value)
+			if key != "" && key != "xml" {
+				params.SetString(key, params.GetString(key)+string(token))
 			}
+		case xml.EndElement: // 结束标签之后的空白不属于任何字段
+			key = ""
 		}
 	}
+}
 
+// 解析XML为Params，忽略格式错误，保留旧版XmlToMap的行为
+func MustXmlToMap(xmlStr string) Params {
+	params, _ := XmlToMap(xmlStr)
 	return params
 }
 
-func MapToXml(params Params) string {
+// 将Params转换为XML，字段名不是合法的XML标签名时返回错误
+func MapToXml(params Params) (string, error) {
+	for k := range params {
+		if !validXmlName(k) {
+			return "", fmt.Errorf("invalid xml field name %q", k)
+		}
+	}
+	return MustMapToXml(params), nil
+}
+
+// 将Params转换为XML，不校验字段名，保留旧版MapToXml的行为
+func MustMapToXml(params Params) string {
 	var buf bytes.Buffer
 	buf.WriteString(`<xml>`)
 	for k, v := range params {
 		buf.WriteString(`<`)
 		buf.WriteString(k)
 		buf.WriteString(`><![CDATA[`)
-		buf.WriteString(v)
+		// 内容中的]]>需要拆分到两个CDATA中
+		buf.WriteString(strings.Replace(v, "]]>", "]]]]><![CDATA[>", -1))
 		buf.WriteString(`]]></`)
 		buf.WriteString(k)
 		buf.WriteString(`>`)
@@ -57,25 +82,31 @@ func MapToXml(params Params) string {
 	return buf.String()
 }
 
+func validXmlName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // 用时间戳生成随机字符串
 func nonceStr() string {
 	return strconv.FormatInt(time.Now().UTC().UnixNano(), 10)
 }
 
-// 将Pkcs12转成Pem
-func pkcs12ToPem(p12 []byte, password string) tls.Certificate {
-
+// 解析Pkcs12证书，商户证书的密码为商户号
+func parsePkcs12(p12 []byte, password string) (tls.Certificate, error) {
 	blocks, err := pkcs12.ToPEM(p12, password)
-
-	// 从恐慌恢复
-	defer func() {
-		if x := recover(); x != nil {
-			log.Print(x)
-		}
-	}()
-
 	if err != nil {
-		panic(err)
+		return tls.Certificate{}, err
 	}
 
 	var pemData []byte
@@ -83,9 +114,5 @@ func pkcs12ToPem(p12 []byte, password string) tls.Certificate {
 		pemData = append(pemData, pem.EncodeToMemory(b)...)
 	}
 
-	cert, err := tls.X509KeyPair(pemData, pemData)
-	if err != nil {
-		panic(err)
-	}
-	return cert
+	return tls.X509KeyPair(pemData, pemData)
 }
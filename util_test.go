@@ -6,17 +6,32 @@ import (
 
 func TestXmlToMap(t *testing.T) {
 	xmlStr := "<xml><return_code><![CDATA[SUCCESS]]></return_code><return_msg><![CDATA[OK]]></return_msg><appid><![CDATA[wx2421b1c4370ec43b]]></appid><mch_id><![CDATA[10000100]]></mch_id><nonce_str><![CDATA[IITRi8Iabbblz1Jc]]></nonce_str><sign><![CDATA[7921E432F65EB8ED0CE9755F0E86D72F]]></sign><result_code><![CDATA[SUCCESS]]></result_code><prepay_id><![CDATA[wx201411101639507cbf6ffd8b0779950874]]></prepay_id><trade_type><![CDATA[APP]]></trade_type></xml>"
-	params := XmlToMap(xmlStr)
-	if params == nil {
+	params, err := XmlToMap(xmlStr)
+	if err != nil {
+		t.Error(err)
+	}
+	if params.GetString("prepay_id") != "wx201411101639507cbf6ffd8b0779950874" {
 		t.Error(params)
 	}
 	t.Log(params)
 }
 
+func TestXmlToMap_Invalid(t *testing.T) {
+	if _, err := XmlToMap("<xml><return_code>SUCCESS</xml>"); err == nil {
+		t.Error("expected error for malformed xml")
+	}
+}
+
 func TestMapToXml(t *testing.T) {
 	params := map[string]string{"return_msg": "OK", "appid": "wx2421b1c4370ec43b", "mch_id": "10000100"}
-	xmlStr := MapToXml(params)
+	xmlStr, err := MapToXml(params)
+	if err != nil {
+		t.Error(err)
+	}
 	t.Log(xmlStr)
+	if _, err := MapToXml(Params{"bad key": "v"}); err == nil {
+		t.Error("expected error for invalid field name")
+	}
 }
 
 func TestNonceStr(t *testing.T) {
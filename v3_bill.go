@@ -0,0 +1,90 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// APIv3 申请账单的返回，download_url有效期为5分钟
+type V3BillDownload struct {
+	HashType    string `json:"hash_type"`
+	HashValue   string `json:"hash_value"`
+	DownloadUrl string `json:"download_url"`
+}
+
+// 申请交易账单。billType为ALL、SUCCESS、REFUND，为空时为ALL；
+// 服务商可通过subMchID指定特约商户，为空时申请服务商自身的账单
+func (c *ClientV3) TradeBill(ctx context.Context, billDate string, subMchID string, billType string) (*V3BillDownload, error) {
+	query := url.Values{"bill_date": {billDate}}
+	if subMchID != "" {
+		query.Set("sub_mchid", subMchID)
+	}
+	if billType != "" {
+		query.Set("bill_type", billType)
+	}
+	return c.applyBill(ctx, "/v3/bill/tradebill?"+query.Encode())
+}
+
+// 申请分账账单，服务商可通过subMchID指定特约商户，为空时返回所有特约商户的分账账单
+func (c *ClientV3) ProfitSharingBill(ctx context.Context, billDate string, subMchID string) (*V3BillDownload, error) {
+	query := url.Values{"bill_date": {billDate}}
+	if subMchID != "" {
+		query.Set("sub_mchid", subMchID)
+	}
+	return c.applyBill(ctx, "/v3/profitsharing/bills?"+query.Encode())
+}
+
+func (c *ClientV3) applyBill(ctx context.Context, path string) (*V3BillDownload, error) {
+	res := &V3BillDownload{}
+	if err := c.Do(ctx, http.MethodGet, path, nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// 下载账单并校验摘要，返回账单原文。交易账单的格式与v2的对账单相同，可使用ParseBill解析，
+// 分账账单使用ParseProfitSharingBill解析。下载返回不带签名，完整性由hash_value保证
+func (c *ClientV3) DownloadBill(ctx context.Context, d *V3BillDownload) (string, error) {
+	data, err := c.download(ctx, d.DownloadUrl)
+	if err != nil {
+		return "", err
+	}
+	var h hash.Hash
+	switch strings.ToUpper(d.HashType) {
+	case "SHA1":
+		h = sha1.New()
+	case "SHA256":
+		h = sha256.New()
+	default:
+		return "", fmt.Errorf("unsupported bill hash_type: %s", d.HashType)
+	}
+	h.Write(data)
+	if !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), d.HashValue) {
+		return "", errors.New("bill hash_value mismatch")
+	}
+	return string(data), nil
+}
+
+// 解析后的分账账单
+type ProfitSharingBill struct {
+	Header  []string
+	Records []Params // 以表头为key
+	Summary Params
+}
+
+// 解析ClientV3.DownloadBill返回的分账账单
+func ParseProfitSharingBill(data string) (*ProfitSharingBill, error) {
+	header, rows, summary, err := parseBillTable(data)
+	if err != nil {
+		return nil, err
+	}
+	return &ProfitSharingBill{Header: header, Records: rows, Summary: summary}, nil
+}
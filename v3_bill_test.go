@@ -0,0 +1,104 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientV3_TradeBill(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha1.Sum([]byte(testBillData))
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), v3AuthSchema) {
+			t.Errorf("unsigned request %s", r.URL)
+		}
+		switch r.URL.Path {
+		case "/v3/bill/tradebill":
+			if r.URL.Query().Get("bill_date") != "2020-05-01" || r.URL.Query().Get("sub_mchid") != "20000001" {
+				t.Error(r.URL)
+			}
+			w.Write([]byte(`{"hash_type":"SHA1","hash_value":"` + hex.EncodeToString(sum[:]) + `","download_url":"` + server.URL + `/v3/billdownload/file?token=abc"}`))
+		case "/v3/billdownload/file":
+			w.Write([]byte(testBillData))
+		default:
+			t.Error(r.URL)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientV3("1900000001", "SERIAL", key, "")
+	client.baseUrl = server.URL
+	ctx := context.Background()
+	d, err := client.TradeBill(ctx, "2020-05-01", "20000001", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := client.DownloadBill(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bill, err := ParseBill(data)
+	if err != nil || len(bill.Records) != 2 {
+		t.Fatal(bill, err)
+	}
+
+	d.HashValue = strings.Repeat("0", 40)
+	if _, err := client.DownloadBill(ctx, d); err == nil {
+		t.Error("expected hash mismatch")
+	}
+}
+
+func TestClientV3_ProfitSharingBill(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const data = "分账发起时间,分账方,分账接收方,微信订单号,商户分账单号,分账金额（元）\r\n" +
+		"`2020-05-01 10:02:03,`20000001,`86693852,`4208450740201411110007820472,`P20150806125346,`0.01\r\n" +
+		"总条数,分账总金额（元）\r\n" +
+		"`1,`0.01\r\n"
+	sum := sha1.Sum([]byte(data))
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/profitsharing/bills":
+			if r.URL.Query().Get("sub_mchid") != "20000001" {
+				t.Error(r.URL)
+			}
+			w.Write([]byte(`{"hash_type":"SHA1","hash_value":"` + hex.EncodeToString(sum[:]) + `","download_url":"` + server.URL + `/v3/billdownload/file?token=def"}`))
+		case "/v3/billdownload/file":
+			w.Write([]byte(data))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientV3("1900000001", "SERIAL", key, "")
+	client.baseUrl = server.URL
+	ctx := context.Background()
+	d, err := client.ProfitSharingBill(ctx, "2020-05-01", "20000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := client.DownloadBill(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bill, err := ParseProfitSharingBill(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bill.Records) != 1 || bill.Records[0].GetString("分账接收方") != "86693852" || bill.Summary.GetString("总条数") != "1" {
+		t.Error(bill)
+	}
+}
@@ -0,0 +1,83 @@
+package wxpay
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// 券核销事件的event_type
+const EventCouponUse = "COUPON.USE"
+
+// 券核销事件，由核销回调通知的resource解密得到
+type CouponUseEvent struct {
+	StockCreatorMchID string `json:"stock_creator_mchid"`
+	StockID           string `json:"stock_id"`
+	CouponID          string `json:"coupon_id"`
+	CouponCode        string `json:"coupon_code"` // 商家券使用券code标识券
+	CouponName        string `json:"coupon_name"`
+	Status            string `json:"status"`
+	Description       string `json:"description"`
+	CreateTime        string `json:"create_time"`
+	CouponType        string `json:"coupon_type"`
+	NoCash            bool   `json:"no_cash"`
+	Singleitem        bool   `json:"singleitem"`
+	NormalCoupon      *struct {
+		CouponAmount       int64 `json:"coupon_amount"`
+		TransactionMinimum int64 `json:"transaction_minimum"`
+	} `json:"normal_coupon_information,omitempty"`
+	ConsumeInformation struct {
+		ConsumeTime   string `json:"consume_time"`
+		ConsumeMchID  string `json:"consume_mchid"`
+		TransactionID string `json:"transaction_id"`
+	} `json:"consume_information"`
+
+	Notify *V3Notify `json:"-"` // 通知本身，包含通知ID，可用于去重
+}
+
+// 券核销事件的消费者
+type CouponUseConsumer interface {
+	ConsumeCouponUse(e *CouponUseEvent) error
+}
+
+// 将函数包装为CouponUseConsumer
+type CouponUseConsumerFunc func(e *CouponUseEvent) error
+
+func (f CouponUseConsumerFunc) ConsumeCouponUse(e *CouponUseEvent) error {
+	return f(e)
+}
+
+// 券核销回调通知的处理器，实现了http.Handler。
+// 验证签名并解密resource后调用Consumer，Consumer失败时返回错误让微信重发通知。
+// event_type不是COUPON.USE的通知直接回复成功
+type CouponUseHandler struct {
+	Client   *ClientV3
+	Consumer CouponUseConsumer
+}
+
+func (h *CouponUseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	event := &CouponUseEvent{}
+	notify, err := h.Client.ParseNotifyRequest(r, event)
+	if err != nil {
+		writeV3NotifyReply(w, err)
+		return
+	}
+	if notify.EventType != EventCouponUse {
+		writeV3NotifyReply(w, nil)
+		return
+	}
+	event.Notify = notify
+	writeV3NotifyReply(w, h.Consumer.ConsumeCouponUse(event))
+}
+
+// 回复APIv3回调通知，err不为nil时回复失败，微信会稍后重发通知
+func writeV3NotifyReply(w http.ResponseWriter, err error) {
+	reply := map[string]string{"code": Success, "message": "成功"}
+	status := http.StatusOK
+	if err != nil {
+		reply = map[string]string{"code": Fail, "message": err.Error()}
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", v3BodyType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(reply)
+}
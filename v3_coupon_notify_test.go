@@ -0,0 +1,60 @@
+package wxpay
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCouponUseHandler(t *testing.T) {
+	platform := newTestPlatform(t, "5157F09EFDC096DE15EBE81A47057A7232F1B8E1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		platform.write(w, platform.certificates(t))
+	}))
+	defer server.Close()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	client := NewClientV3("1900000001", "SERIAL", key, testApiV3Key)
+	client.baseUrl = server.URL
+	NewPlatformCertManager(client)
+
+	block, _ := aes.NewCipher([]byte(testApiV3Key))
+	gcm, _ := cipher.NewGCM(block)
+	plain := `{"stock_id":"9856888","coupon_id":"98674556","status":"USED","consume_information":{"transaction_id":"4200000000382019052709732678859"}}`
+	ciphertext := gcm.Seal(nil, []byte("fdasflkja484"), []byte(plain), []byte("coupon"))
+	body, _ := json.Marshal(map[string]interface{}{
+		"id":         "EV-2018022511223320873",
+		"event_type": EventCouponUse,
+		"resource": map[string]string{
+			"algorithm":       "AEAD_AES_256_GCM",
+			"nonce":           "fdasflkja484",
+			"associated_data": "coupon",
+			"ciphertext":      base64.StdEncoding.EncodeToString(ciphertext),
+		},
+	})
+	signed := httptest.NewRecorder()
+	platform.write(signed, body)
+
+	var got *CouponUseEvent
+	handler := &CouponUseHandler{Client: client, Consumer: CouponUseConsumerFunc(func(e *CouponUseEvent) error {
+		got = e
+		return nil
+	})}
+	r := httptest.NewRequest(http.MethodPost, "/coupon", bytes.NewReader(body))
+	r.Header = signed.Header()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || got == nil || got.CouponID != "98674556" || got.ConsumeInformation.TransactionID != "4200000000382019052709732678859" {
+		t.Error(w.Code, w.Body.String(), got)
+	}
+	if got != nil && got.Notify.ID != "EV-2018022511223320873" {
+		t.Error(got.Notify)
+	}
+}
@@ -0,0 +1,55 @@
+package wxpay
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// APIv3 代金券批次
+type V3FavorStock struct {
+	StockID            string `json:"stock_id"`
+	StockCreatorMchID  string `json:"stock_creator_mchid"`
+	StockName          string `json:"stock_name"`
+	Status             string `json:"status"`
+	DistributedCoupons int64  `json:"distributed_coupons"`
+	StockUseRule       struct {
+		MaxCoupons        int64 `json:"max_coupons"`
+		MaxAmount         int64 `json:"max_amount"`
+		MaxAmountByDay    int64 `json:"max_amount_by_day"`
+		MaxCouponsPerUser int64 `json:"max_coupons_per_user"`
+	} `json:"stock_use_rule"`
+	FixedNormalCoupon *struct {
+		CouponAmount       int64 `json:"coupon_amount"`
+		TransactionMinimum int64 `json:"transaction_minimum"`
+	} `json:"fixed_normal_coupon,omitempty"`
+}
+
+// 查询代金券批次详情，stock_creator_mchid使用客户端的商户号
+func (c *ClientV3) QueryFavorStock(ctx context.Context, stockID string) (*V3FavorStock, error) {
+	res := &V3FavorStock{}
+	path := "/v3/marketing/favor/stocks/" + url.PathEscape(stockID) + "?stock_creator_mchid=" + url.QueryEscape(c.mchID)
+	if err := c.Do(ctx, http.MethodGet, path, nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// 返回用于CouponBudgetMonitor的批次查询
+func (c *ClientV3) CouponStockSource() CouponStockSource {
+	return func(ctx context.Context, stockID string) (*CouponStock, error) {
+		s, err := c.QueryFavorStock(ctx, stockID)
+		if err != nil {
+			return nil, err
+		}
+		stock := &CouponStock{
+			StockID:           s.StockID,
+			RemainingQuantity: s.StockUseRule.MaxCoupons - s.DistributedCoupons,
+			RemainingAmount:   -1,
+		}
+		if s.FixedNormalCoupon != nil {
+			stock.RemainingAmount = s.StockUseRule.MaxAmount - s.DistributedCoupons*s.FixedNormalCoupon.CouponAmount
+		}
+		return stock, nil
+	}
+}
@@ -0,0 +1,33 @@
+package wxpay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// 下载投诉详情等接口返回的图片、附件，例如
+// https://api.mch.weixin.qq.com/v3/merchant-service/images/xxx。
+// 这些地址需要APIv3签名，不能直接使用http.Get下载
+func (c *ClientV3) DownloadMedia(ctx context.Context, mediaUrl string) ([]byte, error) {
+	return c.download(ctx, mediaUrl)
+}
+
+// 对微信支付返回的完整url发送签名的GET请求，返回原始的返回体。
+// 为避免将Authorization发送给其他域名，url必须与客户端的接口地址同域
+func (c *ClientV3) download(ctx context.Context, rawUrl string) ([]byte, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(c.baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != base.Scheme || u.Host != base.Host {
+		return nil, fmt.Errorf("refusing to send signed request to %s://%s", u.Scheme, u.Host)
+	}
+	_, body, err := c.doUrl(ctx, http.MethodGet, rawUrl, u.RequestURI(), nil, nil)
+	return body, err
+}
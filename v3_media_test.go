@@ -0,0 +1,36 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientV3_DownloadMedia(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), v3AuthSchema) || r.URL.Path != "/v3/merchant-service/images/abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("\x89PNG"))
+	}))
+	defer server.Close()
+
+	client := NewClientV3("1900000001", "SERIAL", key, "")
+	client.baseUrl = server.URL
+	data, err := client.DownloadMedia(context.Background(), server.URL+"/v3/merchant-service/images/abc")
+	if err != nil || string(data) != "\x89PNG" {
+		t.Error(data, err)
+	}
+	if _, err := client.DownloadMedia(context.Background(), "https://example.com/v3/merchant-service/images/abc"); err == nil {
+		t.Error("signed request must not be sent to other hosts")
+	}
+}
@@ -0,0 +1,82 @@
+package wxpay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+)
+
+// APIv3 回调通知
+type V3Notify struct {
+	ID           string     `json:"id"`
+	CreateTime   string     `json:"create_time"`
+	EventType    string     `json:"event_type"` // 如TRANSACTION.SUCCESS、REFUND.SUCCESS
+	ResourceType string     `json:"resource_type"`
+	Summary      string     `json:"summary"`
+	Resource     V3Resource `json:"resource"`
+}
+
+// APIv3 退款结果通知解密后的内容
+type V3RefundNotify struct {
+	MchID               string `json:"mchid"`
+	TransactionID       string `json:"transaction_id"`
+	OutTradeNo          string `json:"out_trade_no"`
+	RefundID            string `json:"refund_id"`
+	OutRefundNo         string `json:"out_refund_no"`
+	RefundStatus        string `json:"refund_status"`
+	SuccessTime         string `json:"success_time"`
+	UserReceivedAccount string `json:"user_received_account"`
+	Amount              struct {
+		Total       int64 `json:"total"`
+		Refund      int64 `json:"refund"`
+		PayerTotal  int64 `json:"payer_total"`
+		PayerRefund int64 `json:"payer_refund"`
+	} `json:"amount"`
+}
+
+// 验证回调通知的签名，并将resource解密后的JSON解码到result，result为nil时不解码。
+// 时间戳超出SetNotifyMaxSkew设置的范围时返回ErrNotifyExpired，设置了ReplayCache时重复的回调返回ErrNotifyReplayed。
+// 支付成功通知的result可以使用*V3Transaction，退款通知可以使用*V3RefundNotify
+func (c *ClientV3) ParseNotify(ctx context.Context, header http.Header, body []byte, result interface{}) (*V3Notify, error) {
+	if err := c.VerifySignature(ctx, header, body); err != nil {
+		return nil, err
+	}
+	if err := c.notifyGuard.check(header, c.clock); err != nil {
+		return nil, err
+	}
+	c.observeKeyUsage(CredentialApiV3Key, KeyFingerprint([]byte(c.apiV3Key)), "notify")
+	return decodeV3Notify(c.apiV3Key, body, result)
+}
+
+// 解析已验证签名的回调通知并解密resource
+func decodeV3Notify(apiV3Key string, body []byte, result interface{}) (*V3Notify, error) {
+	notify := &V3Notify{}
+	if err := json.Unmarshal(body, notify); err != nil {
+		return nil, err
+	}
+	r := notify.Resource
+	if r.Algorithm != "AEAD_AES_256_GCM" {
+		return nil, errors.New("unsupported notify resource algorithm: " + r.Algorithm)
+	}
+	plain, err := DecryptAES256GCM(apiV3Key, r.AssociatedData, r.Nonce, r.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		if err := json.Unmarshal(plain, result); err != nil {
+			return nil, err
+		}
+	}
+	return notify, nil
+}
+
+// 读取并解析回调通知请求，参见ParseNotify
+func (c *ClientV3) ParseNotifyRequest(r *http.Request, result interface{}) (*V3Notify, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return c.ParseNotify(r.Context(), r.Header, body, result)
+}
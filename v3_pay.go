@@ -0,0 +1,192 @@
+package wxpay
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// APIv3 订单金额
+type V3Amount struct {
+	Total         int64  `json:"total"`
+	Currency      string `json:"currency,omitempty"`
+	PayerTotal    int64  `json:"payer_total,omitempty"`
+	PayerCurrency string `json:"payer_currency,omitempty"`
+}
+
+// APIv3 支付者
+type V3Payer struct {
+	OpenID string `json:"openid"`
+}
+
+// APIv3 H5场景信息
+type V3H5Info struct {
+	Type        string `json:"type"`
+	AppName     string `json:"app_name,omitempty"`
+	AppUrl      string `json:"app_url,omitempty"`
+	BundleID    string `json:"bundle_id,omitempty"`
+	PackageName string `json:"package_name,omitempty"`
+}
+
+// APIv3 场景信息
+type V3SceneInfo struct {
+	PayerClientIP string     `json:"payer_client_ip"`
+	DeviceID      string     `json:"device_id,omitempty"`
+	StoreInfo     *StoreInfo `json:"store_info,omitempty"`
+	H5Info        *V3H5Info  `json:"h5_info,omitempty"`
+}
+
+// APIv3 下单请求，MchID为空时使用客户端的商户号
+type V3TransactionRequest struct {
+	AppID       string       `json:"appid"`
+	MchID       string       `json:"mchid"`
+	Description string       `json:"description"`
+	OutTradeNo  string       `json:"out_trade_no"`
+	TimeExpire  string       `json:"time_expire,omitempty"`
+	Attach      string       `json:"attach,omitempty"`
+	NotifyUrl   string       `json:"notify_url"`
+	GoodsTag    string       `json:"goods_tag,omitempty"`
+	Amount      V3Amount     `json:"amount"`
+	Payer       *V3Payer     `json:"payer,omitempty"`
+	SceneInfo   *V3SceneInfo `json:"scene_info,omitempty"`
+}
+
+// APIv3 下单返回，不同下单方式只返回其中一个字段
+type V3PrepayResponse struct {
+	PrepayID string `json:"prepay_id,omitempty"` // JSAPI、APP
+	CodeUrl  string `json:"code_url,omitempty"`  // Native
+	H5Url    string `json:"h5_url,omitempty"`    // H5
+}
+
+// APIv3 订单
+type V3Transaction struct {
+	AppID          string       `json:"appid"`
+	MchID          string       `json:"mchid"`
+	OutTradeNo     string       `json:"out_trade_no"`
+	TransactionID  string       `json:"transaction_id"`
+	TradeType      string       `json:"trade_type"`
+	TradeState     string       `json:"trade_state"`
+	TradeStateDesc string       `json:"trade_state_desc"`
+	BankType       string       `json:"bank_type"`
+	Attach         string       `json:"attach"`
+	SuccessTime    string       `json:"success_time"`
+	Payer          *V3Payer     `json:"payer"`
+	Amount         *V3Amount    `json:"amount"`
+	SceneInfo      *V3SceneInfo `json:"scene_info"`
+}
+
+// APIv3 退款金额
+type V3RefundAmount struct {
+	Refund   int64  `json:"refund"`
+	Total    int64  `json:"total"`
+	Currency string `json:"currency"`
+}
+
+// APIv3 退款请求，TransactionID与OutTradeNo二选一
+type V3RefundRequest struct {
+	TransactionID string         `json:"transaction_id,omitempty"`
+	OutTradeNo    string         `json:"out_trade_no,omitempty"`
+	OutRefundNo   string         `json:"out_refund_no"`
+	Reason        string         `json:"reason,omitempty"`
+	NotifyUrl     string         `json:"notify_url,omitempty"`
+	FundsAccount  string         `json:"funds_account,omitempty"`
+	Amount        V3RefundAmount `json:"amount"`
+}
+
+// APIv3 退款单
+type V3Refund struct {
+	RefundID            string `json:"refund_id"`
+	OutRefundNo         string `json:"out_refund_no"`
+	TransactionID       string `json:"transaction_id"`
+	OutTradeNo          string `json:"out_trade_no"`
+	Channel             string `json:"channel"`
+	UserReceivedAccount string `json:"user_received_account"`
+	SuccessTime         string `json:"success_time"`
+	CreateTime          string `json:"create_time"`
+	Status              string `json:"status"`
+	FundsAccount        string `json:"funds_account"`
+	Amount              struct {
+		Total            int64  `json:"total"`
+		Refund           int64  `json:"refund"`
+		PayerTotal       int64  `json:"payer_total"`
+		PayerRefund      int64  `json:"payer_refund"`
+		SettlementRefund int64  `json:"settlement_refund"`
+		DiscountRefund   int64  `json:"discount_refund"`
+		Currency         string `json:"currency"`
+	} `json:"amount"`
+}
+
+func (c *ClientV3) prepay(ctx context.Context, tradeType string, req *V3TransactionRequest) (*V3PrepayResponse, error) {
+	if req.MchID == "" {
+		req.MchID = c.mchID
+	}
+	res := &V3PrepayResponse{}
+	if err := c.Do(ctx, http.MethodPost, "/v3/pay/transactions/"+tradeType, req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// JSAPI下单，公众号和小程序支付均使用此接口
+func (c *ClientV3) JSAPIPrepay(ctx context.Context, req *V3TransactionRequest) (*V3PrepayResponse, error) {
+	return c.prepay(ctx, "jsapi", req)
+}
+
+// Native下单
+func (c *ClientV3) NativePrepay(ctx context.Context, req *V3TransactionRequest) (*V3PrepayResponse, error) {
+	return c.prepay(ctx, "native", req)
+}
+
+// APP下单
+func (c *ClientV3) AppPrepay(ctx context.Context, req *V3TransactionRequest) (*V3PrepayResponse, error) {
+	return c.prepay(ctx, "app", req)
+}
+
+// H5下单
+func (c *ClientV3) H5Prepay(ctx context.Context, req *V3TransactionRequest) (*V3PrepayResponse, error) {
+	return c.prepay(ctx, "h5", req)
+}
+
+// 按微信支付订单号查询订单
+func (c *ClientV3) QueryTransactionByID(ctx context.Context, transactionID string) (*V3Transaction, error) {
+	res := &V3Transaction{}
+	path := "/v3/pay/transactions/id/" + url.PathEscape(transactionID) + "?mchid=" + url.QueryEscape(c.mchID)
+	if err := c.Do(ctx, http.MethodGet, path, nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// 按商户订单号查询订单
+func (c *ClientV3) QueryTransactionByOutTradeNo(ctx context.Context, outTradeNo string) (*V3Transaction, error) {
+	res := &V3Transaction{}
+	path := "/v3/pay/transactions/out-trade-no/" + url.PathEscape(outTradeNo) + "?mchid=" + url.QueryEscape(c.mchID)
+	if err := c.Do(ctx, http.MethodGet, path, nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// 关闭订单
+func (c *ClientV3) CloseTransaction(ctx context.Context, outTradeNo string) error {
+	path := "/v3/pay/transactions/out-trade-no/" + url.PathEscape(outTradeNo) + "/close"
+	return c.Do(ctx, http.MethodPost, path, map[string]string{"mchid": c.mchID}, nil)
+}
+
+// 申请退款
+func (c *ClientV3) Refund(ctx context.Context, req *V3RefundRequest) (*V3Refund, error) {
+	res := &V3Refund{}
+	if err := c.Do(ctx, http.MethodPost, "/v3/refund/domestic/refunds", req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// 按商户退款单号查询退款
+func (c *ClientV3) QueryRefund(ctx context.Context, outRefundNo string) (*V3Refund, error) {
+	res := &V3Refund{}
+	if err := c.Do(ctx, http.MethodGet, "/v3/refund/domestic/refunds/"+url.PathEscape(outRefundNo), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,104 @@
+package wxpay
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 默认允许的回调时间戳与本地时间之差
+const DefaultNotifyMaxSkew = 5 * time.Minute
+
+// 回调的Wechatpay-Timestamp超出允许的时间范围
+var ErrNotifyExpired = errors.New("wechatpay notify timestamp is out of range")
+
+// 回调已经处理过，可能是重放
+var ErrNotifyReplayed = errors.New("wechatpay notify is replayed")
+
+// 最近验证通过的回调，按Wechatpay-Nonce和Wechatpay-Signature去重。
+// 最多保存size条记录，超出时淘汰最早的记录；多个实例部署时每个实例只能发现发往自己的重放
+type ReplayCache struct {
+	mu   sync.Mutex
+	seen map[string]bool
+	keys []string // 按写入顺序循环使用
+	next int
+}
+
+// 创建ReplayCache，size不大于0时为10000
+func NewReplayCache(size int) *ReplayCache {
+	if size <= 0 {
+		size = 10000
+	}
+	return &ReplayCache{seen: make(map[string]bool, size), keys: make([]string, size)}
+}
+
+// 记录key，返回key是否已经记录过
+func (r *ReplayCache) observe(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen[key] {
+		return true
+	}
+	if old := r.keys[r.next]; old != "" {
+		delete(r.seen, old)
+	}
+	r.keys[r.next] = key
+	r.next = (r.next + 1) % len(r.keys)
+	r.seen[key] = true
+	return false
+}
+
+// 回调的时间戳和重放检查，零值使用DefaultNotifyMaxSkew且不检查重放
+type notifyGuard struct {
+	maxSkew time.Duration // 小于0表示不检查时间戳
+	replay  *ReplayCache
+}
+
+// 检查已验证签名的回调
+func (g *notifyGuard) check(header http.Header, clock Clock) error {
+	maxSkew := g.maxSkew
+	if maxSkew == 0 {
+		maxSkew = DefaultNotifyMaxSkew
+	}
+	if maxSkew > 0 {
+		ts, err := strconv.ParseInt(header.Get("Wechatpay-Timestamp"), 10, 64)
+		if err != nil {
+			return ErrNotifyExpired
+		}
+		skew := clockOrSystem(clock).Now().Sub(time.Unix(ts, 0))
+		if skew > maxSkew || skew < -maxSkew {
+			return ErrNotifyExpired
+		}
+	}
+	if g.replay != nil && g.replay.observe(header.Get("Wechatpay-Nonce")+"\n"+header.Get("Wechatpay-Signature")) {
+		return ErrNotifyReplayed
+	}
+	return nil
+}
+
+// 设置回调的Wechatpay-Timestamp与本地时间之差的上限，0表示DefaultNotifyMaxSkew，小于0表示不检查
+func (c *ClientV3) SetNotifyMaxSkew(d time.Duration) {
+	c.notifyGuard.maxSkew = d
+}
+
+// 设置检查回调重放的ReplayCache，nil表示不检查
+func (c *ClientV3) SetNotifyReplayCache(r *ReplayCache) {
+	c.notifyGuard.replay = r
+}
+
+// 设置回调的Wechatpay-Timestamp与本地时间之差的上限，参见ClientV3.SetNotifyMaxSkew
+func (v *Verifier) SetNotifyMaxSkew(d time.Duration) {
+	v.notifyGuard.maxSkew = d
+}
+
+// 设置检查回调重放的ReplayCache，nil表示不检查
+func (v *Verifier) SetNotifyReplayCache(r *ReplayCache) {
+	v.notifyGuard.replay = r
+}
+
+// 设置检查回调时间戳使用的时钟，nil表示使用SystemClock
+func (v *Verifier) SetClock(clock Clock) {
+	v.clock = clock
+}
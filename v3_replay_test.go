@@ -0,0 +1,101 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVerifier_NotifyReplayProtection(t *testing.T) {
+	platform := newTestPlatform(t, "5157F09EFDC096DE15EBE81A47057A7232F1B8E1")
+	verifier := NewVerifier("", testApiV3Key)
+	cert, _ := parseCertificate(platform.cert)
+	verifier.AddPlatformCert(platform.serial, cert)
+	now := time.Unix(1600000000, 0)
+	verifier.SetClock(&fakeClock{now: now})
+
+	block, _ := aes.NewCipher([]byte(testApiV3Key))
+	gcm, _ := cipher.NewGCM(block)
+	ciphertext := gcm.Seal(nil, []byte("fdasflkja484"), []byte(`{}`), []byte("refund"))
+	body, _ := json.Marshal(map[string]interface{}{
+		"event_type": "REFUND.SUCCESS",
+		"resource": map[string]string{
+			"algorithm":       "AEAD_AES_256_GCM",
+			"nonce":           "fdasflkja484",
+			"associated_data": "refund",
+			"ciphertext":      base64.StdEncoding.EncodeToString(ciphertext),
+		},
+	})
+	signed := func(at time.Time, nonce string) http.Header {
+		w := httptest.NewRecorder()
+		platform.writeAt(w, body, at, nonce)
+		return w.Header()
+	}
+	parse := func(header http.Header) error {
+		_, err := verifier.ParseV3Notify(header, body, nil)
+		return err
+	}
+
+	// 默认允许5分钟的偏差
+	if err := parse(signed(now.Add(-4*time.Minute), "A")); err != nil {
+		t.Error(err)
+	}
+	if err := parse(signed(now.Add(-6*time.Minute), "B")); err != ErrNotifyExpired {
+		t.Error(err)
+	}
+	if err := parse(signed(now.Add(6*time.Minute), "B")); err != ErrNotifyExpired {
+		t.Error(err)
+	}
+	verifier.SetNotifyMaxSkew(10 * time.Minute)
+	if err := parse(signed(now.Add(-6*time.Minute), "B")); err != nil {
+		t.Error(err)
+	}
+
+	// 设置ReplayCache后重复的回调被拒绝，超出容量的旧记录被淘汰
+	verifier.SetNotifyReplayCache(NewReplayCache(2))
+	first := signed(now, "C")
+	if err := parse(first); err != nil {
+		t.Error(err)
+	}
+	if err := parse(first); err != ErrNotifyReplayed {
+		t.Error(err)
+	}
+	if err := parse(signed(now, "D")); err != nil {
+		t.Error(err)
+	}
+	if err := parse(signed(now, "E")); err != nil {
+		t.Error(err)
+	}
+	if err := parse(first); err != nil {
+		t.Error("evicted notify", err)
+	}
+}
+
+func TestClientV3_NotifyExpired(t *testing.T) {
+	platform := newTestPlatform(t, "5157F09EFDC096DE15EBE81A47057A7232F1B8E1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		platform.write(w, platform.certificates(t))
+	}))
+	defer server.Close()
+	client := NewClientV3("1900000001", "SERIAL", platform.key, testApiV3Key)
+	client.baseUrl = server.URL
+	NewPlatformCertManager(client)
+
+	body := []byte(`{}`)
+	w := httptest.NewRecorder()
+	platform.writeAt(w, body, time.Now().Add(-time.Hour), "A")
+	if _, err := client.ParseNotify(context.Background(), w.Header(), body, nil); err != ErrNotifyExpired {
+		t.Error(err)
+	}
+	client.SetNotifyMaxSkew(-1)
+	if _, err := client.ParseNotify(context.Background(), w.Header(), body, nil); err == ErrNotifyExpired || err == nil {
+		// body没有resource，时间戳检查通过后解密失败
+		t.Error(err)
+	}
+}
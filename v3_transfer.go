@@ -0,0 +1,152 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// APIv3 发起商家转账的请求
+type V3TransferBatchRequest struct {
+	AppID              string             `json:"appid"`
+	OutBatchNo         string             `json:"out_batch_no"`
+	BatchName          string             `json:"batch_name"`
+	BatchRemark        string             `json:"batch_remark"`
+	TotalAmount        int64              `json:"total_amount"`
+	TotalNum           int                `json:"total_num"`
+	TransferDetailList []V3TransferDetail `json:"transfer_detail_list"`
+}
+
+// APIv3 转账明细。发起转账时UserName填写明文姓名，由TransferBatch加密；
+// 查询明细时返回的加密姓名已由QueryTransferDetail解密
+type V3TransferDetail struct {
+	OutDetailNo    string `json:"out_detail_no"`
+	TransferAmount int64  `json:"transfer_amount"`
+	TransferRemark string `json:"transfer_remark"`
+	OpenID         string `json:"openid"`
+	UserName       string `json:"user_name,omitempty"`
+	OutBatchNo     string `json:"out_batch_no,omitempty"`
+	DetailID       string `json:"detail_id,omitempty"`
+	DetailStatus   string `json:"detail_status,omitempty"`
+	FailReason     string `json:"fail_reason,omitempty"`
+	InitiateTime   string `json:"initiate_time,omitempty"`
+	UpdateTime     string `json:"update_time,omitempty"`
+}
+
+// APIv3 发起商家转账的返回
+type V3TransferBatch struct {
+	OutBatchNo string `json:"out_batch_no"`
+	BatchID    string `json:"batch_id"`
+	CreateTime string `json:"create_time"`
+}
+
+// 使用最新的平台证书加密转账收款用户姓名，返回密文及应设置为Wechatpay-Serial请求头的证书序列号。
+// 尚未下载平台证书时先下载
+func (c *ClientV3) EncryptTransferUserName(ctx context.Context, name string) (ciphertext string, serial string, err error) {
+	serial, cert, err := c.encryptionCert(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	ciphertext, err = encryptSensitive(cert, name)
+	if err != nil {
+		return "", "", err
+	}
+	return ciphertext, serial, nil
+}
+
+// 返回加密敏感字段使用的平台证书，尚未下载平台证书时先下载
+func (c *ClientV3) encryptionCert(ctx context.Context) (string, *x509.Certificate, error) {
+	if c.certs == nil {
+		return "", nil, errors.New("platform certificate manager is not set")
+	}
+	serial, cert, err := c.certs.Newest()
+	if errors.Is(err, ErrCertNotFound) {
+		if err := c.certs.Refresh(ctx); err != nil {
+			return "", nil, err
+		}
+		return c.certs.Newest()
+	}
+	return serial, cert, err
+}
+
+// 使用商户私钥解密返回中的敏感字段，例如转账明细中的user_name
+func (c *ClientV3) DecryptSensitive(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, c.privateKey, data, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// 使用平台证书的公钥进行RSAES-OAEP加密，返回base64编码的密文
+func encryptSensitive(cert *x509.Certificate, plaintext string) (string, error) {
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return "", errors.New("platform certificate key is not RSA")
+	}
+	data, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, key, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// 发起商家转账。明细中的UserName会使用平台证书加密，并设置对应的Wechatpay-Serial请求头，req不会被修改
+func (c *ClientV3) TransferBatch(ctx context.Context, req *V3TransferBatchRequest) (*V3TransferBatch, error) {
+	body := *req
+	body.TransferDetailList = make([]V3TransferDetail, len(req.TransferDetailList))
+	var (
+		header http.Header
+		cert   *x509.Certificate
+	)
+	for i, detail := range req.TransferDetailList {
+		if detail.UserName != "" {
+			// 所有姓名使用同一张平台证书加密，与Wechatpay-Serial一致
+			if cert == nil {
+				serial, newest, err := c.encryptionCert(ctx)
+				if err != nil {
+					return nil, err
+				}
+				header, cert = http.Header{"Wechatpay-Serial": {serial}}, newest
+			}
+			ciphertext, err := encryptSensitive(cert, detail.UserName)
+			if err != nil {
+				return nil, err
+			}
+			detail.UserName = ciphertext
+		}
+		body.TransferDetailList[i] = detail
+	}
+	res := &V3TransferBatch{}
+	if err := c.doWithHeader(ctx, http.MethodPost, "/v3/transfer/batches", header, &body, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// 按商户批次单号和商户明细单号查询转账明细，返回的收款用户姓名已解密
+func (c *ClientV3) QueryTransferDetail(ctx context.Context, outBatchNo string, outDetailNo string) (*V3TransferDetail, error) {
+	res := &V3TransferDetail{}
+	path := "/v3/transfer/batches/out-batch-no/" + url.PathEscape(outBatchNo) + "/details/out-detail-no/" + url.PathEscape(outDetailNo)
+	if err := c.Do(ctx, http.MethodGet, path, nil, res); err != nil {
+		return nil, err
+	}
+	if res.UserName != "" {
+		name, err := c.DecryptSensitive(res.UserName)
+		if err != nil {
+			return nil, err
+		}
+		res.UserName = name
+	}
+	return res, nil
+}
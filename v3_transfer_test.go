@@ -0,0 +1,87 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientV3_TransferBatch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	platform := newTestPlatform(t, "PLATFORM_SERIAL")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			if r.Header.Get("Wechatpay-Serial") != platform.serial {
+				t.Error(r.Header)
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			req := &V3TransferBatchRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				t.Fatal(err)
+			}
+			ciphertext, _ := base64.StdEncoding.DecodeString(req.TransferDetailList[0].UserName)
+			name, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, platform.key, ciphertext, nil)
+			if err != nil || string(name) != "张三" || req.TransferDetailList[1].UserName != "" {
+				t.Error(req, err)
+			}
+			platform.write(w, []byte(`{"out_batch_no":"plfk2020042013","batch_id":"1030000071100999991182020050700019480001"}`))
+		case http.MethodGet:
+			if r.URL.Path != "/v3/transfer/batches/out-batch-no/plfk2020042013/details/out-detail-no/x23zy545Bd5436" {
+				t.Error(r.URL)
+			}
+			ciphertext, _ := rsa.EncryptOAEP(sha1.New(), rand.Reader, &key.PublicKey, []byte("张三"), nil)
+			platform.write(w, []byte(`{"out_detail_no":"x23zy545Bd5436","detail_status":"SUCCESS","user_name":"`+base64.StdEncoding.EncodeToString(ciphertext)+`"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientV3("1900000001", "SERIAL", key, testApiV3Key)
+	client.baseUrl = server.URL
+	if _, _, err := client.EncryptTransferUserName(context.Background(), "张三"); err == nil {
+		t.Error("expected error without platform certificates")
+	}
+	cert, err := parseCertificate(platform.cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewPlatformCertManager(client).AddCertificate(platform.serial, cert); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	req := &V3TransferBatchRequest{
+		AppID:       "wxf636efh567hg4356",
+		OutBatchNo:  "plfk2020042013",
+		BatchName:   "2019年1月深圳分部报销单",
+		BatchRemark: "2019年1月深圳分部报销单",
+		TotalAmount: 2000,
+		TotalNum:    2,
+		TransferDetailList: []V3TransferDetail{
+			{OutDetailNo: "x23zy545Bd5436", TransferAmount: 1000, TransferRemark: "报销", OpenID: "o-MYE42l80oelYMDE34nYD456Xoy", UserName: "张三"},
+			{OutDetailNo: "x23zy545Bd5437", TransferAmount: 1000, TransferRemark: "报销", OpenID: "o-MYE42l80oelYMDE34nYD456Xoz"},
+		},
+	}
+	res, err := client.TransferBatch(ctx, req)
+	if err != nil || res.BatchID != "1030000071100999991182020050700019480001" {
+		t.Fatal(res, err)
+	}
+	if req.TransferDetailList[0].UserName != "张三" {
+		t.Error("request must not be modified")
+	}
+
+	detail, err := client.QueryTransferDetail(ctx, "plfk2020042013", "x23zy545Bd5436")
+	if err != nil || detail.UserName != "张三" || detail.DetailStatus != "SUCCESS" {
+		t.Error(detail, err)
+	}
+}
@@ -0,0 +1,113 @@
+package wxpay
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// 只用于验证回调和解密数据的轻量客户端，不需要商户证书和私钥，也不发送任何请求，
+// 适合部署在只处理回调的边缘服务上
+type Verifier struct {
+	apiKey      string // v2 API密钥
+	signType    string // v2 通知没有sign_type字段时使用的签名类型
+	apiV3Key    string // APIv3密钥
+	clock       Clock
+	notifyGuard notifyGuard
+
+	mu    sync.RWMutex
+	certs map[string]*x509.Certificate // 平台证书，序列号 -> 证书
+}
+
+// 创建Verifier，不使用v2或APIv3时对应的密钥可以为空
+func NewVerifier(apiKey string, apiV3Key string) *Verifier {
+	return &Verifier{
+		apiKey:   apiKey,
+		signType: MD5,
+		apiV3Key: apiV3Key,
+		certs:    make(map[string]*x509.Certificate),
+	}
+}
+
+// 设置v2通知没有sign_type字段时使用的签名类型
+func (v *Verifier) SetSignType(signType string) {
+	v.signType = signType
+}
+
+// 添加平台证书，serial为空时使用证书的序列号
+func (v *Verifier) AddPlatformCert(serial string, cert *x509.Certificate) {
+	if serial == "" {
+		serial = fmt.Sprintf("%X", cert.SerialNumber)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.certs[serial] = cert
+}
+
+// 添加PEM格式的平台证书，pemData可以包含多个证书，例如PlatformCertManager.Export导出的数据
+func (v *Verifier) AddPlatformCertPEM(pemData []byte) error {
+	certs, err := parseCertificates(pemData)
+	if err != nil {
+		return err
+	}
+	for _, cert := range certs {
+		v.AddPlatformCert("", cert)
+	}
+	return nil
+}
+
+// 验证v2签名
+func (v *Verifier) ValidSign(params Params) bool {
+	if !params.ContainsKey(Sign) {
+		return false
+	}
+	signType := params.GetString("sign_type")
+	if signType == "" {
+		signType = v.signType
+	}
+	return params.GetString(Sign) == signParams(params, signType, v.apiKey)
+}
+
+// 解析v2支付结果通知并验证签名
+func (v *Verifier) ParseNotification(xmlStr string) (*Notification, error) {
+	params, err := XmlToMap(xmlStr)
+	if err != nil {
+		return nil, err
+	}
+	if params.GetString("return_code") != Success {
+		return nil, errors.New("notify return_code is not SUCCESS: " + params.GetString("return_msg"))
+	}
+	if !v.ValidSign(params) {
+		return nil, errors.New("invalid sign value in XML")
+	}
+	return NewNotification(params)
+}
+
+// 解密v2退款结果通知
+func (v *Verifier) DecryptRefundNotify(xmlStr string) (Params, error) {
+	return decryptRefundNotify(v.apiKey, xmlStr)
+}
+
+// 使用平台证书验证APIv3回调的签名
+func (v *Verifier) VerifySignature(header http.Header, body []byte) error {
+	v.mu.RLock()
+	cert, ok := v.certs[header.Get("Wechatpay-Serial")]
+	v.mu.RUnlock()
+	if !ok {
+		return ErrCertNotFound
+	}
+	return verifyV3Signature(cert, header, body)
+}
+
+// 验证APIv3回调的签名并解密resource，参见ClientV3.ParseNotify
+func (v *Verifier) ParseV3Notify(header http.Header, body []byte, result interface{}) (*V3Notify, error) {
+	if err := v.VerifySignature(header, body); err != nil {
+		return nil, err
+	}
+	if err := v.notifyGuard.check(header, v.clock); err != nil {
+		return nil, err
+	}
+	return decodeV3Notify(v.apiV3Key, body, result)
+}